@@ -4,10 +4,13 @@ import (
 	"context"
 	"watchAlert/alert/consumer"
 	"watchAlert/alert/eval"
+	"watchAlert/alert/health"
 	probing "watchAlert/alert/probe"
 	"watchAlert/config"
 	"watchAlert/internal/ctx"
 	"watchAlert/pkg/client"
+	mediums "watchAlert/pkg/medium"
+	"watchAlert/pkg/metrics"
 	"watchAlert/pkg/tools"
 
 	"github.com/zeromicro/go-zero/core/logc"
@@ -21,6 +24,8 @@ var (
 
 	RecordingRule eval.RecordingRuleEval
 
+	DatasourceHealth *health.DatasourceMonitor
+
 	// Leader 选举器
 	LeaderElector *tools.LeaderElector
 
@@ -29,6 +34,12 @@ var (
 
 	// 选举开关
 	leaderElectionEnabled bool
+
+	// 集群成员, 分片模式下使用
+	Cluster *tools.ClusterMember
+
+	// 分片开关
+	shardingEnabled bool
 )
 
 func Initialize(ctx *ctx.Context) {
@@ -42,10 +53,24 @@ func Initialize(ctx *ctx.Context) {
 	// 初始化记录规则评估任务
 	RecordingRule = eval.NewRecordingRuleEval(ctx)
 
-	// 检查 Leader 选举是否启用
-	leaderElectionEnabled = config.Application.Server.EnableElection
+	// 初始化数据源健康检查任务
+	DatasourceHealth = health.NewDatasourceMonitor(ctx, IsOwner)
+
+	// 启动通知发送队列, 各节点均可发送及重试通知
+	mediums.StartNoticeQueue(ctx)
+
+	// 启动限流摘要消息的发送任务
+	consumer.StartNoticeDigest(ctx)
+
+	// 检查分片及 Leader 选举是否启用, 分片优先
+	shardingEnabled = config.Application.Server.EnableSharding
+	leaderElectionEnabled = config.Application.Server.EnableElection && !shardingEnabled
 
-	if leaderElectionEnabled {
+	if shardingEnabled {
+		// 启用分片模式, 任务按一致性哈希分配到各节点
+		logc.Infof(ctx.Ctx, "分片模式已启用，加入集群...")
+		startSharding(ctx)
+	} else if leaderElectionEnabled {
 		// 启用 Leader 选举模式
 		logc.Infof(ctx.Ctx, "Leader 选举已启用，开始选举流程...")
 		LeaderElector = tools.NewLeaderElector(
@@ -59,6 +84,9 @@ func Initialize(ctx *ctx.Context) {
 	} else {
 		loadRules()
 	}
+
+	// 注册事件数及 Leader 状态指标
+	registerMetrics()
 }
 
 // loadRules 加载所有规则(成为 Leader 时调用)
@@ -79,6 +107,9 @@ func loadRules() {
 		logc.Errorf(ctx.Ctx, "重启拨测任务失败: %v", err)
 	}
 
+	// 启动数据源健康检查
+	DatasourceHealth.Start()
+
 	// 启动 Redis 消息订阅，监听规则变更
 	startMessageSubscribers()
 }
@@ -119,6 +150,18 @@ func stopMessageSubscribers() {
 
 // handleRuleReload 处理告警规则重载消息
 func handleRuleReload(msg tools.ReloadMessage) {
+	// 仅处理当前节点负责的任务
+	if !IsOwner(msg.ID) {
+		return
+	}
+
+	// 已删除的规则在数据库中不存在, 直接停止
+	if msg.Action == tools.ActionDelete {
+		AlertRule.Stop(msg.ID)
+		metrics.DeleteRule(msg.ID)
+		logc.Infof(ctx.Ctx, "[Leader] 已停止规则评估: %s", msg.Name)
+		return
+	}
 
 	// 从数据库获取规则
 	rule := ctx.DB.Rule().GetRuleObject(msg.ID)
@@ -149,6 +192,18 @@ func handleRuleReload(msg tools.ReloadMessage) {
 
 // handleRecordingRuleReload 处理记录规则重载消息
 func handleRecordingRuleReload(msg tools.ReloadMessage) {
+	// 仅处理当前节点负责的任务
+	if !IsOwner(msg.ID) {
+		return
+	}
+
+	// 已删除的规则在数据库中不存在, 直接停止
+	if msg.Action == tools.ActionDelete {
+		RecordingRule.Stop(msg.ID)
+		logc.Infof(ctx.Ctx, "[Leader] 已停止记录规则评估: %s", msg.Name)
+		return
+	}
+
 	rule := ctx.DB.RecordingRule().GetRuleObject(msg.ID)
 	if rule.RuleId == "" {
 		logc.Errorf(ctx.Ctx, "记录规则不存在: %s", msg.ID)
@@ -177,6 +232,18 @@ func handleRecordingRuleReload(msg tools.ReloadMessage) {
 
 // handleFaultCenterReload 处理故障中心重载消息
 func handleFaultCenterReload(msg tools.ReloadMessage) {
+	// 仅处理当前节点负责的任务
+	if !IsOwner(msg.ID) {
+		return
+	}
+
+	// 已删除的故障中心在数据库中不存在, 直接停止
+	if msg.Action == tools.ActionDelete {
+		ConsumerWork.Stop(msg.ID)
+		logc.Infof(ctx.Ctx, "[Leader] 已停止故障中心消费: %s", msg.Name)
+		return
+	}
+
 	fc, err := ctx.DB.FaultCenter().Get(msg.TenantID, msg.ID, "")
 	if err != nil {
 		logc.Errorf(ctx.Ctx, "故障中心不存在: %s, err: %v", msg.ID, err)
@@ -201,6 +268,19 @@ func handleFaultCenterReload(msg tools.ReloadMessage) {
 
 // handleProbingReload 处理拨测规则重载消息
 func handleProbingReload(msg tools.ReloadMessage) {
+	// 仅处理当前节点负责的任务
+	if !IsOwner(msg.ID) {
+		return
+	}
+
+	// 已删除的拨测规则在数据库中不存在, 直接停止
+	if msg.Action == tools.ActionDelete {
+		if err := Probe.Stop(msg.ID); err != nil {
+			logc.Errorf(ctx.Ctx, "[Leader] 停止拨测任务失败: %s, err: %v", msg.Name, err)
+		}
+		return
+	}
+
 	rule, err := ctx.DB.Probing().Search(msg.TenantID, msg.ID)
 	if err != nil {
 		logc.Errorf(ctx.Ctx, "拨测规则不存在: %s, err: %v", msg.ID, err)
@@ -257,6 +337,18 @@ func unloadRules() {
 	if err := Probe.StopAll(); err != nil {
 		logc.Errorf(ctx.Ctx, "停止所有拨测任务失败: %v", err)
 	}
+
+	// 停止数据源健康检查
+	DatasourceHealth.Stop()
+}
+
+// IsOwner 判断当前节点是否负责该任务, 分片模式下按一致性哈希分配, 否则由 Leader 负责
+func IsOwner(id string) bool {
+	if shardingEnabled {
+		return Cluster.Owns(id)
+	}
+
+	return IsLeader()
 }
 
 // IsLeader 判断节点角色
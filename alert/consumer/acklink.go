@@ -0,0 +1,98 @@
+package consumer
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"watchAlert/internal/ctx"
+	"watchAlert/internal/models"
+	mediums "watchAlert/pkg/medium"
+	"watchAlert/pkg/tools"
+
+	"github.com/bytedance/sonic"
+	"github.com/zeromicro/go-zero/core/logc"
+)
+
+// sendNotice 发送通知, 开启一键认领链接时邮件及短信按接收人拆分发送, 每个接收人的链接绑定其身份
+func sendNotice(ctx *ctx.Context, event *models.AlertCurEvent, params mediums.SendParams) error {
+	if event.IsRecovered || event.ConfirmState.IsOk || (params.NoticeType != "Email" && params.NoticeType != "SMS") {
+		return mediums.Sender(ctx, params)
+	}
+
+	setting, err := ctx.DB.Setting().Get()
+	if err != nil || setting.ChatOpsConfig.SiteUrl == "" || !setting.ChatOpsConfig.AckLink.GetEnable() {
+		return mediums.Sender(ctx, params)
+	}
+
+	var (
+		config = setting.ChatOpsConfig
+		errs   []error
+	)
+	switch params.NoticeType {
+	case "Email":
+		for _, to := range params.Email.To {
+			p := params
+			p.Email = models.Email{Subject: params.Email.Subject, To: []string{to}}
+			if url := newAckLinkUrl(ctx, config, event, getRecipientName(ctx, to, "")); url != "" {
+				p.Content += fmt.Sprintf(`<p><a href="%s">一键认领</a>（%d 分钟内有效, 仅可使用一次）</p>`,
+					html.EscapeString(url), int(config.AckLink.GetExpire().Minutes()))
+			}
+			errs = append(errs, mediums.Sender(ctx, p))
+		}
+		// 抄送人不绑定认领链接
+		if len(params.Email.CC) > 0 {
+			p := params
+			p.Email = models.Email{Subject: params.Email.Subject, CC: params.Email.CC}
+			errs = append(errs, mediums.Sender(ctx, p))
+		}
+	case "SMS":
+		for _, to := range params.SMS.To {
+			p := params
+			p.SMS = models.SMS{To: []string{to}}
+			// 短信内容为事件 JSON, 短信模版中可通过 ackUrl 变量引用链接
+			if url := newAckLinkUrl(ctx, config, event, getRecipientName(ctx, "", to)); url != "" {
+				content := make(map[string]interface{})
+				if err := sonic.UnmarshalString(params.Content, &content); err == nil {
+					content["ackUrl"] = url
+					p.Content = tools.JsonMarshalToString(content)
+				}
+			}
+			errs = append(errs, mediums.Sender(ctx, p))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// newAckLinkUrl 生成接收人的一键认领链接
+func newAckLinkUrl(ctx *ctx.Context, config models.ChatOpsConfig, event *models.AlertCurEvent, recipient string) string {
+	key, err := tools.AckSignKey(ctx.Redis.Redis())
+	if err != nil {
+		logc.Error(ctx.Ctx, fmt.Sprintf("Failed to get ack link sign key: %v", err))
+		return ""
+	}
+
+	token, err := tools.GenerateAckToken(key, tools.AckLinkClaims{
+		TenantId:      event.TenantId,
+		FaultCenterId: event.FaultCenterId,
+		Fingerprint:   event.Fingerprint,
+		Username:      recipient,
+	}, config.AckLink.GetExpire())
+	if err != nil {
+		logc.Error(ctx.Ctx, fmt.Sprintf("Failed to generate ack token: %v", err))
+		return ""
+	}
+
+	return config.GetAckLinkUrl(token)
+}
+
+// getRecipientName 根据邮箱或手机号获取接收人的用户名, 非平台用户使用联系方式
+func getRecipientName(ctx *ctx.Context, email, phone string) string {
+	if member, ok, _ := ctx.DB.User().Get("", "", email, phone); ok {
+		return member.UserName
+	}
+	if email != "" {
+		return email
+	}
+	return phone
+}
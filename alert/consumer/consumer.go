@@ -5,11 +5,14 @@ import (
 	"fmt"
 	"regexp"
 	"runtime/debug"
+	"slices"
 	"sync"
 	"time"
+	"watchAlert/alert/mute"
 	"watchAlert/alert/process"
 	"watchAlert/internal/ctx"
 	"watchAlert/internal/models"
+	"watchAlert/pkg/metrics"
 
 	"github.com/zeromicro/go-zero/core/logc"
 	"golang.org/x/sync/errgroup"
@@ -39,6 +42,8 @@ type (
 	Consume struct {
 		ctx *ctx.Context
 		sync.RWMutex
+		// silenceIds 各故障中心上一次处理的静默规则, 用于清理已删除静默规则的指标
+		silenceIds sync.Map
 	}
 
 	EventsGroup struct {
@@ -119,12 +124,18 @@ func NewConsumerWork(ctx *ctx.Context) ConsumeInterface {
 	}
 }
 
+// Submit 启动故障中心消费任务, 已存在的任务先取消
 func (c *Consume) Submit(faultCenter models.FaultCenter) {
 	c.ctx.Mux.Lock()
 	defer c.ctx.Mux.Unlock()
 
+	if cancel, exists := c.ctx.ContextMap[faultCenter.ID]; exists {
+		cancel()
+	}
+
 	withCtx, cancel := context.WithCancel(context.Background())
 	c.ctx.ContextMap[faultCenter.ID] = cancel
+	c.ctx.TaskVersions[faultCenter.ID] = faultCenter.ConfigVersion()
 	go c.Watch(withCtx, faultCenter)
 }
 
@@ -135,6 +146,7 @@ func (c *Consume) Stop(faultCenterId string) {
 	if cancel, exists := c.ctx.ContextMap[faultCenterId]; exists {
 		cancel()
 		delete(c.ctx.ContextMap, faultCenterId)
+		delete(c.ctx.TaskVersions, faultCenterId)
 	}
 }
 
@@ -184,20 +196,31 @@ func (c *Consume) executeTask(faultCenter models.FaultCenter, taskChan chan stru
 		return
 	}
 
+	// 处理外部推送事件的待恢复状态
+	c.processExternalRecover(data)
 	// 事件过滤
 	filterEvents := c.filterAlertEvents(faultCenter, data)
-	// 事件分组
-	alertGroups := AlertGroups{
-		Rules: make(map[string]RulesGroup),
+	// 事件抑制
+	filterEvents = c.filterInhibitedEvents(faultCenter, data, filterEvents)
+	if faultCenter.IsLabelGrouping() {
+		// 按标签分组发送事件
+		c.sendGroupedAlerts(faultCenter, filterEvents)
+	} else {
+		// 事件分组
+		alertGroups := AlertGroups{
+			Rules: make(map[string]RulesGroup),
+		}
+		c.alarmGrouping(faultCenter, &alertGroups, filterEvents)
+		// 发送事件
+		c.sendAlerts(faultCenter, &alertGroups)
 	}
-	c.alarmGrouping(faultCenter, &alertGroups, filterEvents)
-	// 发送事件
-	c.sendAlerts(faultCenter, &alertGroups)
 	// 处理告警升级
 	err = alarmUpgrade(c.ctx, faultCenter, data)
 	if err != nil {
 		logc.Error(c.ctx.Ctx, fmt.Sprintf("process alarm upgeade fail, err: %s", err.Error()))
 	}
+	// 创建工单
+	processTickets(c.ctx, faultCenter, data)
 }
 
 // filterAlertEvents 过滤告警事件
@@ -217,12 +240,17 @@ func (c *Consume) filterAlertEvents(faultCenter models.FaultCenter, alerts map[s
 		// 记录恢复状态的事件
 		if event.IsRecovered {
 			c.removeAlertFromCache(event)
-			if err := process.RecordAlertHisEvent(c.ctx, *event); err != nil {
-				logc.Error(c.ctx.Ctx, fmt.Sprintf("Failed to record alert history: %v", err))
+			resolveTicket(c.ctx, faultCenter, event)
+			// 通用事件接口在恢复时已记录历史
+			if event.DatasourceType != models.ExternalSourceEvents {
+				if err := process.RecordAlertHisEvent(c.ctx, *event); err != nil {
+					logc.Error(c.ctx.Ctx, fmt.Sprintf("Failed to record alert history: %v", err))
+				}
 			}
 		}
 
-		if valid := c.validateEvent(event, faultCenter); valid {
+		// 标签分组的通知间隔由分组策略控制
+		if valid := faultCenter.IsLabelGrouping() || c.validateEvent(event, faultCenter); valid {
 			newEvents = append(newEvents, event)
 		}
 	}
@@ -230,6 +258,39 @@ func (c *Consume) filterAlertEvents(faultCenter models.FaultCenter, alerts map[s
 	return newEvents
 }
 
+// processExternalRecover 外部推送的事件没有评估器推进状态，在此处完成待恢复 -> 已恢复的转换
+// 超过 endsAt 仍未再次推送的事件视为已恢复
+func (c *Consume) processExternalRecover(alerts map[string]*models.AlertCurEvent) {
+	now := time.Now().Unix()
+	for _, event := range alerts {
+		if !event.IsExternalEvent() {
+			continue
+		}
+		if event.Status != models.StatePendingRecovery && (event.EndsAt == 0 || event.EndsAt > now || event.Status == models.StateRecovered) {
+			continue
+		}
+		process.ResolveExternalEvent(c.ctx, event.TenantId, event.FaultCenterId, event.Fingerprint)
+	}
+}
+
+// filterInhibitedEvents 过滤被抑制规则抑制的事件
+func (c *Consume) filterInhibitedEvents(faultCenter models.FaultCenter, data map[string]*models.AlertCurEvent, alerts []*models.AlertCurEvent) []*models.AlertCurEvent {
+	if len(faultCenter.InhibitRules) == 0 {
+		return alerts
+	}
+
+	var newEvents []*models.AlertCurEvent
+	for _, event := range alerts {
+		if source, ok := mute.IsInhibited(faultCenter.InhibitRules, event, data); ok {
+			logc.Infof(c.ctx.Ctx, "事件已被抑制, 规则名称: %s, 指纹: %s, 抑制源指纹: %s", event.RuleName, event.Fingerprint, source)
+			continue
+		}
+		newEvents = append(newEvents, event)
+	}
+
+	return newEvents
+}
+
 // validateEvent 事件验证
 func (c *Consume) validateEvent(event *models.AlertCurEvent, faultCenter models.FaultCenter) bool {
 	return event.IsRecovered || event.LastSendTime == 0 ||
@@ -308,6 +369,15 @@ func (c *Consume) processSilenceRule(faultCenter models.FaultCenter) {
 		return
 	}
 
+	// 清理已删除静默规则的命中次数
+	if last, ok := c.silenceIds.Swap(faultCenter.ID, silenceIds); ok {
+		for _, silenceId := range last.([]string) {
+			if !slices.Contains(silenceIds, silenceId) {
+				metrics.DeleteSilence(faultCenter.ID, silenceId)
+			}
+		}
+	}
+
 	// 根据ID获取到详细的静默规则
 	for _, silenceId := range silenceIds {
 		muteRule, err := silenceCtx.WithIdGetMuteFromCache(faultCenter.TenantId, faultCenter.ID, silenceId)
@@ -356,6 +426,7 @@ func (c *Consume) StopAllConsumers() {
 	for fcId, cancel := range c.ctx.ContextMap {
 		cancel()
 		delete(c.ctx.ContextMap, fcId)
+		delete(c.ctx.TaskVersions, fcId)
 	}
 
 	logc.Infof(c.ctx.Ctx, "所有故障中心消费者已停止")
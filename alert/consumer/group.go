@@ -0,0 +1,234 @@
+package consumer
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+	"watchAlert/alert/mute"
+	"watchAlert/internal/models"
+	"watchAlert/pkg/tools"
+
+	"github.com/zeromicro/go-zero/core/logc"
+)
+
+// labelGroup 当前周期内按通知对象及分组标签划分的事件
+type labelGroup struct {
+	noticeId string
+	labels   map[string]interface{}
+	firing   []*models.AlertCurEvent
+	resolved []*models.AlertCurEvent
+}
+
+// sendGroupedAlerts 按标签分组发送告警
+// 新分组等待 group_wait 后首次通知, 分组内事件变化后按 group_interval 通知, 无变化时按 repeat_interval 重复通知。
+func (c *Consume) sendGroupedAlerts(faultCenter models.FaultCenter, alerts []*models.AlertCurEvent) {
+	var (
+		curTime  = time.Now().Unix()
+		strategy = faultCenter.GroupStrategy
+		states   = c.ctx.Redis.AlertGroup().List(faultCenter.TenantId, faultCenter.ID)
+		groups   = make(map[string]*labelGroup)
+	)
+
+	for _, alert := range alerts {
+		if mute.IsMutedOnSend(mute.MuteParams{
+			IsRecovered:   alert.IsRecovered,
+			TenantId:      alert.TenantId,
+			Labels:        alert.Labels,
+			FaultCenterId: alert.FaultCenterId,
+			RecoverNotify: faultCenter.RecoverNotify,
+		}) {
+			continue
+		}
+
+		groupLabels := getGroupLabels(alert.Labels, strategy.GroupBy)
+		for _, noticeId := range new(AlertGroups).getNoticeId(alert, faultCenter) {
+			key := buildGroupKey(noticeId, groupLabels)
+			group, ok := groups[key]
+			if !ok {
+				group = &labelGroup{noticeId: noticeId, labels: groupLabels}
+				groups[key] = group
+			}
+
+			if alert.IsRecovered {
+				group.resolved = append(group.resolved, alert)
+			} else {
+				group.firing = append(group.firing, alert)
+			}
+		}
+	}
+
+	// 已无事件但仍有待发送恢复通知的分组
+	for key, state := range states {
+		if _, ok := groups[key]; !ok {
+			groups[key] = &labelGroup{noticeId: state.NoticeId, labels: state.GroupLabels}
+		}
+	}
+
+	for key, group := range groups {
+		state, exists := states[key]
+		if !exists {
+			state = models.AlertGroupState{
+				GroupKey:    key,
+				NoticeId:    group.noticeId,
+				GroupLabels: group.labels,
+				CreateAt:    curTime,
+			}
+		}
+		for _, event := range group.resolved {
+			state.AddResolved(*event)
+		}
+
+		// 未发送过告警通知的分组无需发送恢复通知
+		if state.LastNotifyAt == 0 && len(group.firing) == 0 {
+			state.Resolved = nil
+		}
+
+		if len(group.firing) == 0 && len(state.Resolved) == 0 {
+			c.ctx.Redis.AlertGroup().Delete(faultCenter.TenantId, faultCenter.ID, key)
+			continue
+		}
+
+		if !isGroupDue(state, group.firing, strategy, curTime) {
+			if !exists || len(group.resolved) > 0 {
+				c.ctx.Redis.AlertGroup().Set(faultCenter.TenantId, faultCenter.ID, state)
+			}
+			continue
+		}
+
+		c.flushGroup(faultCenter, &state, group.firing, curTime)
+		if len(group.firing) == 0 {
+			c.ctx.Redis.AlertGroup().Delete(faultCenter.TenantId, faultCenter.ID, key)
+			continue
+		}
+		c.ctx.Redis.AlertGroup().Set(faultCenter.TenantId, faultCenter.ID, state)
+	}
+}
+
+// isGroupDue 判断分组是否到达通知时间
+func isGroupDue(state models.AlertGroupState, firing []*models.AlertCurEvent, strategy models.GroupStrategy, curTime int64) bool {
+	if state.LastNotifyAt == 0 {
+		return curTime >= state.CreateAt+strategy.GetGroupWait()
+	}
+
+	if len(state.Resolved) > 0 || isGroupChanged(state.Notified, firing) {
+		return curTime >= state.LastNotifyAt+strategy.GetGroupInterval()
+	}
+
+	return curTime >= state.LastNotifyAt+strategy.GetRepeatInterval()
+}
+
+// isGroupChanged 比对上次通知时的告警中事件
+func isGroupChanged(notified []string, firing []*models.AlertCurEvent) bool {
+	if len(notified) != len(firing) {
+		return true
+	}
+	for _, event := range firing {
+		if !slices.Contains(notified, event.Fingerprint) {
+			return true
+		}
+	}
+	return false
+}
+
+// flushGroup 将分组内全部事件合并为一条通知发送
+func (c *Consume) flushGroup(faultCenter models.FaultCenter, state *models.AlertGroupState, firing []*models.AlertCurEvent, curTime int64) {
+	var members []models.AlertCurEvent
+	for _, event := range firing {
+		members = append(members, *event)
+	}
+	members = append(members, state.Resolved...)
+	sort.SliceStable(members, func(i, j int) bool {
+		if members[i].IsRecovered != members[j].IsRecovered {
+			return !members[i].IsRecovered
+		}
+		return members[i].Severity < members[j].Severity
+	})
+
+	// 以最高等级的事件作为通知主体, 模版中可通过 .GroupAlerts 遍历分组成员
+	event := members[0]
+	event.GroupAlerts = members
+	event.Annotations = buildGroupAnnotations(state.GroupLabels, members)
+
+	if err := handleAlert(c.ctx, "group", faultCenter, state.NoticeId, []*models.AlertCurEvent{&event}); err != nil {
+		logc.Error(c.ctx.Ctx, fmt.Sprintf("Failed to send alert group, groupKey: %s, err: %v", state.GroupKey, err))
+	}
+
+	notice, _ := getNoticeData(c.ctx, faultCenter.TenantId, state.NoticeId)
+	for _, alert := range firing {
+		if !slices.Contains(state.Notified, alert.Fingerprint) && isPrimaryNotice(faultCenter, alert, state.NoticeId) {
+			handleSubscribe(c.ctx, alert, notice)
+		}
+		alert.LastSendTime = curTime
+		c.ctx.Redis.Alert().PushAlertEvent(alert)
+	}
+	for i := range state.Resolved {
+		if isPrimaryNotice(faultCenter, &state.Resolved[i], state.NoticeId) {
+			handleSubscribe(c.ctx, &state.Resolved[i], notice)
+		}
+	}
+
+	state.LastNotifyAt = curTime
+	state.Notified = nil
+	for _, alert := range firing {
+		state.Notified = append(state.Notified, alert.Fingerprint)
+	}
+	state.Resolved = nil
+}
+
+// getGroupLabels 获取事件的分组标签
+func getGroupLabels(labels map[string]interface{}, groupBy []string) map[string]interface{} {
+	groupLabels := make(map[string]interface{})
+	if slices.Contains(groupBy, "...") {
+		for k, v := range labels {
+			groupLabels[k] = v
+		}
+		return groupLabels
+	}
+
+	for _, key := range groupBy {
+		if v, ok := labels[key]; ok {
+			groupLabels[key] = v
+		}
+	}
+	return groupLabels
+}
+
+// buildGroupKey 通知对象 + 分组标签 = 分组 Key
+func buildGroupKey(noticeId string, groupLabels map[string]interface{}) string {
+	return noticeId + ":" + tools.Md5Hash([]byte(formatGroupLabels(groupLabels)))
+}
+
+func formatGroupLabels(groupLabels map[string]interface{}) string {
+	var pairs []string
+	for k, v := range groupLabels {
+		pairs = append(pairs, fmt.Sprintf("%s=%v", k, v))
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ", ")
+}
+
+// buildGroupAnnotations 生成分组通知的事件详情, 兼容未使用 .GroupAlerts 的模版
+func buildGroupAnnotations(groupLabels map[string]interface{}, members []models.AlertCurEvent) string {
+	var firing, resolved int
+	for _, m := range members {
+		if m.IsRecovered {
+			resolved++
+		} else {
+			firing++
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("分组 [%s] 告警中 %d 条, 已恢复 %d 条\n", formatGroupLabels(groupLabels), firing, resolved))
+	for _, m := range members {
+		status := "告警中"
+		if m.IsRecovered {
+			status = "已恢复"
+		}
+		b.WriteString(fmt.Sprintf("- [%s][%s] %s: %s\n", status, m.Severity, m.RuleName, strings.SplitN(m.Annotations, "\n", 2)[0]))
+	}
+
+	return b.String()
+}
@@ -9,6 +9,7 @@ import (
 	"watchAlert/alert/process"
 	"watchAlert/internal/ctx"
 	"watchAlert/internal/models"
+	"watchAlert/internal/types"
 	mediums "watchAlert/pkg/medium"
 	"watchAlert/pkg/templates"
 	"watchAlert/pkg/tools"
@@ -65,21 +66,37 @@ func handleAlert(ctx *ctx.Context, processType string, faultCenter models.FaultC
 					logc.Infof(ctx.Ctx, "没有匹配的通知策略, 告警事件名称: %s, 通知对象名称: %s", event.RuleName, noticeData.Name)
 				}
 
-				if mute.IsMuted(mute.MuteParams{
+				if mute.IsMutedOnSend(mute.MuteParams{
 					IsRecovered:   event.IsRecovered,
 					TenantId:      event.TenantId,
 					Labels:        event.Labels,
 					FaultCenterId: event.FaultCenterId,
 					RecoverNotify: faultCenter.RecoverNotify,
 				}) {
+					if event.IsRecovered {
+						resolveIncidents(ctx, event, noticeData, routes)
+					}
 					continue
 				}
 
+				// 推送至订阅该规则的用户
+				if processType == "alarm" && isPrimaryNotice(faultCenter, event, noticeId) {
+					handleSubscribe(ctx, event, noticeData)
+				}
+
+				recipients := newDutyRecipients(ctx, *noticeData.GetDutyId())
 				for _, route := range routes {
-					// 设置值班用户信息
-					dutyUsers := getDutyUsers(ctx, noticeData, route.NoticeType)
+					// 设置值班用户信息, 配置了通知偏好的值班人员按偏好选择通知方式
+					members := recipients.forRoute(event.Severity, route.NoticeType)
+					dutyUsers := formatDutyUsers(members, route.NoticeType)
 					event.DutyUser = strings.Join(dutyUsers, " ")
 
+					// 超过通知限流时合并至摘要消息, 外部事件平台需收到每个触发及恢复请求, 不参与限流
+					if !slices.Contains(mediums.IncidentNoticeTypes, route.NoticeType) && !allowNotice(ctx, noticeData, route) {
+						suppressNotice(ctx, event, noticeData, route)
+						continue
+					}
+
 					// 生成告警内容
 					content := generateAlertContent(ctx, event, noticeData, route)
 
@@ -98,19 +115,21 @@ func handleAlert(ctx *ctx.Context, processType string, faultCenter models.FaultC
 						To: route.To,
 					}
 
-					if len(dutyUsers) > 0 {
-						switch route.NoticeType {
-						case "Phone":
-							phone.To = append(phone.To, dutyUsers...)
-						case "SMS":
-							sms.To = append(sms.To, dutyUsers...)
-						case "Email":
-							email.To = append(email.To, dutyUsers...)
-						}
+					telegram := models.Telegram{
+						To: route.To,
+					}
+
+					switch route.NoticeType {
+					case "Phone":
+						phone.To = append(phone.To, getContacts(members, route.NoticeType)...)
+					case "SMS":
+						sms.To = append(sms.To, getContacts(members, route.NoticeType)...)
+					case "Email":
+						email.To = append(email.To, getContacts(members, route.NoticeType)...)
 					}
 
 					// 发送告警
-					err := mediums.Sender(ctx, mediums.SendParams{
+					err := sendNotice(ctx, event, mediums.SendParams{
 						TenantId:    event.TenantId,
 						EventId:     event.EventId,
 						RuleName:    event.RuleName,
@@ -120,9 +139,11 @@ func handleAlert(ctx *ctx.Context, processType string, faultCenter models.FaultC
 						NoticeName:  noticeData.Name,
 						IsRecovered: event.IsRecovered,
 						Hook:        route.Hook,
+						WebHook:     route.WebHook,
 						Email:       email,
 						Phone:       phone,
 						SMS:         sms,
+						Telegram:    telegram,
 						Content:     content,
 						Sign:        route.Sign,
 					})
@@ -130,6 +151,11 @@ func handleAlert(ctx *ctx.Context, processType string, faultCenter models.FaultC
 						logc.Error(ctx.Ctx, fmt.Sprintf("Failed to send alert: %v", err))
 					}
 				}
+
+				// 通知对象的路由未包含值班人员偏好的通知方式时, 直接通知到个人
+				if !event.IsRecovered {
+					sendToMembers(ctx, event, recipients.pending(ctx, event.Severity, nil), noticeId, noticeData.Name, "", &noticeData)
+				}
 			}
 
 			return nil
@@ -149,7 +175,7 @@ func alarmAggregation(ctx *ctx.Context, processType string, faultCenter models.F
 	curTime := time.Now().Unix()
 	newAlertGroups := alertGroups
 	switch faultCenter.GetAlarmAggregationType() {
-	case "Rule":
+	case models.AggregationTypeRule:
 		for severity, events := range alertGroups {
 			newAlertGroups[severity] = withRuleGroupByAlerts(ctx, curTime, events)
 		}
@@ -203,78 +229,72 @@ func getNoticeRoutes(notice models.AlertNotice, severity string) []models.Route
 }
 
 type WebhookContent struct {
-	Alarm     *models.AlertCurEvent `json:"alarm"`
-	DutyUsers []models.DutyUser     `json:"dutyUsers"`
+	Alarm     *models.AlertCurEvent  `json:"alarm"`
+	DutyUsers []models.DutyUser      `json:"dutyUsers"`
+	History   []models.AlertHisEvent `json:"history,omitempty"`
 }
 
+// webhookHistorySize WebHook 携带的历史记录条数
+const webhookHistorySize = 5
+
 // generateAlertContent 生成告警内容
 func generateAlertContent(ctx *ctx.Context, alert *models.AlertCurEvent, noticeData models.AlertNotice, route models.Route) string {
 	if route.NoticeType == "WebHook" {
-		users, ok := ctx.DB.DutyCalendar().GetDutyUserData(*noticeData.GetDutyId(), time.Now().Format("2006-1-2"))
+		return generateWebhookContent(ctx, alert, noticeData, route.WebHook)
+	}
+
+	template, err := templates.NewTemplate(ctx, *alert, route)
+	if err != nil {
+		logc.Error(ctx.Ctx, fmt.Sprintf("Failed to create template: %v", err))
+		return ""
+	}
+	return template.CardContentMsg
+}
+
+// generateWebhookContent 生成 WebHook 请求体, 配置请求体模版时按模版渲染
+func generateWebhookContent(ctx *ctx.Context, alert *models.AlertCurEvent, noticeData models.AlertNotice, config models.WebHookConfig) string {
+	content := WebhookContent{
+		Alarm:     alert,
+		DutyUsers: []models.DutyUser{},
+	}
+
+	if !config.ExcludeDutyUsers {
+		users, ok := ctx.DB.DutyCalendar().GetDutyUserData(*noticeData.GetDutyId(), time.Now())
 		if !ok || len(users) == 0 {
 			logc.Error(ctx.Ctx, "Failed to get duty users, noticeName: ", noticeData.Name)
 		}
 
-		var dutyUsers = []models.DutyUser{}
 		for _, user := range users {
-			dutyUsers = append(dutyUsers, models.DutyUser{
+			content.DutyUsers = append(content.DutyUsers, models.DutyUser{
 				Email:    user.Email,
 				Mobile:   user.Phone,
 				UserId:   user.UserId,
 				Username: user.UserName,
 			})
 		}
-		content := WebhookContent{
-			Alarm:     alert,
-			DutyUsers: dutyUsers,
+	}
+
+	if config.IncludeHistory {
+		history, err := ctx.DB.Event().GetHistoryEvent(types.RequestAlertHisEventQuery{
+			TenantId:      alert.TenantId,
+			FaultCenterId: alert.FaultCenterId,
+			Fingerprint:   alert.Fingerprint,
+			Page:          models.Page{Index: 1, Size: webhookHistorySize},
+		})
+		if err != nil {
+			logc.Error(ctx.Ctx, fmt.Sprintf("Failed to get history events: %v", err))
 		}
+		content.History = history.List
+	}
 
+	if config.BodyTemplate == "" {
 		return tools.JsonMarshalToString(content)
 	}
 
-	template, err := templates.NewTemplate(ctx, *alert, route)
+	body, err := templates.RenderTemplate(config.BodyTemplate, content)
 	if err != nil {
-		logc.Error(ctx.Ctx, fmt.Sprintf("Failed to create template: %v", err))
-		return ""
-	}
-	return template.CardContentMsg
-}
-
-func getDutyUsers(ctx *ctx.Context, noticeData models.AlertNotice, noticeType string) []string {
-	var us []string
-	users, ok := ctx.DB.DutyCalendar().GetDutyUserData(*noticeData.GetDutyId(), time.Now().Format("2006-1-2"))
-	if ok {
-		switch noticeType {
-		case "FeiShu":
-			for _, user := range users {
-				us = append(us, fmt.Sprintf("<at id=%s></at>", user.DutyUserId))
-			}
-			return us
-		case "DingDing":
-			for _, user := range users {
-				us = append(us, fmt.Sprintf("@%s", user.DutyUserId))
-			}
-			return us
-		case "Email", "WeChat", "WebHook":
-			for _, user := range users {
-				us = append(us, fmt.Sprintf("@%s", user.UserName))
-			}
-			return us
-		case "Slack":
-			for _, user := range users {
-				us = append(us, fmt.Sprintf("<@%s>", user.DutyUserId))
-			}
-			return us
-		case "Phone", "SMS":
-			for _, user := range users {
-				if user.Phone == "" {
-					continue
-				}
-				us = append(us, user.Phone)
-			}
-			return us
-		}
+		logc.Error(ctx.Ctx, fmt.Sprintf("Failed to render webhook template: %v", err))
+		return tools.JsonMarshalToString(content)
 	}
-
-	return []string{"暂无"}
+	return body
 }
@@ -0,0 +1,37 @@
+package consumer
+
+import (
+	"fmt"
+	"slices"
+	"watchAlert/internal/ctx"
+	"watchAlert/internal/models"
+	mediums "watchAlert/pkg/medium"
+
+	"github.com/zeromicro/go-zero/core/logc"
+)
+
+// resolveIncidents 恢复通知被关闭或静默时, 仍需解决外部事件平台中的事件
+func resolveIncidents(ctx *ctx.Context, event *models.AlertCurEvent, noticeData models.AlertNotice, routes []models.Route) {
+	for _, route := range routes {
+		if !slices.Contains(mediums.IncidentNoticeTypes, route.NoticeType) {
+			continue
+		}
+
+		err := mediums.Sender(ctx, mediums.SendParams{
+			TenantId:    event.TenantId,
+			EventId:     event.EventId,
+			RuleName:    event.RuleName,
+			Severity:    event.Severity,
+			NoticeType:  route.NoticeType,
+			NoticeId:    noticeData.Uuid,
+			NoticeName:  noticeData.Name,
+			IsRecovered: event.IsRecovered,
+			Hook:        route.Hook,
+			Content:     generateAlertContent(ctx, event, noticeData, route),
+			Sign:        route.Sign,
+		})
+		if err != nil {
+			logc.Error(ctx.Ctx, fmt.Sprintf("Failed to resolve incident: %v", err))
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package consumer
+
+import (
+	"fmt"
+	"html"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"watchAlert/internal/ctx"
+	"watchAlert/internal/models"
+	mediums "watchAlert/pkg/medium"
+	"watchAlert/pkg/templates"
+
+	"github.com/zeromicro/go-zero/core/logc"
+)
+
+// directChannels 可直接通知到个人的通知方式, IM 类通知需通过通知对象的群机器人 @ 用户
+var directChannels = []string{"Email", "Phone", "SMS"}
+
+// dutyRecipients 当前值班人员及其通知偏好
+type dutyRecipients struct {
+	members []models.Member
+	at      time.Time
+	// 已通过通知对象路由通知的用户及方式
+	covered map[string][]string
+}
+
+func newDutyRecipients(ctx *ctx.Context, dutyId string) *dutyRecipients {
+	at := time.Now()
+	members, _ := ctx.DB.DutyCalendar().GetDutyUserData(dutyId, at)
+	return &dutyRecipients{
+		members: members,
+		at:      at,
+		covered: make(map[string][]string),
+	}
+}
+
+// forRoute 获取路由需要通知的值班人员, 配置了通知偏好的用户仅在偏好包含该通知方式时通知
+func (d *dutyRecipients) forRoute(severity, noticeType string) []models.Member {
+	var members []models.Member
+	for _, member := range d.members {
+		channels, ok := member.GetNotifyChannels(severity, d.at)
+		if ok {
+			if !slices.Contains(channels, noticeType) {
+				continue
+			}
+			d.covered[member.UserId] = append(d.covered[member.UserId], noticeType)
+		}
+		members = append(members, member)
+	}
+	return members
+}
+
+// pending 按通知偏好获取尚未通知的方式及用户, defaultChannels 为未配置偏好的用户使用的通知方式
+// 偏好仅包含 IM 类通知且没有匹配的路由时, 回退至 defaultChannels, 未指定时回退至邮件
+func (d *dutyRecipients) pending(ctx *ctx.Context, severity string, defaultChannels []string) map[string][]models.Member {
+	byChannel := make(map[string][]models.Member)
+	for _, member := range d.members {
+		channels, ok := member.GetNotifyChannels(severity, d.at)
+		if !ok {
+			channels = defaultChannels
+		} else if len(channels) > 0 && len(d.covered[member.UserId]) == 0 && !slices.ContainsFunc(channels, func(channel string) bool {
+			return slices.Contains(directChannels, channel)
+		}) {
+			fallback := defaultChannels
+			if len(fallback) == 0 {
+				fallback = []string{"Email"}
+			}
+			logc.Infof(ctx.Ctx, "值班人员 %s 的通知偏好 %v 没有匹配的通知路由, 回退至 %v", member.UserName, channels, fallback)
+			channels = fallback
+		}
+
+		for _, channel := range channels {
+			if !slices.Contains(directChannels, channel) || slices.Contains(d.covered[member.UserId], channel) {
+				continue
+			}
+			byChannel[channel] = append(byChannel[channel], member)
+		}
+	}
+	return byChannel
+}
+
+func (d *dutyRecipients) names() []string {
+	var names []string
+	for _, member := range d.members {
+		names = append(names, member.UserName)
+	}
+	return names
+}
+
+// formatDutyUsers 生成通知内容中 @ 值班人员的格式
+func formatDutyUsers(members []models.Member, noticeType string) []string {
+	var us []string
+	for _, member := range members {
+		switch noticeType {
+		case "FeiShu":
+			us = append(us, fmt.Sprintf("<at id=%s></at>", member.GetContact(noticeType)))
+		case "DingDing":
+			us = append(us, fmt.Sprintf("@%s", member.GetContact(noticeType)))
+		case "Email", "WeChat", "WebHook":
+			us = append(us, fmt.Sprintf("@%s", member.UserName))
+		case "Slack":
+			us = append(us, fmt.Sprintf("<@%s>", member.GetContact(noticeType)))
+		case "Teams":
+			// 卡片中的 <at> 标签将转换为 Teams 的提及实体
+			if contact := member.GetContact(noticeType); contact != "" {
+				us = append(us, fmt.Sprintf("<at>%s</at>", contact))
+			}
+		case "Telegram":
+			// 数字 ID 通过链接提及, 否则按用户名提及
+			contact := strings.TrimPrefix(member.GetContact(noticeType), "@")
+			if _, err := strconv.ParseInt(contact, 10, 64); err == nil {
+				us = append(us, fmt.Sprintf(`<a href="tg://user?id=%s">%s</a>`, contact, html.EscapeString(member.UserName)))
+			} else if contact != "" {
+				us = append(us, "@"+contact)
+			}
+		case "Phone", "SMS":
+			if contact := member.GetContact(noticeType); contact != "" {
+				us = append(us, contact)
+			}
+		}
+	}
+
+	if len(us) == 0 {
+		return []string{"暂无"}
+	}
+	return us
+}
+
+// getContacts 获取值班人员的联系方式
+func getContacts(members []models.Member, channel string) []string {
+	var contacts []string
+	for _, member := range members {
+		if contact := member.GetContact(channel); contact != "" && !slices.Contains(contacts, contact) {
+			contacts = append(contacts, contact)
+		}
+	}
+	return contacts
+}
+
+// sendToMembers 按通知方式直接通知到个人, 邮件未指定模版时使用默认格式, limit 不为空时按该通知对象的配置限流
+func sendToMembers(ctx *ctx.Context, event *models.AlertCurEvent, byChannel map[string][]models.Member, noticeId, noticeName, emailTmplId string, limit *models.AlertNotice) {
+	for _, channel := range directChannels {
+		contacts := getContacts(byChannel[channel], channel)
+		if len(contacts) == 0 {
+			continue
+		}
+
+		if route := (models.Route{NoticeType: channel, To: contacts}); limit != nil && !allowNotice(ctx, *limit, route) {
+			suppressNotice(ctx, event, *limit, route)
+			continue
+		}
+
+		params := mediums.SendParams{
+			TenantId:    event.TenantId,
+			EventId:     event.EventId,
+			RuleName:    event.RuleName,
+			Severity:    event.Severity,
+			NoticeType:  channel,
+			NoticeId:    noticeId,
+			NoticeName:  noticeName,
+			IsRecovered: event.IsRecovered,
+		}
+
+		switch channel {
+		case "Email":
+			params.Email = models.Email{Subject: event.RuleName, To: contacts}
+			params.Content = buildPersonalEmail(event)
+			if emailTmplId != "" {
+				template, err := templates.NewTemplate(ctx, *event, models.Route{NoticeType: channel, NoticeTmplId: emailTmplId})
+				if err != nil {
+					logc.Error(ctx.Ctx, fmt.Sprintf("Failed to create template: %v", err))
+				} else {
+					params.Content = template.CardContentMsg
+				}
+			}
+		case "Phone":
+			params.Phone = models.Phone{To: contacts}
+			params.Content = event.GetJsonString()
+		case "SMS":
+			params.SMS = models.SMS{To: contacts}
+			params.Content = event.GetJsonString()
+		}
+
+		if err := sendNotice(ctx, event, params); err != nil {
+			logc.Error(ctx.Ctx, fmt.Sprintf("Failed to send to duty users, channel: %s, err: %v", channel, err))
+		}
+	}
+}
+
+// buildPersonalEmail 默认的个人通知邮件内容
+func buildPersonalEmail(event *models.AlertCurEvent) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("<h3>[%s] %s</h3>", html.EscapeString(event.Severity), html.EscapeString(event.RuleName)))
+	b.WriteString(fmt.Sprintf("<p>触发时间: %s</p>", time.Unix(event.FirstTriggerTime, 0).Format("2006-01-02 15:04:05")))
+	b.WriteString(fmt.Sprintf("<p>故障中心: %s</p>", html.EscapeString(event.FaultCenterId)))
+	b.WriteString(fmt.Sprintf("<pre>%s</pre>", html.EscapeString(event.Annotations)))
+	return b.String()
+}
@@ -0,0 +1,257 @@
+package consumer
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"watchAlert/internal/ctx"
+	"watchAlert/internal/models"
+	mediums "watchAlert/pkg/medium"
+	"watchAlert/pkg/tools"
+
+	"github.com/bytedance/sonic"
+	"github.com/go-redis/redis"
+	"github.com/zeromicro/go-zero/core/logc"
+)
+
+const (
+	noticeLimitKeyPrefix = "w8t:noticeLimit:"
+	noticeStormKeyPrefix = "w8t:noticeStorm:"
+	// noticeStormTTL 告警风暴通知的间隔, 间隔内仅通知一次
+	noticeStormTTL = 30 * time.Minute
+	// noticeDigestInterval 摘要消息的发送间隔
+	noticeDigestInterval = time.Minute
+	// noticeDigestTopRules 摘要消息中展示的规则数
+	noticeDigestTopRules = 10
+)
+
+// 摘要消息中的字段, 规则计数的字段名为 noticeDigestRulePrefix + 规则名称
+const (
+	noticeDigestKeysKey     = "w8t:noticeDigest:keys"
+	noticeDigestKeyPrefix   = "w8t:noticeDigest:"
+	noticeDigestFieldNotice = "_notice"
+	noticeDigestFieldRoute  = "_route"
+	noticeDigestFieldCount  = "_count"
+	noticeDigestRulePrefix  = "rule:"
+	// noticeDigestTTL 摘要未被发送时的过期时间
+	noticeDigestTTL = time.Hour
+)
+
+// flushNoticeDigestScript 原子地取出并删除摘要, 摘要不存在时从待发送列表移除
+var flushNoticeDigestScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 0 then
+	redis.call("SREM", KEYS[2], ARGV[1])
+	return {}
+end
+local digest = redis.call("HGETALL", KEYS[1])
+redis.call("DEL", KEYS[1])
+return digest
+`)
+
+// StartNoticeDigest 定期发送限流抑制的摘要消息
+func StartNoticeDigest(ctx *ctx.Context) {
+	go func() {
+		ticker := time.NewTicker(noticeDigestInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Ctx.Done():
+				return
+			case <-ticker.C:
+				flushNoticeDigests(ctx)
+			}
+		}
+	}()
+}
+
+// allowNotice 判断通知路由是否超过限流, 通知对象及通知类型均按分钟计数
+func allowNotice(ctx *ctx.Context, notice models.AlertNotice, route models.Route) bool {
+	limit := notice.RateLimit
+	if !limit.GetEnable() {
+		return true
+	}
+
+	minute := time.Now().Unix() / 60
+	if limit.MaxPerMinute > 0 {
+		key := fmt.Sprintf("%s%s:%s:%d", noticeLimitKeyPrefix, notice.TenantId, notice.Uuid, minute)
+		if !incrWithinLimit(ctx, key, limit.MaxPerMinute) {
+			return false
+		}
+	}
+	if mediumLimit := limit.GetMediumLimit(route.NoticeType); mediumLimit > 0 {
+		key := fmt.Sprintf("%s%s:%s:%s:%d", noticeLimitKeyPrefix, notice.TenantId, notice.Uuid, route.NoticeType, minute)
+		if !incrWithinLimit(ctx, key, mediumLimit) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// incrWithinLimit 计数加一并判断是否在限制内, Redis 异常时不限流
+func incrWithinLimit(ctx *ctx.Context, key string, limit int) bool {
+	count, err := ctx.Redis.Redis().Incr(key).Result()
+	if err != nil {
+		logc.Errorf(ctx.Ctx, "通知限流计数失败, key: %s, err: %s", key, err.Error())
+		return true
+	}
+	if count == 1 {
+		ctx.Redis.Redis().Expire(key, 2*time.Minute)
+	}
+
+	return count <= int64(limit)
+}
+
+// suppressNotice 记录被限流抑制的通知, 合并至摘要消息, 并在开启时向值班人员发送告警风暴通知
+func suppressNotice(ctx *ctx.Context, event *models.AlertCurEvent, notice models.AlertNotice, route models.Route) {
+	now := time.Now()
+	err := ctx.DB.Notice().AddRecord(models.NoticeRecord{
+		EventId:  event.EventId,
+		Date:     now.Format("2006-01-02"),
+		CreateAt: now.Unix(),
+		TenantId: event.TenantId,
+		RuleName: event.RuleName,
+		NType:    route.NoticeType,
+		NObj:     notice.Uuid,
+		Severity: event.Severity,
+		Status:   models.NoticeRecordSuppressed,
+		ErrMsg:   "超过通知限流, 已合并至摘要消息",
+	})
+	if err != nil {
+		logc.Errorf(ctx.Ctx, "Add notice record failed, err: %s", err.Error())
+	}
+
+	// 摘要保存在 Redis 中, 重启或任务迁移至其他节点后仍可发送
+	id := tools.Md5Hash([]byte(strings.Join([]string{notice.TenantId, notice.Uuid, route.NoticeType, route.Hook, strings.Join(route.To, ",")}, "/")))
+	key := noticeDigestKeyPrefix + id
+	_, err = ctx.Redis.Redis().TxPipelined(func(pipe redis.Pipeliner) error {
+		pipe.SAdd(noticeDigestKeysKey, id)
+		pipe.HSetNX(key, noticeDigestFieldNotice, tools.JsonMarshalToString(models.AlertNotice{TenantId: notice.TenantId, Uuid: notice.Uuid, Name: notice.Name}))
+		pipe.HSetNX(key, noticeDigestFieldRoute, tools.JsonMarshalToString(route))
+		pipe.HIncrBy(key, noticeDigestFieldCount, 1)
+		pipe.HIncrBy(key, noticeDigestRulePrefix+event.RuleName, 1)
+		pipe.Expire(key, noticeDigestTTL)
+		return nil
+	})
+	if err != nil {
+		logc.Errorf(ctx.Ctx, "记录通知摘要失败, err: %s", err.Error())
+	}
+
+	if notice.RateLimit.StormNotice {
+		sendStormNotice(ctx, notice)
+	}
+}
+
+// sendStormNotice 向通知对象的消息类路由发送告警风暴通知, 并提醒当前值班人员
+func sendStormNotice(ctx *ctx.Context, notice models.AlertNotice) {
+	ok, err := ctx.Redis.Redis().SetNX(noticeStormKeyPrefix+notice.TenantId+":"+notice.Uuid, time.Now().Unix(), noticeStormTTL).Result()
+	if err != nil || !ok {
+		return
+	}
+
+	members, _ := ctx.DB.DutyCalendar().GetDutyUserData(*notice.GetDutyId(), time.Now())
+	for _, route := range notice.Routes {
+		text := fmt.Sprintf("【告警风暴】通知对象「%s」的通知量已超过限流阈值, 超出部分将每分钟合并为摘要消息发送, 请值班人员及时前往 WatchAlert 控制台处理。", notice.Name)
+		if users := formatDutyUsers(members, route.NoticeType); len(users) > 0 {
+			text += "\n值班人员: " + strings.Join(users, " ")
+		}
+		sendTextNotice(ctx, notice, route, "告警风暴", text)
+	}
+}
+
+// flushNoticeDigests 发送摘要周期内限流抑制的通知汇总, 多个节点同时发送时每条摘要仅由一个节点取出
+func flushNoticeDigests(ctx *ctx.Context) {
+	ids, err := ctx.Redis.Redis().SMembers(noticeDigestKeysKey).Result()
+	if err != nil {
+		logc.Errorf(ctx.Ctx, "获取通知摘要失败, err: %s", err.Error())
+		return
+	}
+
+	for _, id := range ids {
+		result, err := flushNoticeDigestScript.Run(ctx.Redis.Redis(), []string{noticeDigestKeyPrefix + id, noticeDigestKeysKey}, id).Result()
+		if err != nil {
+			logc.Errorf(ctx.Ctx, "取出通知摘要失败, err: %s", err.Error())
+			continue
+		}
+
+		fields, _ := result.([]interface{})
+		digest := make(map[string]string, len(fields)/2)
+		for i := 0; i+1 < len(fields); i += 2 {
+			digest[fmt.Sprint(fields[i])] = fmt.Sprint(fields[i+1])
+		}
+		sendNoticeDigest(ctx, digest)
+	}
+}
+
+// sendNoticeDigest 按规则的抑制数量排序, 发送摘要消息
+func sendNoticeDigest(ctx *ctx.Context, digest map[string]string) {
+	var (
+		notice models.AlertNotice
+		route  models.Route
+	)
+	if sonic.UnmarshalString(digest[noticeDigestFieldNotice], &notice) != nil || sonic.UnmarshalString(digest[noticeDigestFieldRoute], &route) != nil {
+		return
+	}
+
+	count, _ := strconv.Atoi(digest[noticeDigestFieldCount])
+	rules := make(map[string]int)
+	for field, value := range digest {
+		if name, ok := strings.CutPrefix(field, noticeDigestRulePrefix); ok {
+			rules[name], _ = strconv.Atoi(value)
+		}
+	}
+	if count == 0 {
+		return
+	}
+
+	names := make([]string, 0, len(rules))
+	for rule := range rules {
+		names = append(names, rule)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return rules[names[i]] > rules[names[j]]
+	})
+	if len(names) > noticeDigestTopRules {
+		names = names[:noticeDigestTopRules]
+	}
+
+	text := fmt.Sprintf("【告警摘要】通知对象「%s」另有 %d 条告警已被限流抑制, 请前往 WatchAlert 控制台查看。", notice.Name, count)
+	for _, rule := range names {
+		text += fmt.Sprintf("\n- %s: %d 条", rule, rules[rule])
+	}
+	sendTextNotice(ctx, notice, route, "告警摘要", text)
+}
+
+// sendTextNotice 发送纯文本的系统消息, 不受通知限流限制, 电话及短信路由不发送
+func sendTextNotice(ctx *ctx.Context, notice models.AlertNotice, route models.Route, ruleName, text string) {
+	content, ok := mediums.NewTextContent(route.NoticeType, text)
+	if !ok {
+		return
+	}
+
+	err := mediums.Sender(ctx, mediums.SendParams{
+		TenantId:   notice.TenantId,
+		RuleName:   ruleName,
+		NoticeType: route.NoticeType,
+		NoticeId:   notice.Uuid,
+		NoticeName: notice.Name,
+		Hook:       route.Hook,
+		WebHook:    route.WebHook,
+		Email: models.Email{
+			Subject: ruleName,
+			To:      route.To,
+			CC:      route.CC,
+		},
+		Telegram: models.Telegram{
+			To: route.To,
+		},
+		Content: content,
+		Sign:    route.Sign,
+	})
+	if err != nil {
+		logc.Error(ctx.Ctx, fmt.Sprintf("Failed to send %s: %v", ruleName, err))
+	}
+}
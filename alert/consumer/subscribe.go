@@ -0,0 +1,104 @@
+package consumer
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"watchAlert/internal/ctx"
+	"watchAlert/internal/models"
+	mediums "watchAlert/pkg/medium"
+	"watchAlert/pkg/templates"
+	"watchAlert/pkg/tools"
+
+	"github.com/zeromicro/go-zero/core/logc"
+)
+
+// isPrimaryNotice 事件可能路由到多个通知对象, 仅在第一个通知对象处理时推送订阅, 避免重复发送
+func isPrimaryNotice(faultCenter models.FaultCenter, event *models.AlertCurEvent, noticeId string) bool {
+	noticeIds := new(AlertGroups).getNoticeId(event, faultCenter)
+	return len(noticeIds) > 0 && noticeIds[0] == noticeId
+}
+
+// handleSubscribe 将事件推送给订阅该规则的用户, 与通知对象共用通知限流
+func handleSubscribe(ctx *ctx.Context, event *models.AlertCurEvent, notice models.AlertNotice) {
+	subscribes, err := ctx.DB.Subscribe().List(event.TenantId, event.RuleId, "")
+	if err != nil {
+		logc.Error(ctx.Ctx, fmt.Sprintf("Failed to get subscribes: %v", err))
+		return
+	}
+
+	for _, subscribe := range subscribes {
+		if !matchSubscribe(subscribe, event) {
+			continue
+		}
+
+		for _, route := range subscribe.GetNoticeRoutes() {
+			if !allowNotice(ctx, notice, route) {
+				suppressNotice(ctx, event, notice, route)
+				continue
+			}
+
+			content := generateSubscribeContent(ctx, event, route)
+			if content == "" {
+				continue
+			}
+
+			err := sendNotice(ctx, event, mediums.SendParams{
+				TenantId:    event.TenantId,
+				EventId:     event.EventId,
+				RuleName:    event.RuleName,
+				Severity:    event.Severity,
+				NoticeType:  route.NoticeType,
+				NoticeId:    subscribe.SId,
+				NoticeName:  subscribe.SUserId,
+				IsRecovered: event.IsRecovered,
+				Hook:        route.Hook,
+				Email: models.Email{
+					Subject: route.Subject,
+					To:      route.To,
+				},
+				Content: content,
+				Sign:    route.Sign,
+			})
+			if err != nil {
+				logc.Error(ctx.Ctx, fmt.Sprintf("Failed to send subscribe, sId: %s, err: %v", subscribe.SId, err))
+			}
+		}
+	}
+}
+
+// matchSubscribe 匹配订阅的告警等级和过滤条件
+func matchSubscribe(subscribe models.AlertSubscribe, event *models.AlertCurEvent) bool {
+	if len(subscribe.SRuleSeverity) > 0 && !slices.Contains(subscribe.SRuleSeverity, event.Severity) {
+		return false
+	}
+
+	var labels []models.NoticeLabels
+	for _, filter := range subscribe.SFilter {
+		label, ok := models.ParseSubscribeFilter(filter)
+		if ok {
+			labels = append(labels, label)
+			continue
+		}
+		// 非标签表达式时按关键字匹配事件内容
+		if !strings.Contains(event.GetJsonString(), filter) {
+			return false
+		}
+	}
+
+	return evalCondition(event.Labels, labels)
+}
+
+// generateSubscribeContent 生成订阅消息内容
+func generateSubscribeContent(ctx *ctx.Context, event *models.AlertCurEvent, route models.Route) string {
+	if route.NoticeType == "WebHook" {
+		return tools.JsonMarshalToString(WebhookContent{Alarm: event, DutyUsers: []models.DutyUser{}})
+	}
+
+	template, err := templates.NewTemplate(ctx, *event, route)
+	if err != nil {
+		logc.Error(ctx.Ctx, fmt.Sprintf("Failed to create subscribe template: %v", err))
+		return ""
+	}
+	return template.CardContentMsg
+}
@@ -0,0 +1,189 @@
+package consumer
+
+import (
+	"fmt"
+	"time"
+	"watchAlert/internal/ctx"
+	"watchAlert/internal/models"
+	"watchAlert/pkg/ticket"
+	"watchAlert/pkg/tools"
+
+	"github.com/bytedance/sonic"
+	"github.com/zeromicro/go-zero/core/logc"
+)
+
+const (
+	// ticketKeyPrefix 事件对应的工单编号, 创建中时为 ticketCreating
+	ticketKeyPrefix = "w8t:ticket:"
+	ticketCreating  = "creating"
+	// ticketRetryInterval 创建工单失败后的重试间隔
+	ticketRetryInterval = 5 * time.Minute
+	ticketKeyTTL        = 30 * 24 * time.Hour
+
+	// ticketResolveKeyPrefix 流转失败待重试的工单, 按故障中心保存, field 为事件指纹
+	ticketResolveKeyPrefix = "w8t:ticketResolve:"
+	// ticketResolveMaxRetries 流转工单的最大重试次数
+	ticketResolveMaxRetries = 12
+)
+
+// ticketResolveTask 待重试流转的工单
+type ticketResolveTask struct {
+	Event    models.AlertCurEvent `json:"event"`
+	Retries  int                  `json:"retries"`
+	NextTime int64                `json:"nextTime"`
+}
+
+func buildTicketKey(event models.AlertCurEvent) string {
+	return ticketKeyPrefix + event.TenantId + ":" + event.FaultCenterId + ":" + event.Fingerprint
+}
+
+// processTickets 为满足条件且未创建工单的告警事件创建工单
+func processTickets(ctx *ctx.Context, faultCenter models.FaultCenter, events map[string]*models.AlertCurEvent) {
+	config := faultCenter.TicketConfig
+	if !config.GetEnabled() {
+		return
+	}
+	retryResolveTickets(ctx, faultCenter)
+
+	now := time.Now().Unix()
+	var pending []models.AlertCurEvent
+	for _, event := range events {
+		if event.IsRecovered || event.Status != models.StateAlerting || event.TicketKey != "" || !config.IsMatched(*event, now) {
+			continue
+		}
+		pending = append(pending, *event)
+	}
+	if len(pending) == 0 {
+		return
+	}
+
+	ticketer, err := ticket.NewTicketer(config)
+	if err != nil {
+		logc.Error(ctx.Ctx, fmt.Sprintf("工单配置无效, faultCenter: %s, err: %v", faultCenter.Name, err))
+		return
+	}
+
+	// 创建工单可能耗时较长, 不阻塞事件的通知
+	go func() {
+		for _, event := range pending {
+			createTicket(ctx, ticketer, event)
+		}
+	}()
+}
+
+// createTicket 创建工单并记录至事件, 通过 Redis 避免重复创建
+func createTicket(ctx *ctx.Context, ticketer ticket.Ticketer, event models.AlertCurEvent) {
+	key := buildTicketKey(event)
+	ok, err := ctx.Redis.Redis().SetNX(key, ticketCreating, ticketRetryInterval).Result()
+	if err != nil {
+		return
+	}
+	if !ok {
+		// 已创建的工单未能写入事件时, 重新写入
+		if ticketKey, _ := ctx.Redis.Redis().Get(key).Result(); ticketKey != "" && ticketKey != ticketCreating {
+			setEventTicketKey(ctx, event, ticketKey)
+		}
+		return
+	}
+
+	ticketKey, err := ticketer.Create(event)
+	if err != nil {
+		logc.Error(ctx.Ctx, fmt.Sprintf("创建工单失败, 将在 %s 后重试, rule: %s, fingerprint: %s, err: %v", ticketRetryInterval, event.RuleName, event.Fingerprint, err))
+		return
+	}
+
+	ctx.Redis.Redis().Set(key, ticketKey, ticketKeyTTL)
+	setEventTicketKey(ctx, event, ticketKey)
+	logc.Infof(ctx.Ctx, "创建工单成功, ticket: %s, rule: %s, fingerprint: %s", ticketKey, event.RuleName, event.Fingerprint)
+}
+
+// setEventTicketKey 与 process.PushEventToFaultCenter 持有同一把锁, 避免覆盖并发写入的事件
+func setEventTicketKey(ctx *ctx.Context, event models.AlertCurEvent, ticketKey string) {
+	ctx.Mux.Lock()
+	defer ctx.Mux.Unlock()
+
+	cache, err := ctx.Redis.Alert().GetEventFromCache(event.TenantId, event.FaultCenterId, event.Fingerprint)
+	if err != nil || cache.TicketKey == ticketKey {
+		return
+	}
+
+	cache.TicketKey = ticketKey
+	ctx.Redis.Alert().PushAlertEvent(&cache)
+}
+
+// resolveTicket 事件恢复时补全工单编号并流转工单状态
+func resolveTicket(ctx *ctx.Context, faultCenter models.FaultCenter, event *models.AlertCurEvent) {
+	key := buildTicketKey(*event)
+	if event.TicketKey == "" {
+		if ticketKey, _ := ctx.Redis.Redis().Get(key).Result(); ticketKey != ticketCreating {
+			event.TicketKey = ticketKey
+		}
+	}
+	if event.TicketKey == "" {
+		return
+	}
+
+	ticketer, err := ticket.NewTicketer(faultCenter.TicketConfig)
+	if err != nil {
+		logc.Error(ctx.Ctx, fmt.Sprintf("工单配置无效, faultCenter: %s, err: %v", faultCenter.Name, err))
+		return
+	}
+
+	go doResolveTicket(ctx, ticketer, ticketResolveTask{Event: *event})
+}
+
+// doResolveTicket 流转工单, 成功后删除工单记录, 失败时保存至 Redis 等待重试
+func doResolveTicket(ctx *ctx.Context, ticketer ticket.Ticketer, task ticketResolveTask) {
+	event := task.Event
+	retryKey := ticketResolveKeyPrefix + event.TenantId + ":" + event.FaultCenterId
+
+	err := ticketer.Resolve(event.TicketKey, event)
+	if err == nil {
+		ctx.Redis.Redis().Del(buildTicketKey(event))
+		ctx.Redis.Redis().HDel(retryKey, event.Fingerprint)
+		return
+	}
+
+	task.Retries++
+	if task.Retries > ticketResolveMaxRetries {
+		logc.Error(ctx.Ctx, fmt.Sprintf("流转工单失败, 已达到最大重试次数, ticket: %s, err: %v", event.TicketKey, err))
+		ctx.Redis.Redis().HDel(retryKey, event.Fingerprint)
+		return
+	}
+
+	logc.Error(ctx.Ctx, fmt.Sprintf("流转工单失败, 将在 %s 后重试, ticket: %s, err: %v", ticketRetryInterval, event.TicketKey, err))
+	task.NextTime = time.Now().Add(ticketRetryInterval).Unix()
+	ctx.Redis.Redis().HSet(retryKey, event.Fingerprint, tools.JsonMarshalToString(task))
+}
+
+// retryResolveTickets 重试到期的工单流转
+func retryResolveTickets(ctx *ctx.Context, faultCenter models.FaultCenter) {
+	retryKey := ticketResolveKeyPrefix + faultCenter.TenantId + ":" + faultCenter.ID
+	tasks, err := ctx.Redis.Redis().HGetAll(retryKey).Result()
+	if err != nil || len(tasks) == 0 {
+		return
+	}
+
+	ticketer, err := ticket.NewTicketer(faultCenter.TicketConfig)
+	if err != nil {
+		return
+	}
+
+	now := time.Now()
+	for fingerprint, value := range tasks {
+		var task ticketResolveTask
+		if err := sonic.UnmarshalString(value, &task); err != nil {
+			ctx.Redis.Redis().HDel(retryKey, fingerprint)
+			continue
+		}
+		if task.NextTime > now.Unix() {
+			continue
+		}
+
+		// 重试期间推迟下次重试时间, 避免重复流转
+		claimed := task
+		claimed.NextTime = now.Add(ticketRetryInterval).Unix()
+		ctx.Redis.Redis().HSet(retryKey, fingerprint, tools.JsonMarshalToString(claimed))
+		go doResolveTicket(ctx, ticketer, task)
+	}
+}
@@ -1,7 +1,9 @@
 package consumer
 
 import (
+	"errors"
 	"fmt"
+	"sort"
 	"time"
 	"watchAlert/alert/mute"
 	"watchAlert/internal/ctx"
@@ -10,12 +12,13 @@ import (
 	"github.com/zeromicro/go-zero/core/logc"
 )
 
-// AggregatedAlert 存储聚合后的告警信息
+// AggregatedAlert 存储聚合后的告警信息, 按升级阶段及级别聚合
 type AggregatedAlert struct {
 	Fingerprints []string
 	Events       []*models.AlertCurEvent
-	Status       int64
-	Timeout      int64
+	Stage        string
+	Level        int
+	UpgradeLevel models.UpgradeLevel
 }
 
 // alarmUpgrade 处理告警升级主入口
@@ -31,19 +34,29 @@ func alarmUpgrade(ctx *ctx.Context, faultCenter models.FaultCenter, alerts map[s
 		return nil
 	}
 
-	confirmAggregated := createAggregatedAlert(models.ConfirmStatus, faultCenter)
+	var (
+		levels     = faultCenter.UpgradeStrategy.GetLevels()
+		acked      = faultCenter.UpgradeStrategy.Acked
+		aggregates = make(map[string]*AggregatedAlert)
+	)
 	// 遍历事件并处理升级阶段
 	for _, event := range filterAlerts {
-		// 确认阶段
 		if !event.ConfirmState.IsOk {
-			if err := processStage(ctx, faultCenter, event, currentTime, confirmAggregated, models.ConfirmStatus); err != nil {
-				logc.Error(ctx.Ctx, fmt.Errorf("process confirm stage failed: %w", err))
-			}
+			// 未认领阶段, 按超时时间逐级升级
+			processConfirmStage(levels, event, currentTime, aggregates)
+		} else if acked != nil {
+			// 已认领但未恢复阶段
+			processAckedStage(*acked, event, currentTime, aggregates)
 		}
 	}
 
-	if confirmAggregated != nil {
-		sendIfNotEmpty(ctx, faultCenter, confirmAggregated)
+	keys := make([]string, 0, len(aggregates))
+	for key := range aggregates {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		sendIfNotEmpty(ctx, faultCenter, aggregates[key])
 	}
 
 	return nil
@@ -91,99 +104,157 @@ func isMutedEvent(event *models.AlertCurEvent, faultCenter models.FaultCenter) b
 	})
 }
 
-// createAggregatedAlert 创建聚合告警对象
-func createAggregatedAlert(status int64, faultCenter models.FaultCenter) *AggregatedAlert {
-	return &AggregatedAlert{
-		Fingerprints: make([]string, 0),
-		Events:       make([]*models.AlertCurEvent, 0),
-		Status:       status,
-		Timeout:      faultCenter.GetTimeout(),
+// processConfirmStage 未认领阶段, 升级到已超时的最高级别, 未达到更高级别时按当前级别的间隔重复通知
+func processConfirmStage(levels []models.UpgradeLevel, alert *models.AlertCurEvent, currentTime int64, aggregates map[string]*AggregatedAlert) {
+	state := &alert.UpgradeState
+	// 兼容升级前已发送过超时通知的事件
+	if state.Level == 0 && alert.ConfirmState.ConfirmTimeoutSendTime != 0 {
+		state.Level = 1
+		state.LastNoticeTime = alert.ConfirmState.ConfirmTimeoutSendTime
 	}
-}
-
-// processStage 统一处理确认和处理阶段的逻辑
-func processStage(ctx *ctx.Context, faultCenter models.FaultCenter, alert *models.AlertCurEvent, currentTime int64, aggregated *AggregatedAlert, status int64) error {
-	// 检查是否超时 (达到升级条件)
-	statusStrategy := faultCenter.UpgradeStrategy
-	startTime := getStartTime(alert)
-	timeoutDuration := statusStrategy.Timeout
 
-	isUpgradeTimeout, err := checkTimeout(startTime, currentTime, timeoutDuration)
-	if err != nil {
-		return fmt.Errorf("check confirm upgrade timeout failed: %w", err)
+	target := 0
+	for i, level := range levels {
+		if checkTimeout(alert.FirstTriggerTime, currentTime, level.Timeout) {
+			target = i + 1
+		}
 	}
-	if !isUpgradeTimeout {
-		return nil
+	if target == 0 {
+		return
 	}
 
-	// 检查是否需要重新通知 (达到通知间隔)
-	lastNoticeTime := getLastNoticeTime(alert)
-	if lastNoticeTime != 0 {
-		reNotifyDuration := statusStrategy.RepeatInterval
-
-		// 检查通知间隔是否超时
-		isReNotifyTimeout, err := checkTimeout(lastNoticeTime, currentTime, reNotifyDuration)
-		if err != nil {
-			return fmt.Errorf("check confirm re-notify interval failed: %w", err)
-		}
-		if !isReNotifyTimeout {
-			return nil
+	if target <= state.Level {
+		target = min(state.Level, len(levels))
+		// 检查是否需要重新通知 (达到通知间隔)
+		if !checkTimeout(state.LastNoticeTime, currentTime, levels[target-1].RepeatInterval) {
+			return
 		}
 	}
 
-	aggregated.Fingerprints = append(aggregated.Fingerprints, alert.Fingerprint)
-	aggregated.Events = append(aggregated.Events, alert)
-	// 更新通知时间，并推送到 Redis
-	setLastNoticeTime(ctx, alert, currentTime)
-
-	return nil
+	state.Level = target
+	state.LastNoticeTime = currentTime
+	alert.ConfirmState.ConfirmTimeoutSendTime = currentTime
+	addToAggregate(aggregates, models.UpgradeStageConfirm, target, levels[target-1], alert)
 }
 
-// getStartTime 根据状态获取开始时间
-func getStartTime(alert *models.AlertCurEvent) int64 {
-	return alert.FirstTriggerTime
-}
+// processAckedStage 已认领阶段, 超时时间从认领时间开始计算
+func processAckedStage(level models.UpgradeLevel, alert *models.AlertCurEvent, currentTime int64, aggregates map[string]*AggregatedAlert) {
+	state := &alert.UpgradeState
+	if !checkTimeout(alert.ConfirmState.ConfirmActionTime, currentTime, level.Timeout) {
+		return
+	}
+	if state.AckedNoticeTime != 0 && !checkTimeout(state.AckedNoticeTime, currentTime, level.RepeatInterval) {
+		return
+	}
 
-// getLastNoticeTime 根据状态获取上次通知时间
-func getLastNoticeTime(alert *models.AlertCurEvent) int64 {
-	return alert.ConfirmState.ConfirmTimeoutSendTime
+	state.AckedNoticeTime = currentTime
+	addToAggregate(aggregates, models.UpgradeStageAcked, 1, level, alert)
 }
 
-// setLastNoticeTime 根据状态设置上次通知时间
-func setLastNoticeTime(ctx *ctx.Context, alert *models.AlertCurEvent, currentTime int64) {
-	alert.ConfirmState.ConfirmTimeoutSendTime = currentTime
-	ctx.Redis.Alert().PushAlertEvent(alert)
+// addToAggregate 将事件加入对应阶段及级别的聚合告警
+func addToAggregate(aggregates map[string]*AggregatedAlert, stage string, levelIndex int, level models.UpgradeLevel, alert *models.AlertCurEvent) {
+	key := fmt.Sprintf("%s-%d", stage, levelIndex)
+	aggregated, ok := aggregates[key]
+	if !ok {
+		aggregated = &AggregatedAlert{
+			Stage:        stage,
+			Level:        levelIndex,
+			UpgradeLevel: level,
+		}
+		aggregates[key] = aggregated
+	}
+
+	aggregated.Fingerprints = append(aggregated.Fingerprints, alert.Fingerprint)
+	aggregated.Events = append(aggregated.Events, alert)
 }
 
-// sendIfNotEmpty 检查聚合告警是否不为空，如果不为空则发送
+// sendIfNotEmpty 检查聚合告警是否不为空，如果不为空则发送, 并记录升级进度
 func sendIfNotEmpty(ctx *ctx.Context, faultCenter models.FaultCenter, aggregated *AggregatedAlert) {
 	if len(aggregated.Events) == 0 {
 		return
 	}
 
 	// 仅保留第一个事件发送
+	event := *aggregated.Events[0]
 	if len(aggregated.Events) > 1 {
-		aggregated.Events[0].Annotations = fmt.Sprintf("%s%s", aggregated.Events[0].Annotations, getContent(len(aggregated.Events)))
-		aggregated.Events = aggregated.Events[:1]
+		event.Annotations = fmt.Sprintf("%s%s", event.Annotations, getContent(len(aggregated.Events)))
+	}
+
+	users, err := sendAggregatedAlert(ctx, faultCenter, aggregated, &event)
+	if err != nil {
+		logc.Error(ctx.Ctx, fmt.Errorf("send aggregated %s alert failed: %w", aggregated.Stage, err))
+	}
+
+	record := models.UpgradeRecord{
+		Stage:    aggregated.Stage,
+		Level:    aggregated.Level,
+		Name:     aggregated.UpgradeLevel.Name,
+		NoticeId: aggregated.UpgradeLevel.NoticeId,
+		DutyId:   aggregated.UpgradeLevel.DutyId,
+		Users:    users,
+		Time:     time.Now().Unix(),
+	}
+	for _, alert := range aggregated.Events {
+		alert.UpgradeState.AddRecord(record)
+		ctx.Redis.Alert().PushAlertEvent(alert)
+	}
+}
+
+// sendAggregatedAlert 发送聚合后的告警函数, 返回被通知的值班人员
+func sendAggregatedAlert(ctx *ctx.Context, faultCenter models.FaultCenter, aggregated *AggregatedAlert, event *models.AlertCurEvent) ([]string, error) {
+	level := aggregated.UpgradeLevel
+	logc.Alert(ctx.Ctx, fmt.Sprintf("Aggregated alarm %s upgrade to level %d(%s) fingerprints: %v, exceeded %d min",
+		aggregated.Stage,
+		aggregated.Level,
+		level.Name,
+		aggregated.Fingerprints,
+		level.Timeout))
+
+	var (
+		users []string
+		errs  []error
+	)
+	if level.NoticeId != "" {
+		notice, err := ctx.DB.Notice().Get(faultCenter.TenantId, level.NoticeId)
+		if err == nil {
+			users = append(users, getDutyUserNames(ctx, *notice.GetDutyId())...)
+		}
+		if err := handleAlert(ctx, "upgrade", faultCenter, level.NoticeId, []*models.AlertCurEvent{event}); err != nil {
+			errs = append(errs, err)
+		}
 	}
 
-	if err := sendAggregatedAlert(ctx, faultCenter, aggregated); err != nil {
-		logc.Error(ctx.Ctx, fmt.Errorf("send aggregated confirm alert failed: %w", err))
+	if level.DutyId != "" {
+		users = append(users, pageDutyUsers(ctx, level, event)...)
 	}
+
+	return users, errors.Join(errs...)
 }
 
-// sendAggregatedAlert 发送聚合后的告警函数
-func sendAggregatedAlert(ctx *ctx.Context, faultCenter models.FaultCenter, aggregated *AggregatedAlert) error {
-	noticeId := faultCenter.GetUpgradeNoticeId()
-	if noticeId == "" {
+// getDutyUserNames 获取当前值班人员名称
+func getDutyUserNames(ctx *ctx.Context, dutyId string) []string {
+	if dutyId == "" {
 		return nil
 	}
+	return newDutyRecipients(ctx, dutyId).names()
+}
 
-	logc.Alert(ctx.Ctx, fmt.Sprintf("Aggregated alarm confirm timeout fingerprints: %v, exceeded %d min",
-		aggregated.Fingerprints,
-		aggregated.Timeout))
+// pageDutyUsers 直接通知升级级别对应值班表的当前值班人员, 配置了通知偏好的用户按偏好通知
+func pageDutyUsers(ctx *ctx.Context, level models.UpgradeLevel, event *models.AlertCurEvent) []string {
+	recipients := newDutyRecipients(ctx, level.DutyId)
+	if len(recipients.members) == 0 {
+		logc.Error(ctx.Ctx, fmt.Sprintf("No duty users found for upgrade level %s, dutyId: %s", level.Name, level.DutyId))
+		return nil
+	}
+
+	noticeTypes := level.NoticeTypes
+	if len(noticeTypes) == 0 {
+		noticeTypes = []string{"Email"}
+	}
 
-	return handleAlert(ctx, "upgrade", faultCenter, noticeId, aggregated.Events)
+	// 升级通知已按告警分组聚合, 不参与通知限流
+	sendToMembers(ctx, event, recipients.pending(ctx, event.Severity, noticeTypes), level.DutyId, level.Name, level.NoticeTmplId, nil)
+	return recipients.names()
 }
 
 // getContent 生成聚合通知内容
@@ -192,9 +263,9 @@ func getContent(number int) string {
 }
 
 // checkTimeout 检查是否超时 duration 单位为分钟
-func checkTimeout(startTime, currentTime int64, duration int64) (bool, error) {
+func checkTimeout(startTime, currentTime int64, duration int64) bool {
 	timeoutSeconds := duration * 60
 
 	// 如果 currentTime 超过 startTime + timeoutSeconds，则超时
-	return currentTime > startTime+timeoutSeconds, nil
+	return currentTime > startTime+timeoutSeconds
 }
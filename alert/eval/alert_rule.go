@@ -3,14 +3,14 @@ package eval
 import (
 	"context"
 	"fmt"
-	"runtime/debug"
 	"slices"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 	"watchAlert/internal/ctx"
 	"watchAlert/internal/models"
-	"watchAlert/pkg/provider"
+	w8tMetrics "watchAlert/pkg/metrics"
 	"watchAlert/pkg/tools"
 
 	"github.com/go-redis/redis"
@@ -62,23 +62,33 @@ type (
 
 	// AlertRule 告警规则
 	AlertRule struct {
-		ctx *ctx.Context
+		ctx       *ctx.Context
+		scheduler *evalScheduler
 	}
 )
 
 func NewAlertRuleEval(ctx *ctx.Context) AlertRuleEval {
-	return &AlertRule{
+	t := &AlertRule{
 		ctx: ctx,
 	}
+	t.scheduler = newEvalScheduler(ctx.Ctx, t.executeTask)
+
+	return t
 }
 
+// Submit 提交规则评估任务, 已存在的任务先取消, 避免重复提交时旧任务无法停止
 func (t *AlertRule) Submit(rule models.AlertRule) {
 	t.ctx.Mux.Lock()
 	defer t.ctx.Mux.Unlock()
 
+	if cancel, exists := t.ctx.ContextMap[rule.RuleId]; exists {
+		cancel()
+	}
+
 	c, cancel := context.WithCancel(context.Background())
 	t.ctx.ContextMap[rule.RuleId] = cancel
-	go t.Eval(c, rule)
+	t.ctx.TaskVersions[rule.RuleId] = strconv.FormatInt(rule.UpdateAt, 10)
+	t.Eval(c, rule)
 }
 
 func (t *AlertRule) Stop(ruleId string) {
@@ -88,6 +98,7 @@ func (t *AlertRule) Stop(ruleId string) {
 	if cancel, exists := t.ctx.ContextMap[ruleId]; exists {
 		cancel()
 		delete(t.ctx.ContextMap, ruleId)
+		delete(t.ctx.TaskVersions, ruleId)
 	}
 }
 
@@ -96,6 +107,7 @@ func (t *AlertRule) Restart(rule models.AlertRule) {
 	t.Submit(rule)
 }
 
+// Eval 将规则交给调度器周期评估, ctx 取消后停止评估
 func (t *AlertRule) Eval(ctx context.Context, rule models.AlertRule) {
 	err := rule.Validate()
 	if err != nil {
@@ -103,39 +115,12 @@ func (t *AlertRule) Eval(ctx context.Context, rule models.AlertRule) {
 		return
 	}
 
-	taskChan := make(chan struct{}, TaskChannelBufferSize)
-	timer := time.NewTicker(t.getEvalTimeDuration(rule.EvalInterval))
-	defer func() {
-		timer.Stop()
-		if r := recover(); r != nil {
-			// 获取调用栈信息
-			stack := debug.Stack()
-			logc.Errorf(t.ctx.Ctx, "Recovered from rule eval goroutine panic: %s, RuleName: %s, RuleId: %s\n%s", r, rule.RuleName, rule.RuleId, stack)
-			t.Restart(rule)
-		}
-	}()
-
-	for {
-		select {
-		case <-timer.C:
-			// 处理任务信号量
-			taskChan <- struct{}{}
-			logc.Infof(t.ctx.Ctx, fmt.Sprintf("Handle eval task, RuleId: %v, RuleName: %s", rule.RuleId, rule.RuleName))
-			t.executeTask(rule, taskChan)
-		case <-ctx.Done():
-			logc.Infof(t.ctx.Ctx, fmt.Sprintf("Stop eval task, RuleId: %v, RuleName: %s", rule.RuleId, rule.RuleName))
-			return
-		}
-		timer.Reset(t.getEvalTimeDuration(rule.EvalInterval))
-	}
+	t.scheduler.add(ctx, rule, t.getEvalTimeDuration(rule.EvalInterval))
 }
 
 // executeTask 执行评估任务
-func (t *AlertRule) executeTask(rule models.AlertRule, taskChan chan struct{}) {
-	defer func() {
-		// 释放任务信号量
-		<-taskChan
-	}()
+func (t *AlertRule) executeTask(ctx context.Context, rule models.AlertRule) {
+	logc.Infof(t.ctx.Ctx, fmt.Sprintf("Handle eval task, RuleId: %v, RuleName: %s", rule.RuleId, rule.RuleName))
 
 	// 在规则评估前检查是否仍然启用
 	if !t.isRuleEnabled(rule.RuleId) {
@@ -143,7 +128,12 @@ func (t *AlertRule) executeTask(rule models.AlertRule, taskChan chan struct{}) {
 	}
 
 	// 并发处理数据源
-	curFingerprints := t.processDatasources(rule)
+	curFingerprints := t.processDatasources(ctx, rule)
+
+	// 评估期间规则已停止, 不处理恢复
+	if ctx.Err() != nil {
+		return
+	}
 
 	// 处理恢复逻辑
 	t.Recover(rule.TenantId, rule.RuleId,
@@ -152,8 +142,8 @@ func (t *AlertRule) executeTask(rule models.AlertRule, taskChan chan struct{}) {
 		curFingerprints)
 }
 
-// processDatasources 处理数据源
-func (t *AlertRule) processDatasources(rule models.AlertRule) []string {
+// processDatasources 处理数据源, 每个数据源的并发评估数受调度器限制
+func (t *AlertRule) processDatasources(ctx context.Context, rule models.AlertRule) []string {
 	var (
 		curFingerprints []string
 		fingerprintChan = make(chan []string, len(rule.DatasourceIdList))
@@ -165,6 +155,12 @@ func (t *AlertRule) processDatasources(rule models.AlertRule) []string {
 		wg.Add(1)
 		go func(dsId string) {
 			defer wg.Done()
+			release, ok := t.scheduler.acquire(ctx, dsId)
+			if !ok {
+				return
+			}
+			defer release()
+
 			fingerprints := t.processSingleDatasource(dsId, rule)
 			if len(fingerprints) > 0 {
 				fingerprintChan <- fingerprints
@@ -189,13 +185,15 @@ func (t *AlertRule) processSingleDatasource(dsId string, rule models.AlertRule)
 	instance, err := t.ctx.DB.Datasource().GetInstance(dsId)
 	if err != nil {
 		logc.Errorf(t.ctx.Ctx, "Failed to get datasource instance %s: %v", dsId, err)
-		return nil
+		recordEvalFailure(rule)
+		return handleNoData(t.ctx, dsId, rule, fmt.Sprintf("获取数据源失败: %v", err))
 	}
 
-	// 检查数据源健康状态
-	if ok, _ := provider.CheckDatasourceHealth(instance); !ok {
+	// 检查数据源健康状态, 由健康检查任务周期更新
+	if health := t.ctx.Redis.DatasourceHealth().Get(dsId); !health.IsAvailable() {
 		logc.Errorf(t.ctx.Ctx, "Datasource %s is unhealthy", dsId)
-		return nil
+		recordEvalFailure(rule)
+		return handleNoData(t.ctx, dsId, rule, "数据源健康检查失败: "+health.LastError)
 	}
 
 	// 检查数据源是否启用
@@ -214,6 +212,11 @@ func (t *AlertRule) processSingleDatasource(dsId string, rule models.AlertRule)
 	return handler(t.ctx, dsId, instance.Type, rule)
 }
 
+// recordEvalFailure 记录评估失败次数
+func recordEvalFailure(rule models.AlertRule) {
+	w8tMetrics.RuleEvalFailures.WithLabelValues(rule.RuleId, rule.DatasourceType).Inc()
+}
+
 // getEvalTimeDuration 获取评估时间间隔
 func (t *AlertRule) getEvalTimeDuration(evalInterval int64) time.Duration {
 	return time.Duration(evalInterval) * time.Second
@@ -426,6 +429,7 @@ func (t *AlertRule) StopAllEvals() {
 	for ruleId, cancel := range t.ctx.ContextMap {
 		cancel()
 		delete(t.ctx.ContextMap, ruleId)
+		delete(t.ctx.TaskVersions, ruleId)
 	}
 
 	logc.Infof(t.ctx.Ctx, "所有规则评估器已停止")
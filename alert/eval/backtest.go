@@ -0,0 +1,124 @@
+package eval
+
+import (
+	"fmt"
+	"time"
+	"watchAlert/alert/process"
+	"watchAlert/internal/models"
+	"watchAlert/pkg/provider"
+)
+
+// BacktestMaxPoints 单个序列的最大评估次数, 与 Prometheus 单次范围查询的点数限制一致
+const BacktestMaxPoints = 11000
+
+type backtestSeries struct {
+	labels map[string]interface{}
+	values map[int64]float64
+}
+
+type backtestCondition struct {
+	severity    string
+	operator    string
+	value       float64
+	forDuration int64
+}
+
+// Backtest 按规则的评估周期回放 PromQL, 模拟各序列在每个告警等级下的触发及恢复区间
+func Backtest(cli provider.MetricsFactoryProvider, datasourceId string, rule models.AlertRule, start, end time.Time) ([]models.RuleBacktestSeries, error) {
+	var conditions []backtestCondition
+	for _, r := range sortRulesByPriority(rule.PrometheusConfig.Rules) {
+		operator, value, err := process.ProcessRuleExpr(r.Expr)
+		if err != nil {
+			return nil, fmt.Errorf("告警等级 %s 的表达式无效: %w", r.Severity, err)
+		}
+		conditions = append(conditions, backtestCondition{
+			severity:    r.Severity,
+			operator:    operator,
+			value:       value,
+			forDuration: r.ForDuration,
+		})
+	}
+
+	resQuery, err := cli.QueryRange(rule.PrometheusConfig.PromQL, start, end, time.Duration(rule.EvalInterval)*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	// 按序列分组, 记录每个评估时间点的值
+	var (
+		fingerprints []string
+		grouped      = make(map[string]*backtestSeries)
+	)
+	for _, v := range resQuery {
+		fingerprint := v.GetFingerprint()
+		series, ok := grouped[fingerprint]
+		if !ok {
+			series = &backtestSeries{labels: v.GetMetric(), values: make(map[int64]float64)}
+			grouped[fingerprint] = series
+			fingerprints = append(fingerprints, fingerprint)
+		}
+		// Prometheus 返回毫秒级时间戳
+		series.values[v.Timestamp/1000] = v.GetValue()
+	}
+
+	var result []models.RuleBacktestSeries
+	for _, fingerprint := range fingerprints {
+		series := grouped[fingerprint]
+		for _, condition := range conditions {
+			intervals := backtestIntervals(series, condition, start.Unix(), end.Unix(), rule.EvalInterval)
+			if len(intervals) == 0 {
+				continue
+			}
+
+			result = append(result, models.RuleBacktestSeries{
+				DatasourceId: datasourceId,
+				// 规则携带 RuleId 时与实时评估的事件指纹一致
+				Fingerprint: metricFingerprint(rule, series.labels, condition.severity),
+				Labels:      series.labels,
+				Severity:    condition.severity,
+				Intervals:   intervals,
+			})
+		}
+	}
+
+	return result, nil
+}
+
+// backtestIntervals 逐个评估时间点判断告警条件, 持续满足超过 ForDuration 后触发, 条件不满足或无数据时恢复
+func backtestIntervals(series *backtestSeries, condition backtestCondition, start, end, step int64) []models.RuleBacktestInterval {
+	var (
+		intervals []models.RuleBacktestInterval
+		current   *models.RuleBacktestInterval
+	)
+
+	for t := start; t <= end; t += step {
+		value, ok := series.values[t]
+		if !ok || !process.EvalCondition(models.EvalCondition{
+			Operator:      condition.operator,
+			QueryValue:    value,
+			ExpectedValue: condition.value,
+		}) {
+			if current != nil && current.FiringAt > 0 {
+				current.ResolvedAt = t
+				intervals = append(intervals, *current)
+			}
+			current = nil
+			continue
+		}
+
+		if current == nil {
+			current = &models.RuleBacktestInterval{ActiveAt: t, FirstValue: value}
+		}
+		current.LastValue = value
+		// 与 AlertCurEvent.IsArriveForDuration 的判断保持一致
+		if current.FiringAt == 0 && t-current.ActiveAt > condition.forDuration {
+			current.FiringAt = t
+		}
+	}
+
+	if current != nil && current.FiringAt > 0 {
+		intervals = append(intervals, *current)
+	}
+
+	return intervals
+}
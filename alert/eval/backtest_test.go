@@ -0,0 +1,128 @@
+package eval
+
+import (
+	"reflect"
+	"testing"
+	"time"
+	"watchAlert/internal/models"
+	"watchAlert/pkg/provider"
+)
+
+func TestBacktestIntervals(t *testing.T) {
+	// 评估周期 60s, 时间点 0, 60, ..., 600
+	newSeries := func(values map[int64]float64) *backtestSeries {
+		return &backtestSeries{values: values}
+	}
+	above := backtestCondition{operator: ">", value: 10}
+
+	tests := []struct {
+		name        string
+		values      map[int64]float64
+		forDuration int64
+		want        []models.RuleBacktestInterval
+	}{
+		{
+			name:   "never matched",
+			values: map[int64]float64{0: 1, 60: 2, 120: 3},
+		},
+		{
+			// 与 IsArriveForDuration 一致, 持续时间需大于 ForDuration, 第二次评估时触发
+			name:   "fires on second evaluation and resolves",
+			values: map[int64]float64{0: 1, 60: 20, 120: 30, 180: 1},
+			want: []models.RuleBacktestInterval{
+				{ActiveAt: 60, FiringAt: 120, ResolvedAt: 180, FirstValue: 20, LastValue: 30},
+			},
+		},
+		{
+			name:        "for duration must be exceeded",
+			values:      map[int64]float64{60: 20, 120: 20, 180: 20, 240: 1},
+			forDuration: 60,
+			want: []models.RuleBacktestInterval{
+				{ActiveAt: 60, FiringAt: 180, ResolvedAt: 240, FirstValue: 20, LastValue: 20},
+			},
+		},
+		{
+			name:        "pending only never fires",
+			values:      map[int64]float64{60: 20, 120: 20, 180: 1},
+			forDuration: 60,
+		},
+		{
+			name:   "missing sample resolves",
+			values: map[int64]float64{0: 20, 60: 20, 180: 20},
+			want: []models.RuleBacktestInterval{
+				{ActiveAt: 0, FiringAt: 60, ResolvedAt: 120, FirstValue: 20, LastValue: 20},
+			},
+		},
+		{
+			name:   "still firing at end",
+			values: map[int64]float64{540: 20, 600: 25},
+			want: []models.RuleBacktestInterval{
+				{ActiveAt: 540, FiringAt: 600, FirstValue: 20, LastValue: 25},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			condition := above
+			condition.forDuration = tt.forDuration
+			got := backtestIntervals(newSeries(tt.values), condition, 0, 600, 60)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBacktest(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	cli, err := provider.NewMemoryProvider([]models.RuleTestSeries{
+		{Series: `up{instance="a"}`, Values: "1 0 0 0 1 1"},
+		{Series: `up{instance="b"}`, Values: "1x5"},
+	}, start, time.Minute, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rule := models.AlertRule{
+		RuleId:       "r1",
+		RuleName:     "InstanceDown",
+		EvalInterval: 60,
+		PrometheusConfig: models.PrometheusConfig{
+			PromQL: "up",
+			Rules: []models.Rules{
+				{Severity: "P0", Expr: "== 0", ForDuration: 60},
+				{Severity: "P1", Expr: "== 0"},
+			},
+		},
+	}
+
+	result, err := Backtest(cli, "ds1", rule, start, start.Add(5*time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result) != 2 {
+		t.Fatalf("got %d series, want 2: %+v", len(result), result)
+	}
+
+	for _, series := range result {
+		if want := metricFingerprint(rule, series.Labels, series.Severity); series.Fingerprint != want {
+			t.Errorf("%s fingerprint %s does not match live evaluation %s", series.Severity, series.Fingerprint, want)
+		}
+		if series.Labels["instance"] != "a" || len(series.Intervals) != 1 {
+			t.Fatalf("unexpected series %+v", series)
+		}
+
+		interval := series.Intervals[0]
+		firingAt := start.Add(2 * time.Minute).Unix()
+		if series.Severity == "P0" {
+			firingAt = start.Add(3 * time.Minute).Unix()
+		}
+		if interval.FiringAt != firingAt || interval.ResolvedAt != start.Add(4*time.Minute).Unix() {
+			t.Errorf("%s interval %+v", series.Severity, interval)
+		}
+	}
+	if result[0].Fingerprint == result[1].Fingerprint {
+		t.Error("severities should have distinct fingerprints")
+	}
+}
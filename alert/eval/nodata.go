@@ -0,0 +1,229 @@
+package eval
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"watchAlert/alert/process"
+	"watchAlert/internal/ctx"
+	"watchAlert/internal/models"
+	"watchAlert/pkg/provider"
+	"watchAlert/pkg/tools"
+
+	"github.com/bytedance/sonic"
+	"github.com/zeromicro/go-zero/core/logc"
+)
+
+const (
+	// seenSeriesKeyPrefix 规则在数据源上出现过的序列, field 为序列指纹
+	seenSeriesKeyPrefix = "w8t:seenSeries:"
+
+	// noDataLabel 无数据告警的标签, 区分于规则本身的告警
+	noDataLabel = "no_data"
+	// noDataQuery 查询无数据或数据源不可用
+	noDataQuery = "query"
+	// noDataAbsent 之前出现过的序列消失
+	noDataAbsent = "absent"
+)
+
+// noDataDatasourceTypes 支持无数据策略的规则类型
+var noDataDatasourceTypes = map[string]struct{}{
+	DatasourceTypePrometheus:    {},
+	DatasourceTypeAliCloudSLS:   {},
+	DatasourceTypeLoki:          {},
+	DatasourceTypeElasticSearch: {},
+	DatasourceTypeVictoriaLogs:  {},
+	DatasourceTypeClickHouse:    {},
+}
+
+// seenSeries 出现过的序列
+type seenSeries struct {
+	Labels   map[string]interface{} `json:"labels"`
+	LastSeen int64                  `json:"lastSeen"`
+}
+
+// handleNoData 查询无数据或数据源不可用时按规则的无数据策略处理, 返回需要保持的事件指纹
+func handleNoData(ctx *ctx.Context, datasourceId string, rule models.AlertRule, reason string) []string {
+	policy := rule.NoDataConfig.GetPolicy()
+	if _, ok := noDataDatasourceTypes[rule.DatasourceType]; !ok || policy == models.NoDataResolve {
+		return nil
+	}
+
+	fingerprints := activeFingerprints(ctx, rule, datasourceId, nil)
+	logc.Infof(ctx.Ctx, "规则无数据, 策略: %s, 规则ID: %s, 规则名称: %s, 数据源ID: %s, 原因: %s, 保持事件数: %d", policy, rule.RuleId, rule.RuleName, datasourceId, reason, len(fingerprints))
+
+	if policy == models.NoDataAlerting {
+		labels := map[string]interface{}{
+			"datasource_id": datasourceId,
+		}
+		annotations := fmt.Sprintf("规则「%s」在数据源「%s」上无数据, 原因: %s。该告警表示监控数据缺失, 并非故障恢复或再次发生, 请检查数据源及采集端。", rule.RuleName, datasourceId, reason)
+		fingerprints = append(fingerprints, pushNoDataEvent(ctx, datasourceId, rule, noDataQuery, labels, annotations))
+	}
+
+	return fingerprints
+}
+
+// detectAbsentSeries 记录本次查询返回的序列, 对之前出现过但本次消失的序列按无数据策略处理, 返回需要保持的事件指纹
+func detectAbsentSeries(ctx *ctx.Context, datasourceId string, rule models.AlertRule, resQuery []provider.Metrics) []string {
+	config := rule.NoDataConfig
+	if !config.AbsentDetection || config.GetPolicy() == models.NoDataResolve {
+		return nil
+	}
+
+	var (
+		key     = seenSeriesKeyPrefix + rule.TenantId + ":" + rule.RuleId + ":" + datasourceId
+		now     = time.Now().Unix()
+		current = make(map[string]interface{}, len(resQuery))
+	)
+	for _, v := range resQuery {
+		current[v.GetFingerprint()] = tools.JsonMarshalToString(seenSeries{Labels: v.GetMetric(), LastSeen: now})
+	}
+
+	stored, err := ctx.Redis.Redis().HGetAll(key).Result()
+	if err != nil {
+		logc.Errorf(ctx.Ctx, "获取规则历史序列失败, 规则ID: %s, 数据源ID: %s, 错误: %v", rule.RuleId, datasourceId, err)
+		return nil
+	}
+
+	if len(current) > 0 {
+		ctx.Redis.Redis().HMSet(key, current)
+	}
+	ctx.Redis.Redis().Expire(key, time.Duration(config.GetAbsentRetention())*time.Second*2)
+
+	var (
+		expired []string
+		absent  []map[string]interface{}
+	)
+	for seriesFingerprint, value := range stored {
+		if _, ok := current[seriesFingerprint]; ok {
+			continue
+		}
+
+		var series seenSeries
+		if err := sonic.UnmarshalString(value, &series); err != nil || now-series.LastSeen > config.GetAbsentRetention() {
+			expired = append(expired, seriesFingerprint)
+			continue
+		}
+		absent = append(absent, series.Labels)
+	}
+	if len(expired) > 0 {
+		ctx.Redis.Redis().HDel(key, expired...)
+	}
+	if len(absent) == 0 {
+		return nil
+	}
+
+	// 保持消失序列已有的告警事件
+	fingerprints := activeFingerprints(ctx, rule, datasourceId, absent)
+
+	if config.GetPolicy() == models.NoDataAlerting {
+		for _, labels := range absent {
+			eventLabels := make(map[string]interface{}, len(labels)+1)
+			for k, v := range labels {
+				eventLabels[k] = v
+			}
+			eventLabels["datasource_id"] = datasourceId
+
+			annotations := fmt.Sprintf("规则「%s」的序列 %s 已无数据。该告警表示监控数据缺失, 并非故障恢复或再次发生, 请检查对应的采集目标。", rule.RuleName, formatSeriesLabels(labels))
+			fingerprints = append(fingerprints, pushNoDataEvent(ctx, datasourceId, rule, noDataAbsent, eventLabels, annotations))
+		}
+	}
+
+	return fingerprints
+}
+
+// activeFingerprints 获取规则在数据源上未恢复的事件指纹, series 不为空时仅返回这些序列的事件
+func activeFingerprints(ctx *ctx.Context, rule models.AlertRule, datasourceId string, series []map[string]interface{}) []string {
+	events, err := ctx.Redis.Alert().GetAllEvents(models.BuildAlertEventCacheKey(rule.TenantId, rule.FaultCenterId))
+	if err != nil {
+		return nil
+	}
+
+	var wanted map[string]struct{}
+	if series != nil {
+		wanted = make(map[string]struct{})
+		for _, labels := range series {
+			for _, r := range rule.PrometheusConfig.Rules {
+				wanted[metricFingerprint(rule, labels, r.Severity)] = struct{}{}
+			}
+		}
+	}
+
+	var fingerprints []string
+	for fingerprint, event := range events {
+		if event.RuleId != rule.RuleId || event.DatasourceId != datasourceId || event.IsRecovered {
+			continue
+		}
+		// 无数据告警由本次评估重新生成
+		if _, ok := event.Labels[noDataLabel]; ok {
+			continue
+		}
+		if wanted != nil {
+			if _, ok := wanted[fingerprint]; !ok {
+				continue
+			}
+		}
+		fingerprints = append(fingerprints, fingerprint)
+	}
+
+	return fingerprints
+}
+
+// pushNoDataEvent 推送无数据告警事件, 返回事件指纹
+func pushNoDataEvent(ctx *ctx.Context, datasourceId string, rule models.AlertRule, kind string, labels map[string]interface{}, annotations string) string {
+	severity := noDataSeverity(rule)
+
+	fingerprintLabels := make(map[string]interface{}, len(labels)+3)
+	for k, v := range labels {
+		fingerprintLabels[k] = v
+	}
+	fingerprintLabels["rule_id"] = rule.RuleId
+	fingerprintLabels[noDataLabel] = kind
+	fingerprint := provider.Metrics{Labels: fingerprintLabels}.GetFingerprint()
+
+	event := process.BuildEvent(rule, func() map[string]interface{} {
+		newLabels := make(map[string]interface{}, len(labels)+4)
+		for k, v := range labels {
+			newLabels[k] = v
+		}
+		newLabels["rule_name"] = rule.RuleName
+		newLabels["fingerprint"] = fingerprint
+		newLabels["severity"] = severity
+		newLabels[noDataLabel] = kind
+		for k, v := range rule.ExternalLabels {
+			newLabels[k] = v
+		}
+		return newLabels
+	})
+	event.DatasourceId = datasourceId
+	event.Fingerprint = fingerprint
+	event.Severity = severity
+	event.ForDuration = rule.GetForDuration(severity)
+	event.Annotations = annotations
+	event.Status = models.StatePreAlert
+
+	process.PushEventToFaultCenter(ctx, &event)
+	return fingerprint
+}
+
+// noDataSeverity 无数据告警的等级, 未配置时使用规则中的最高等级
+func noDataSeverity(rule models.AlertRule) string {
+	if rule.NoDataConfig.Severity != "" {
+		return rule.NoDataConfig.Severity
+	}
+	if len(rule.PrometheusConfig.Rules) > 0 {
+		return sortRulesByPriority(rule.PrometheusConfig.Rules)[0].Severity
+	}
+	return rule.Severity
+}
+
+func formatSeriesLabels(labels map[string]interface{}) string {
+	pairs := make([]string, 0, len(labels))
+	for k, v := range labels {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", k, fmt.Sprintf("%v", v)))
+	}
+	sort.Strings(pairs)
+
+	return "{" + strings.Join(pairs, ", ") + "}"
+}
@@ -1,6 +1,7 @@
 package eval
 
 import (
+	"context"
 	"fmt"
 	"sort"
 	"strings"
@@ -23,14 +24,13 @@ func metrics(ctx *ctx.Context, datasourceId, datasourceType string, rule models.
 		externalLabels map[string]interface{}
 		// 当前活跃告警的指纹列表
 		curFingerprints []string
-		// 按指纹分组存储事件，相同规则只保留最高优先级的事件
-		highestPriorityEvents = make(map[string]struct{})
 	)
 
 	cli, err := pools.GetClient(datasourceId)
 	if err != nil {
 		logc.Errorf(ctx.Ctx, "获取数据源客户端失败, 规则ID: %s, 规则名称: %s, 数据源ID: %s, 错误: %v", rule.RuleId, rule.RuleName, datasourceId, err)
-		return nil
+		recordEvalFailure(rule)
+		return handleNoData(ctx, datasourceId, rule, fmt.Sprintf("获取数据源客户端失败: %v", err))
 	}
 
 	switch datasourceType {
@@ -38,7 +38,8 @@ func metrics(ctx *ctx.Context, datasourceId, datasourceType string, rule models.
 		resQuery, err = cli.(provider.PrometheusProvider).Query(rule.PrometheusConfig.PromQL)
 		if err != nil {
 			logc.Errorf(ctx.Ctx, "Prometheus查询失败, 规则ID: %s, 规则名称: %s, 数据源ID: %s, PromQL: %s, 错误: %v", rule.RuleId, rule.RuleName, datasourceId, rule.PrometheusConfig.PromQL, err)
-			return nil
+			recordEvalFailure(rule)
+			return handleNoData(ctx, datasourceId, rule, fmt.Sprintf("查询失败: %v", err))
 		}
 
 		// 检查查询结果数量，避免过多结果导致系统压力
@@ -54,9 +55,58 @@ func metrics(ctx *ctx.Context, datasourceId, datasourceType string, rule models.
 	}
 
 	if len(resQuery) == 0 {
+		return handleNoData(ctx, datasourceId, rule, "查询结果为空")
+	}
+
+	// 获取初次触发值
+	firstValue := func(fingerprint string) interface{} {
+		data, err := ctx.Redis.Alert().GetEventFromCache(rule.TenantId, rule.FaultCenterId, fingerprint)
+		if err == nil {
+			return data.Labels["first_value"]
+		}
 		return nil
 	}
 
+	for _, result := range evalMetricEvents(ctx.Ctx, datasourceId, rule, resQuery, externalLabels, firstValue) {
+		event := result.event
+		// 告警评估
+		if result.matched {
+			queryCallbackLabels(ctx.Ctx, cli.(provider.PrometheusProvider), rule, &event)
+			process.PushEventToFaultCenter(ctx, &event)
+			curFingerprints = append(curFingerprints, event.Fingerprint)
+		} else {
+			// 更新恢复时最新值
+			cache, err := ctx.Redis.Alert().GetEventFromCache(event.TenantId, event.FaultCenterId, event.Fingerprint)
+			if err == nil {
+				if !cache.IsRecovered && cache.Status != models.StateRecovered {
+					event.Labels["value"] = result.value
+					process.PushEventToFaultCenter(ctx, &event)
+				}
+			}
+		}
+	}
+
+	// 检测消失的序列
+	curFingerprints = append(curFingerprints, detectAbsentSeries(ctx, datasourceId, rule, resQuery)...)
+
+	return curFingerprints
+}
+
+// metricEvent 序列在某一告警等级下构建的事件
+type metricEvent struct {
+	event   models.AlertCurEvent
+	value   float64
+	matched bool
+}
+
+// evalMetricEvents 按优先级（P0 > P1 > P2）评估每个序列的告警条件并构建事件, firstValue 返回事件初次触发时的值, 不存在时返回 nil
+func evalMetricEvents(ctx context.Context, datasourceId string, rule models.AlertRule, resQuery []provider.Metrics, externalLabels map[string]interface{}, firstValue func(fingerprint string) interface{}) []metricEvent {
+	var (
+		results []metricEvent
+		// 按指纹分组存储事件，相同规则只保留最高优先级的事件
+		highestPriorityEvents = make(map[string]struct{})
+	)
+
 	// 按优先级排序规则（P0 > P1 > P2）
 	rules := sortRulesByPriority(rule.PrometheusConfig.Rules)
 
@@ -67,27 +117,15 @@ func metrics(ctx *ctx.Context, datasourceId, datasourceType string, rule models.
 			metricLabels[k] = val
 		}
 
-		// 使用独立的标签副本来生成指纹，避免修改原始数据
-		fingerprintLabels := make(map[string]interface{})
-		for k, val := range metricLabels {
-			fingerprintLabels[k] = val
-		}
-		fingerprintLabels["rule_id"] = rule.RuleId
-		fingerprintLabels["rule_name"] = rule.RuleName
-
 		// 遍历按优先级排序后的规则
 		for _, ruleExpr := range rules {
-			fingerprintLabels["severity"] = ruleExpr.Severity
 			operator, value, err := process.ProcessRuleExpr(ruleExpr.Expr)
 			if err != nil {
-				logc.Errorf(ctx.Ctx, "处理规则表达式失败, 规则ID: %s, 规则名称: %s, 表达式: %s, 错误: %v", rule.RuleId, rule.RuleName, ruleExpr.Expr, err)
+				logc.Errorf(ctx, "处理规则表达式失败, 规则ID: %s, 规则名称: %s, 表达式: %s, 错误: %v", rule.RuleId, rule.RuleName, ruleExpr.Expr, err)
 				continue
 			}
 
-			fingerprintMetric := provider.Metrics{
-				Labels: fingerprintLabels,
-			}
-			fingerprint := fingerprintMetric.GetFingerprint()
+			fingerprint := metricFingerprint(rule, metricLabels, ruleExpr.Severity)
 
 			event := process.BuildEvent(rule, func() map[string]interface{} {
 				newMetric := make(map[string]interface{})
@@ -105,10 +143,8 @@ func metrics(ctx *ctx.Context, datasourceId, datasourceType string, rule models.
 					newMetric[ek] = ev
 				}
 
-				// 获取初次触发值
-				data, err := ctx.Redis.Alert().GetEventFromCache(rule.TenantId, rule.FaultCenterId, fingerprint)
-				if err == nil && data.Labels["first_value"] != nil {
-					newMetric["first_value"] = data.Labels["first_value"]
+				if first := firstValue(fingerprint); first != nil {
+					newMetric["first_value"] = first
 				} else {
 					newMetric["first_value"] = v.Value
 				}
@@ -123,49 +159,55 @@ func metrics(ctx *ctx.Context, datasourceId, datasourceType string, rule models.
 			event.Annotations = tools.ParserVariables(rule.PrometheusConfig.Annotations, tools.ConvertStructToMap(event))
 			event.Status = models.StatePreAlert
 
-			// 告警评估
-			if process.EvalCondition(models.EvalCondition{
+			matched := process.EvalCondition(models.EvalCondition{
 				Operator:      operator,
 				QueryValue:    v.Value,
 				ExpectedValue: value,
-			}) {
+			})
+			if matched {
 				if len(highestPriorityEvents) > 0 {
 					// 如果有高优先级告警，则抑制掉低级告警
 					event.LastSendTime = time.Now().Unix()
 				}
 				highestPriorityEvents[fingerprint] = struct{}{}
-				event.Status = models.StatePreAlert
-
-				if len(rule.PrometheusConfig.CallbakPromQLs) > 0 {
-					for _, callbak := range rule.PrometheusConfig.CallbakPromQLs {
-						ql := tools.ParserVariables(callbak.Value, map[string]interface{}{"labels": event.Labels})
-						callbakQuery, err := cli.(provider.PrometheusProvider).Query(ql)
-						if err != nil {
-							logc.Errorf(ctx.Ctx, "query callback promql error: %v, callback_key: %s, callback_promql: %s", err, callbak.Key, callbak.Value)
-						}
-
-						if len(callbakQuery) > 0 {
-							event.Labels[callbak.Key] = callbakQuery[0].GetValue()
-						}
-					}
-				}
-
-				process.PushEventToFaultCenter(ctx, &event)
-				curFingerprints = append(curFingerprints, fingerprint)
-			} else {
-				// 更新恢复时最新值
-				cache, err := ctx.Redis.Alert().GetEventFromCache(event.TenantId, event.FaultCenterId, event.Fingerprint)
-				if err == nil {
-					if !cache.IsRecovered && cache.Status != models.StateRecovered {
-						event.Labels["value"] = v.GetValue()
-						process.PushEventToFaultCenter(ctx, &event)
-					}
-				}
 			}
+
+			results = append(results, metricEvent{event: event, value: v.GetValue(), matched: matched})
 		}
 	}
 
-	return curFingerprints
+	return results
+}
+
+// metricFingerprint 计算序列在某一告警等级下的事件指纹, 使用独立的标签副本, 避免修改原始数据
+func metricFingerprint(rule models.AlertRule, metricLabels map[string]interface{}, severity string) string {
+	fingerprintLabels := make(map[string]interface{})
+	for k, val := range metricLabels {
+		fingerprintLabels[k] = val
+	}
+	fingerprintLabels["rule_id"] = rule.RuleId
+	fingerprintLabels["rule_name"] = rule.RuleName
+	fingerprintLabels["severity"] = severity
+
+	fingerprintMetric := provider.Metrics{
+		Labels: fingerprintLabels,
+	}
+	return fingerprintMetric.GetFingerprint()
+}
+
+// queryCallbackLabels 执行回调 PromQL, 将查询结果写入事件标签
+func queryCallbackLabels(ctx context.Context, cli provider.MetricsFactoryProvider, rule models.AlertRule, event *models.AlertCurEvent) {
+	for _, callbak := range rule.PrometheusConfig.CallbakPromQLs {
+		ql := tools.ParserVariables(callbak.Value, map[string]interface{}{"labels": event.Labels})
+		callbakQuery, err := cli.Query(ql)
+		if err != nil {
+			logc.Errorf(ctx, "query callback promql error: %v, callback_key: %s, callback_promql: %s", err, callbak.Key, callbak.Value)
+		}
+
+		if len(callbakQuery) > 0 {
+			event.Labels[callbak.Key] = callbakQuery[0].GetValue()
+		}
+	}
 }
 
 // sortRulesByPriority 按优先级排序规则
@@ -217,7 +259,8 @@ func logs(ctx *ctx.Context, datasourceId, datasourceType string, rule models.Ale
 	cli, err := pools.GetClient(datasourceId)
 	if err != nil {
 		logc.Errorf(ctx.Ctx, "获取数据源客户端失败, 规则ID: %s, 规则名称: %s, 数据源ID: %s, 错误: %v", rule.RuleId, rule.RuleName, datasourceId, err)
-		return []string{}
+		recordEvalFailure(rule)
+		return handleNoData(ctx, datasourceId, rule, fmt.Sprintf("获取数据源客户端失败: %v", err))
 	}
 
 	switch datasourceType {
@@ -233,7 +276,8 @@ func logs(ctx *ctx.Context, datasourceId, datasourceType string, rule models.Ale
 		log, count, err = cli.(provider.LokiProvider).Query(queryOptions)
 		if err != nil {
 			logc.Errorf(ctx.Ctx, "Loki查询失败, 规则ID: %s, 规则名称: %s, 数据源ID: %s, LogQL: %s, 错误: %v", rule.RuleId, rule.RuleName, datasourceId, rule.LokiConfig.LogQL, err)
-			return []string{}
+			recordEvalFailure(rule)
+			return handleNoData(ctx, datasourceId, rule, fmt.Sprintf("查询失败: %v", err))
 		}
 
 		externalLabels = cli.(provider.LokiProvider).GetExternalLabels()
@@ -262,7 +306,8 @@ func logs(ctx *ctx.Context, datasourceId, datasourceType string, rule models.Ale
 		log, count, err = cli.(provider.AliCloudSlsDsProvider).Query(queryOptions)
 		if err != nil {
 			logc.Errorf(ctx.Ctx, "AliCloudSLS查询失败, 规则ID: %s, 规则名称: %s, 数据源ID: %s, LogQL: %s, 错误: %v", rule.RuleId, rule.RuleName, datasourceId, rule.AliCloudSLSConfig.LogQL, err)
-			return []string{}
+			recordEvalFailure(rule)
+			return handleNoData(ctx, datasourceId, rule, fmt.Sprintf("查询失败: %v", err))
 		}
 
 		externalLabels = cli.(provider.AliCloudSlsDsProvider).GetExternalLabels()
@@ -291,7 +336,8 @@ func logs(ctx *ctx.Context, datasourceId, datasourceType string, rule models.Ale
 		log, count, err = cli.(provider.ElasticSearchDsProvider).Query(queryOptions)
 		if err != nil {
 			logc.Errorf(ctx.Ctx, "ElasticSearch查询失败, 规则ID: %s, 规则名称: %s, 数据源ID: %s, 索引: %s, 错误: %v", rule.RuleId, rule.RuleName, datasourceId, rule.ElasticSearchConfig.Index, err)
-			return []string{}
+			recordEvalFailure(rule)
+			return handleNoData(ctx, datasourceId, rule, fmt.Sprintf("查询失败: %v", err))
 		}
 
 		externalLabels = cli.(provider.ElasticSearchDsProvider).GetExternalLabels()
@@ -319,7 +365,8 @@ func logs(ctx *ctx.Context, datasourceId, datasourceType string, rule models.Ale
 		log, count, err = cli.(provider.VictoriaLogsProvider).Query(queryOptions)
 		if err != nil {
 			logc.Errorf(ctx.Ctx, "VictoriaLogs查询失败, 规则ID: %s, 规则名称: %s, 数据源ID: %s, LogQL: %s, 错误: %v", rule.RuleId, rule.RuleName, datasourceId, rule.VictoriaLogsConfig.LogQL, err)
-			return []string{}
+			recordEvalFailure(rule)
+			return handleNoData(ctx, datasourceId, rule, fmt.Sprintf("查询失败: %v", err))
 		}
 
 		externalLabels = cli.(provider.VictoriaLogsProvider).GetExternalLabels()
@@ -343,7 +390,8 @@ func logs(ctx *ctx.Context, datasourceId, datasourceType string, rule models.Ale
 		log, count, err = cli.(provider.ClickHouseProvider).Query(queryOptions)
 		if err != nil {
 			logc.Errorf(ctx.Ctx, "ClickHouse查询失败, 规则ID: %s, 规则名称: %s, 数据源ID: %s, LogQL: %s, 错误: %v", rule.RuleId, rule.RuleName, datasourceId, rule.ClickHouseConfig.LogQL, err)
-			return []string{}
+			recordEvalFailure(rule)
+			return handleNoData(ctx, datasourceId, rule, fmt.Sprintf("查询失败: %v", err))
 		}
 
 		externalLabels = cli.(provider.ClickHouseProvider).GetExternalLabels()
@@ -433,6 +481,7 @@ func traces(ctx *ctx.Context, datasourceId, datasourceType string, rule models.A
 		cli, err := pools.GetClient(datasourceId)
 		if err != nil {
 			logc.Errorf(ctx.Ctx, "获取Jaeger数据源客户端失败, 规则ID: %s, 规则名称: %s, 数据源ID: %s, 错误: %v", rule.RuleId, rule.RuleName, datasourceId, err)
+			recordEvalFailure(rule)
 			return []string{}
 		}
 
@@ -445,6 +494,7 @@ func traces(ctx *ctx.Context, datasourceId, datasourceType string, rule models.A
 		queryRes, err = cli.(provider.JaegerDsProvider).Query(queryOptions)
 		if err != nil {
 			logc.Errorf(ctx.Ctx, "Jaeger查询失败, 规则ID: %s, 规则名称: %s, 数据源ID: %s, 服务: %s, 错误: %v", rule.RuleId, rule.RuleName, datasourceId, rule.JaegerConfig.Service, err)
+			recordEvalFailure(rule)
 			return []string{}
 		}
 
@@ -487,6 +537,7 @@ func cloudWatch(ctx *ctx.Context, datasourceId, datasourceType string, rule mode
 	cfg, err := pools.GetClient(datasourceId)
 	if err != nil {
 		logc.Errorf(ctx.Ctx, "获取CloudWatch数据源客户端失败, 规则ID: %s, 规则名称: %s, 数据源ID: %s, 错误: %v", rule.RuleId, rule.RuleName, datasourceId, err)
+		recordEvalFailure(rule)
 		return []string{}
 	}
 
@@ -549,6 +600,7 @@ func kubernetesEvent(ctx *ctx.Context, datasourceId, datasourceType string, rule
 	datasourceObj, err := ctx.DB.Datasource().GetInstance(datasourceId)
 	if err != nil {
 		logc.Errorf(ctx.Ctx, "获取数据源实例失败, 规则ID: %s, 规则名称: %s, 数据源ID: %s, 错误: %v", rule.RuleId, rule.RuleName, datasourceId, err)
+		recordEvalFailure(rule)
 		return []string{}
 	}
 
@@ -556,6 +608,7 @@ func kubernetesEvent(ctx *ctx.Context, datasourceId, datasourceType string, rule
 	cli, err := pools.GetClient(datasourceId)
 	if err != nil {
 		logc.Errorf(ctx.Ctx, "获取Kubernetes数据源客户端失败, 规则ID: %s, 规则名称: %s, 数据源ID: %s, 错误: %v", rule.RuleId, rule.RuleName, datasourceId, err)
+		recordEvalFailure(rule)
 		return []string{}
 	}
 
@@ -566,6 +619,7 @@ func kubernetesEvent(ctx *ctx.Context, datasourceId, datasourceType string, rule
 	k8sEventMap, err := k8sClient.GetWarningEvent(rule.KubernetesConfig.Reason, rule.KubernetesConfig.Scope, rule.KubernetesConfig.Filter)
 	if err != nil {
 		logc.Errorf(ctx.Ctx, "获取Kubernetes警告事件失败, 规则ID: %s, 规则名称: %s, 数据源ID: %s, 原因: %s, 错误: %v", rule.RuleId, rule.RuleName, datasourceId, rule.KubernetesConfig.Reason, err)
+		recordEvalFailure(rule)
 		return []string{}
 	}
 
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"runtime/debug"
+	"strconv"
 	"sync"
 	"time"
 	"watchAlert/internal/ctx"
@@ -35,13 +36,18 @@ func NewRecordingRuleEval(ctx *ctx.Context) RecordingRuleEval {
 	}
 }
 
-// Submit 提交记录规则评估任务
+// Submit 提交记录规则评估任务, 已存在的任务先取消
 func (t *RecordingRule) Submit(rule models.RecordingRule) {
 	t.ctx.Mux.Lock()
 	defer t.ctx.Mux.Unlock()
 
+	if cancel, exists := t.ctx.ContextMap[rule.RuleId]; exists {
+		cancel()
+	}
+
 	c, cancel := context.WithCancel(context.Background())
 	t.ctx.ContextMap[rule.RuleId] = cancel
+	t.ctx.TaskVersions[rule.RuleId] = strconv.FormatInt(rule.UpdateAt, 10)
 	go t.Eval(c, rule)
 }
 
@@ -53,6 +59,7 @@ func (t *RecordingRule) Stop(ruleId string) {
 	if cancel, exists := t.ctx.ContextMap[ruleId]; exists {
 		cancel()
 		delete(t.ctx.ContextMap, ruleId)
+		delete(t.ctx.TaskVersions, ruleId)
 	}
 }
 
@@ -115,8 +122,8 @@ func (t *RecordingRule) processSingleDatasource(rule models.RecordingRule) {
 		return
 	}
 
-	// 检查数据源健康状态
-	if ok, _ := provider.CheckDatasourceHealth(instance); !ok {
+	// 检查数据源健康状态, 由健康检查任务周期更新
+	if !t.ctx.Redis.DatasourceHealth().Get(rule.DatasourceId).IsAvailable() {
 		logc.Errorf(t.ctx.Ctx, "Datasource %s is unhealthy", rule.DatasourceId)
 		return
 	}
@@ -256,6 +263,7 @@ func (t *RecordingRule) StopAllEvals() {
 	for ruleId, cancel := range t.ctx.ContextMap {
 		cancel()
 		delete(t.ctx.ContextMap, ruleId)
+		delete(t.ctx.TaskVersions, ruleId)
 	}
 
 	logc.Infof(t.ctx.Ctx, "所有记录规则评估器已停止")
@@ -0,0 +1,468 @@
+package eval
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"watchAlert/alert/process"
+	"watchAlert/internal/models"
+	"watchAlert/pkg/provider"
+
+	"github.com/bytedance/sonic"
+	"github.com/prometheus/common/model"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	ruleTestDatasourceId        = "rule-test"
+	ruleTestDefaultEvalInterval = time.Minute
+
+	// 通过接口执行单元测试时的限制, 避免单个请求占用过多内存及 CPU
+	ruleTestMaxSeries    = 1000
+	ruleTestMaxSamples   = 100000
+	ruleTestMaxChecks    = 1000
+	ruleTestMaxEvalSteps = 10000
+)
+
+// ruleTestIgnoreLabels 比对告警标签时忽略的内置标签
+var ruleTestIgnoreLabels = []string{"fingerprint", "value", "first_value", "rule_name"}
+
+// LoadRuleTestFile 加载单元测试文件及其引用的规则文件
+func LoadRuleTestFile(path string) (models.RuleTestFile, error) {
+	var file models.RuleTestFile
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return file, err
+	}
+	if err := unmarshalYamlOrJson(data, &file); err != nil {
+		return file, fmt.Errorf("解析测试文件 %s 失败: %w", path, err)
+	}
+
+	for _, ruleFile := range file.RuleFiles {
+		if !filepath.IsAbs(ruleFile) {
+			ruleFile = filepath.Join(filepath.Dir(path), ruleFile)
+		}
+		rules, err := loadRuleFile(ruleFile)
+		if err != nil {
+			return file, err
+		}
+		file.Rules = append(file.Rules, rules...)
+	}
+
+	return file, nil
+}
+
+// ParseRuleTestFile 解析单元测试文件内容, 仅支持内联的规则
+func ParseRuleTestFile(content string) (models.RuleTestFile, error) {
+	var file models.RuleTestFile
+	if err := unmarshalYamlOrJson([]byte(content), &file); err != nil {
+		return file, fmt.Errorf("解析测试文件失败: %w", err)
+	}
+	if len(file.RuleFiles) > 0 {
+		return file, fmt.Errorf("不支持引用规则文件, 请使用 rules 内联规则")
+	}
+	if err := checkRuleTestLimits(file); err != nil {
+		return file, err
+	}
+
+	return file, nil
+}
+
+// checkRuleTestLimits 校验输入序列的样本数及评估次数, 样本数按 promtool 的展开语法计算, 不实际展开
+func checkRuleTestLimits(file models.RuleTestFile) error {
+	defaultInterval := ruleTestDefaultEvalInterval
+	if d, err := model.ParseDuration(file.EvaluationInterval); err == nil && d > 0 {
+		defaultInterval = time.Duration(d)
+	}
+	steps := make(map[string]time.Duration)
+	for _, rule := range file.Rules {
+		steps[rule.RuleName] = defaultInterval
+		if rule.EvalInterval > 0 {
+			steps[rule.RuleName] = time.Duration(rule.EvalInterval) * time.Second
+		}
+	}
+
+	var series, samples, checks, evalSteps int
+	for _, group := range file.Tests {
+		series += len(group.InputSeries)
+		for _, input := range group.InputSeries {
+			n, err := countRuleTestSamples(input.Values)
+			if err != nil {
+				return fmt.Errorf("序列 %s 的样本无效: %w", input.Series, err)
+			}
+			samples += n
+			if samples > ruleTestMaxSamples {
+				return fmt.Errorf("输入序列的样本总数不能超过 %d", ruleTestMaxSamples)
+			}
+		}
+
+		// 每个规则从 0 开始评估至最大的评估时间
+		maxEvalTime := make(map[string]time.Duration)
+		for _, check := range group.AlertRuleTests {
+			checks++
+			if d, err := model.ParseDuration(check.EvalTime); err == nil && time.Duration(d) > maxEvalTime[check.AlertName] {
+				maxEvalTime[check.AlertName] = time.Duration(d)
+			}
+		}
+		for name, evalTime := range maxEvalTime {
+			if step, ok := steps[name]; ok {
+				evalSteps += int(evalTime/step) + 1
+			}
+			if evalSteps > ruleTestMaxEvalSteps {
+				return fmt.Errorf("评估总次数不能超过 %d, 请缩短 eval_time 或增大评估周期", ruleTestMaxEvalSteps)
+			}
+		}
+	}
+	switch {
+	case series > ruleTestMaxSeries:
+		return fmt.Errorf("输入序列数不能超过 %d", ruleTestMaxSeries)
+	case checks > ruleTestMaxChecks:
+		return fmt.Errorf("检查点数不能超过 %d", ruleTestMaxChecks)
+	}
+
+	return nil
+}
+
+// countRuleTestSamples 计算 values 展开后的样本数, 如 "1 0x10 _x3" 为 1 + 11 + 4, 直方图中的空格不作为分隔符
+func countRuleTestSamples(values string) (int, error) {
+	var (
+		count int
+		depth int
+		token strings.Builder
+	)
+	flush := func() error {
+		defer token.Reset()
+		if token.Len() == 0 {
+			return nil
+		}
+
+		text := token.String()
+		i := strings.LastIndex(text, "x")
+		if i < 0 || strings.HasSuffix(text, "}}") {
+			count++
+			return nil
+		}
+		n, err := strconv.Atoi(text[i+1:])
+		if err != nil || n < 0 {
+			return fmt.Errorf("%s 的重复次数无效", text)
+		}
+		count += min(n, ruleTestMaxSamples) + 1
+		return nil
+	}
+
+	for _, r := range values {
+		switch {
+		case r == '{':
+			depth++
+		case r == '}':
+			depth--
+		case (r == ' ' || r == '\t' || r == '\n') && depth == 0:
+			if err := flush(); err != nil {
+				return 0, err
+			}
+			if count > ruleTestMaxSamples {
+				return count, nil
+			}
+			continue
+		}
+		token.WriteRune(r)
+	}
+	if err := flush(); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// loadRuleFile 加载 WatchAlert 导出的规则文件, 内容可以是单条规则或规则列表
+func loadRuleFile(path string) ([]models.AlertRule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var rules []models.AlertRule
+	if err := unmarshalYamlOrJson(data, &rules); err == nil {
+		return rules, nil
+	}
+
+	var rule models.AlertRule
+	if err := unmarshalYamlOrJson(data, &rule); err != nil {
+		return nil, fmt.Errorf("解析规则文件 %s 失败: %w", path, err)
+	}
+
+	return []models.AlertRule{rule}, nil
+}
+
+// unmarshalYamlOrJson 按 json 标签解析 YAML 或 JSON 内容
+func unmarshalYamlOrJson(data []byte, v interface{}) error {
+	var raw interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	body, err := sonic.Marshal(raw)
+	if err != nil {
+		return err
+	}
+
+	return sonic.Unmarshal(body, v)
+}
+
+// RunRuleTests 使用内存序列执行告警规则单元测试, 评估及模版渲染与 Prometheus 告警规则评估一致
+func RunRuleTests(file models.RuleTestFile) models.RuleTestResult {
+	result := models.RuleTestResult{Success: true}
+
+	defaultInterval := ruleTestDefaultEvalInterval
+	if file.EvaluationInterval != "" {
+		interval, err := model.ParseDuration(file.EvaluationInterval)
+		if err != nil {
+			result.Success = false
+			result.Failed++
+			result.Cases = append(result.Cases, models.RuleTestCaseResult{Error: fmt.Sprintf("evaluation_interval 无效: %v", err)})
+			return result
+		}
+		defaultInterval = time.Duration(interval)
+	}
+
+	rules := make(map[string]models.AlertRule)
+	for _, rule := range file.Rules {
+		rules[rule.RuleName] = rule
+	}
+
+	for i, group := range file.Tests {
+		name := group.Name
+		if name == "" {
+			name = fmt.Sprintf("#%d", i+1)
+		}
+
+		for _, c := range runRuleTestGroup(name, group, rules, defaultInterval, file.ExternalLabels) {
+			result.Total++
+			if !c.Success {
+				result.Failed++
+				result.Success = false
+			}
+			result.Cases = append(result.Cases, c)
+		}
+	}
+
+	return result
+}
+
+func runRuleTestGroup(name string, group models.RuleTestGroup, rules map[string]models.AlertRule, defaultInterval time.Duration, externalLabels map[string]interface{}) []models.RuleTestCaseResult {
+	var cases []models.RuleTestCaseResult
+	fail := func(c models.RuleTestCaseResult, format string, args ...interface{}) {
+		c.Error = fmt.Sprintf(format, args...)
+		cases = append(cases, c)
+	}
+
+	interval := defaultInterval
+	if group.Interval != "" {
+		d, err := model.ParseDuration(group.Interval)
+		if err != nil {
+			fail(models.RuleTestCaseResult{Group: name}, "interval 无效: %v", err)
+			return cases
+		}
+		interval = time.Duration(d)
+	}
+
+	start := time.Unix(0, 0).UTC()
+	cli, err := provider.NewMemoryProvider(group.InputSeries, start, interval, externalLabels)
+	if err != nil {
+		fail(models.RuleTestCaseResult{Group: name}, "%v", err)
+		return cases
+	}
+
+	// 按规则分组, 同一规则按评估时间依次模拟
+	checks := make(map[string][]models.RuleTestAlert)
+	var ruleNames []string
+	for _, check := range group.AlertRuleTests {
+		if _, ok := checks[check.AlertName]; !ok {
+			ruleNames = append(ruleNames, check.AlertName)
+		}
+		checks[check.AlertName] = append(checks[check.AlertName], check)
+	}
+
+	for _, ruleName := range ruleNames {
+		rule, ok := rules[ruleName]
+		cases = append(cases, simulateRuleTests(name, rule, ok, cli, start, defaultInterval, checks[ruleName])...)
+	}
+
+	return cases
+}
+
+// simulateRuleTests 从第一个样本开始按规则的评估周期评估, 在各评估时间比对告警中的事件
+func simulateRuleTests(group string, rule models.AlertRule, exists bool, cli *provider.MemoryProvider, start time.Time, defaultInterval time.Duration, checks []models.RuleTestAlert) []models.RuleTestCaseResult {
+	type evalCheck struct {
+		check    models.RuleTestAlert
+		evalTime time.Duration
+	}
+
+	var (
+		cases   []models.RuleTestCaseResult
+		pending []evalCheck
+	)
+	for _, check := range checks {
+		c := models.RuleTestCaseResult{Group: group, AlertName: check.AlertName, EvalTime: check.EvalTime, ExpAlerts: check.ExpAlerts}
+		switch {
+		case !exists:
+			c.Error = fmt.Sprintf("规则 %s 不存在", check.AlertName)
+		case rule.DatasourceType != provider.PrometheusDsProvider:
+			c.Error = fmt.Sprintf("规则 %s 不是 Prometheus 规则", check.AlertName)
+		}
+		evalTime, err := model.ParseDuration(check.EvalTime)
+		if err != nil && c.Error == "" {
+			c.Error = fmt.Sprintf("eval_time 无效: %v", err)
+		}
+		if c.Error != "" {
+			cases = append(cases, c)
+			continue
+		}
+		pending = append(pending, evalCheck{check: check, evalTime: time.Duration(evalTime)})
+	}
+	if len(pending) == 0 {
+		return cases
+	}
+	sort.SliceStable(pending, func(i, j int) bool {
+		return pending[i].evalTime < pending[j].evalTime
+	})
+
+	step := defaultInterval
+	if rule.EvalInterval > 0 {
+		step = time.Duration(rule.EvalInterval) * time.Second
+	}
+	for _, r := range rule.PrometheusConfig.Rules {
+		if _, _, err := process.ProcessRuleExpr(r.Expr); err != nil {
+			for _, p := range pending {
+				cases = append(cases, models.RuleTestCaseResult{Group: group, AlertName: p.check.AlertName, EvalTime: p.check.EvalTime, ExpAlerts: p.check.ExpAlerts, Error: fmt.Sprintf("告警等级 %s 的表达式无效: %v", r.Severity, err)})
+			}
+			return cases
+		}
+	}
+
+	var (
+		active  = make(map[string]models.AlertCurEvent)
+		evalErr error
+	)
+	for t := time.Duration(0); len(pending) > 0 && t <= pending[len(pending)-1].evalTime; t += step {
+		cli.EvalTime = start.Add(t)
+		active, evalErr = evalRuleTestStep(rule, cli, active, int64(t/time.Second))
+
+		// 评估时间在本次与下次评估之间的检查点使用本次评估后的状态
+		for len(pending) > 0 && pending[0].evalTime < t+step {
+			c := models.RuleTestCaseResult{Group: group, AlertName: pending[0].check.AlertName, EvalTime: pending[0].check.EvalTime, ExpAlerts: pending[0].check.ExpAlerts}
+			if evalErr != nil {
+				c.Error = fmt.Sprintf("评估失败: %v", evalErr)
+			} else {
+				c.GotAlerts = firingAlerts(active)
+				c.Success = equalAlerts(c.ExpAlerts, c.GotAlerts)
+			}
+			cases = append(cases, c)
+			pending = pending[1:]
+		}
+	}
+
+	return cases
+}
+
+// evalRuleTestStep 执行一次评估, 与故障中心一致: 持续满足条件超过 ForDuration 后转为告警中, 不满足条件时恢复
+func evalRuleTestStep(rule models.AlertRule, cli *provider.MemoryProvider, active map[string]models.AlertCurEvent, now int64) (map[string]models.AlertCurEvent, error) {
+	resQuery, err := cli.Query(rule.PrometheusConfig.PromQL)
+	if err != nil {
+		return active, err
+	}
+
+	firstValue := func(fingerprint string) interface{} {
+		if prev, ok := active[fingerprint]; ok {
+			return prev.Labels["first_value"]
+		}
+		return nil
+	}
+
+	next := make(map[string]models.AlertCurEvent)
+	for _, result := range evalMetricEvents(context.Background(), ruleTestDatasourceId, rule, resQuery, cli.GetExternalLabels(), firstValue) {
+		if !result.matched {
+			continue
+		}
+
+		event := result.event
+		queryCallbackLabels(context.Background(), cli, rule, &event)
+		event.FirstTriggerTime = now
+		if prev, ok := active[event.Fingerprint]; ok {
+			event.FirstTriggerTime = prev.FirstTriggerTime
+			event.Status = prev.Status
+		}
+		event.LastEvalTime = now
+		if event.Status == models.StatePreAlert && event.IsArriveForDuration() {
+			_ = event.TransitionStatus(models.StateAlerting)
+		}
+		next[event.Fingerprint] = event
+	}
+
+	return next, nil
+}
+
+func firingAlerts(active map[string]models.AlertCurEvent) []models.RuleTestExpAlert {
+	alerts := []models.RuleTestExpAlert{}
+	for _, event := range active {
+		if event.Status != models.StateAlerting {
+			continue
+		}
+
+		labels := make(map[string]string)
+		for k, v := range event.Labels {
+			labels[k] = fmt.Sprintf("%v", v)
+		}
+		for _, k := range ruleTestIgnoreLabels {
+			delete(labels, k)
+		}
+		alerts = append(alerts, models.RuleTestExpAlert{ExpLabels: labels, ExpAnnotations: event.Annotations})
+	}
+	sortAlerts(alerts)
+
+	return alerts
+}
+
+// equalAlerts 比对期望与实际的告警, 忽略顺序
+func equalAlerts(exp, got []models.RuleTestExpAlert) bool {
+	if len(exp) != len(got) {
+		return false
+	}
+
+	exp = append([]models.RuleTestExpAlert(nil), exp...)
+	sortAlerts(exp)
+	for i := range exp {
+		if alertKey(exp[i]) != alertKey(got[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func sortAlerts(alerts []models.RuleTestExpAlert) {
+	sort.Slice(alerts, func(i, j int) bool {
+		return alertKey(alerts[i]) < alertKey(alerts[j])
+	})
+}
+
+func alertKey(alert models.RuleTestExpAlert) string {
+	keys := make([]string, 0, len(alert.ExpLabels))
+	for k := range alert.ExpLabels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, k := range keys {
+		b.WriteString(fmt.Sprintf("%s=%q,", k, alert.ExpLabels[k]))
+	}
+	b.WriteString("|" + strings.TrimSpace(alert.ExpAnnotations))
+
+	return b.String()
+}
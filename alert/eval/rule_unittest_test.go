@@ -0,0 +1,129 @@
+package eval
+
+import (
+	"strings"
+	"testing"
+)
+
+const ruleTestFixture = `
+evaluation_interval: 1m
+rules:
+  - ruleName: InstanceDown
+    datasourceType: Prometheus
+    evalInterval: 60
+    prometheusConfig:
+      promQL: up
+      annotations: '${labels.instance} is down'
+      rules:
+        - severity: P1
+          expr: '== 0'
+          forDuration: 60
+tests:
+  - interval: 1m
+    input_series:
+      # 0x3 展开为 4 个样本: 1 0 0 0 0 1 1
+      - series: 'up{job="node", instance="a"}'
+        values: '1 0x3 1 1'
+      - series: 'up{job="node", instance="b"}'
+        values: '1x5'
+    alert_rule_test:
+%s
+`
+
+func TestRunRuleTests(t *testing.T) {
+	firing := `        exp_alerts:
+          - exp_labels: {severity: P1, job: node, instance: a, __name__: up}
+            exp_annotations: 'a is down'`
+
+	tests := []struct {
+		name     string
+		evalTime string
+		expect   string
+		success  bool
+	}{
+		{name: "healthy", evalTime: "0m", success: true},
+		{name: "pending", evalTime: "2m", success: true},
+		{name: "firing", evalTime: "3m", expect: firing, success: true},
+		{name: "between evaluations", evalTime: "3m30s", expect: firing, success: true},
+		{name: "still firing", evalTime: "4m", expect: firing, success: true},
+		{name: "resolved", evalTime: "5m", success: true},
+		{name: "unexpected firing", evalTime: "5m", expect: firing},
+		{name: "missing alert", evalTime: "3m"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check := "      - eval_time: " + tt.evalTime + "\n        alertname: InstanceDown\n" + tt.expect
+			file, err := ParseRuleTestFile(strings.Replace(ruleTestFixture, "%s", check, 1))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			result := RunRuleTests(file)
+			if result.Total != 1 || result.Success != tt.success {
+				t.Errorf("got %+v, want success %v", result, tt.success)
+			}
+		})
+	}
+}
+
+func TestCountRuleTestSamples(t *testing.T) {
+	tests := []struct {
+		values string
+		want   int
+		err    bool
+	}{
+		{values: "", want: 0},
+		{values: "1 2 3", want: 3},
+		{values: "1+1x10", want: 11},
+		{values: "0x3 _x2 stale", want: 8},
+		{values: "-1-1x4  5", want: 6},
+		{values: "{{schema:0 sum:5 count:4 buckets:[1 2 1]}}x2 {{count:1}}", want: 4},
+		{values: "1x99999999999", want: ruleTestMaxSamples + 1},
+		{values: "1xabc", err: true},
+	}
+
+	for _, tt := range tests {
+		got, err := countRuleTestSamples(tt.values)
+		if (err != nil) != tt.err || (!tt.err && got != tt.want) {
+			t.Errorf("countRuleTestSamples(%q) = %d, %v, want %d", tt.values, got, err, tt.want)
+		}
+	}
+}
+
+func TestParseRuleTestFileLimits(t *testing.T) {
+	tests := []struct {
+		name  string
+		check string
+		input string
+		err   bool
+	}{
+		{
+			name:  "within limits",
+			check: "      - eval_time: 1h\n        alertname: InstanceDown\n",
+			input: "1x100",
+		},
+		{
+			name:  "too many samples",
+			check: "      - eval_time: 1m\n        alertname: InstanceDown\n",
+			input: "1+1x1000000",
+			err:   true,
+		},
+		{
+			name:  "too many evaluation steps",
+			check: "      - eval_time: 1000d\n        alertname: InstanceDown\n",
+			input: "1",
+			err:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := strings.Replace(ruleTestFixture, "%s", tt.check, 1)
+			content = strings.Replace(content, "'1x5'", "'"+tt.input+"'", 1)
+			if _, err := ParseRuleTestFile(content); (err != nil) != tt.err {
+				t.Errorf("got err %v, want err %v", err, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,221 @@
+package eval
+
+import (
+	"container/heap"
+	"context"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+	"time"
+	"watchAlert/config"
+	"watchAlert/internal/models"
+	w8tMetrics "watchAlert/pkg/metrics"
+	"watchAlert/pkg/tools"
+
+	"github.com/zeromicro/go-zero/core/logc"
+)
+
+// defaultEvalWorkersPerDatasource 每个数据源默认并发执行的评估数
+const defaultEvalWorkersPerDatasource = 10
+
+type (
+	// evalScheduler 告警规则评估调度器, 由一个协程按规则的评估周期及固定偏移统一调度, 避免同一时刻集中查询数据源
+	evalScheduler struct {
+		ctx     context.Context
+		mu      sync.Mutex
+		entries map[string]*scheduledRule
+		queue   scheduleQueue
+		wake    chan struct{}
+		execute func(ctx context.Context, rule models.AlertRule)
+
+		// 每个数据源的并发评估数
+		workers  int
+		limiters sync.Map
+	}
+
+	// scheduledRule 调度中的规则
+	scheduledRule struct {
+		ctx      context.Context
+		rule     models.AlertRule
+		interval time.Duration
+		next     time.Time
+		running  atomic.Bool
+		index    int
+	}
+
+	// scheduleQueue 按下次评估时间排序的小顶堆
+	scheduleQueue []*scheduledRule
+)
+
+func newEvalScheduler(ctx context.Context, execute func(ctx context.Context, rule models.AlertRule)) *evalScheduler {
+	workers := config.Application.Server.EvalWorkersPerDatasource
+	if workers <= 0 {
+		workers = defaultEvalWorkersPerDatasource
+	}
+
+	s := &evalScheduler{
+		ctx:     ctx,
+		entries: make(map[string]*scheduledRule),
+		wake:    make(chan struct{}, 1),
+		execute: execute,
+		workers: workers,
+	}
+	go s.loop()
+
+	return s
+}
+
+// add 添加规则, ctx 取消后规则不再评估, 同一规则重复添加时以最后一次为准
+func (s *evalScheduler) add(ctx context.Context, rule models.AlertRule, interval time.Duration) {
+	s.mu.Lock()
+	entry := &scheduledRule{
+		ctx:      ctx,
+		rule:     rule,
+		interval: interval,
+		next:     firstEvalTime(rule.RuleId, interval, time.Now()),
+	}
+	s.entries[rule.RuleId] = entry
+	heap.Push(&s.queue, entry)
+	s.mu.Unlock()
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// firstEvalTime 按规则 ID 计算固定的偏移, 使同一周期的规则分散在整个周期内评估
+func firstEvalTime(ruleId string, interval time.Duration, now time.Time) time.Time {
+	offset := time.Duration(tools.HashAdd(tools.HashNew(), ruleId) % uint64(interval))
+	next := now.Truncate(interval).Add(offset)
+	if next.Before(now) {
+		next = next.Add(interval)
+	}
+
+	return next
+}
+
+func (s *evalScheduler) loop() {
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+
+	for {
+		wait := s.dispatchDue(time.Now())
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(wait)
+
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-s.wake:
+		case <-timer.C:
+		}
+	}
+}
+
+// dispatchDue 分发到期的规则, 返回距下一个规则到期的时间
+func (s *evalScheduler) dispatchDue(now time.Time) time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for s.queue.Len() > 0 {
+		entry := s.queue[0]
+		// 已停止或已被重新添加的规则
+		if entry.ctx.Err() != nil || s.entries[entry.rule.RuleId] != entry {
+			heap.Pop(&s.queue)
+			if s.entries[entry.rule.RuleId] == entry {
+				delete(s.entries, entry.rule.RuleId)
+			}
+			continue
+		}
+
+		if entry.next.After(now) {
+			return entry.next.Sub(now)
+		}
+
+		// 调度延迟超过一个周期, 跳过错过的评估
+		if lag := now.Sub(entry.next); lag >= entry.interval {
+			missed := int64(lag / entry.interval)
+			w8tMetrics.RuleEvalMissed.WithLabelValues(entry.rule.RuleId, "delay").Add(float64(missed))
+			logc.Errorf(s.ctx, "Rule eval missed %d times due to scheduling delay, RuleName: %s, RuleId: %s", missed, entry.rule.RuleName, entry.rule.RuleId)
+			entry.next = entry.next.Add(time.Duration(missed) * entry.interval)
+		}
+		entry.next = entry.next.Add(entry.interval)
+		heap.Fix(&s.queue, 0)
+
+		s.dispatch(entry)
+	}
+
+	return time.Hour
+}
+
+// dispatch 执行一次评估, 上一次评估未完成时跳过本次评估
+func (s *evalScheduler) dispatch(entry *scheduledRule) {
+	if !entry.running.CompareAndSwap(false, true) {
+		w8tMetrics.RuleEvalMissed.WithLabelValues(entry.rule.RuleId, "overrun").Inc()
+		logc.Errorf(s.ctx, "Rule eval skipped, previous eval is still running, RuleName: %s, RuleId: %s", entry.rule.RuleName, entry.rule.RuleId)
+		return
+	}
+
+	go func() {
+		start := time.Now()
+		defer func() {
+			entry.running.Store(false)
+			if r := recover(); r != nil {
+				logc.Errorf(s.ctx, "Recovered from rule eval panic: %s, RuleName: %s, RuleId: %s\n%s", r, entry.rule.RuleName, entry.rule.RuleId, debug.Stack())
+			}
+		}()
+
+		s.execute(entry.ctx, entry.rule)
+
+		cost := time.Since(start)
+		w8tMetrics.RuleEvalDuration.WithLabelValues(entry.rule.RuleId, entry.rule.DatasourceType).Observe(cost.Seconds())
+		if cost > entry.interval {
+			logc.Errorf(s.ctx, "Rule eval overran its interval, cost: %s, interval: %s, RuleName: %s, RuleId: %s", cost, entry.interval, entry.rule.RuleName, entry.rule.RuleId)
+		}
+	}()
+}
+
+// acquire 占用数据源的评估并发数, 返回释放函数, ctx 取消时返回 false
+func (s *evalScheduler) acquire(ctx context.Context, datasourceId string) (func(), bool) {
+	value, _ := s.limiters.LoadOrStore(datasourceId, make(chan struct{}, s.workers))
+	limiter := value.(chan struct{})
+
+	select {
+	case limiter <- struct{}{}:
+		return func() { <-limiter }, true
+	case <-ctx.Done():
+		return nil, false
+	}
+}
+
+func (q scheduleQueue) Len() int { return len(q) }
+
+func (q scheduleQueue) Less(i, j int) bool { return q[i].next.Before(q[j].next) }
+
+func (q scheduleQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *scheduleQueue) Push(x interface{}) {
+	entry := x.(*scheduledRule)
+	entry.index = len(*q)
+	*q = append(*q, entry)
+}
+
+func (q *scheduleQueue) Pop() interface{} {
+	old := *q
+	n := len(old)
+	entry := old[n-1]
+	old[n-1] = nil
+	*q = old[:n-1]
+
+	return entry
+}
@@ -0,0 +1,59 @@
+package eval
+
+import (
+	"fmt"
+	"testing"
+	"time"
+	"watchAlert/pkg/tools"
+)
+
+func TestFirstEvalTime(t *testing.T) {
+	base := time.Unix(1700000000, 0).Truncate(time.Minute)
+	tests := []struct {
+		name     string
+		ruleId   string
+		interval time.Duration
+		now      time.Time
+	}{
+		{name: "start of period", ruleId: "a-1", interval: time.Minute, now: base},
+		{name: "end of period", ruleId: "a-1", interval: time.Minute, now: base.Add(59 * time.Second)},
+		{name: "mid period", ruleId: "a-2", interval: time.Minute, now: base.Add(30 * time.Second)},
+		{name: "short interval", ruleId: "a-3", interval: 5 * time.Second, now: base.Add(2 * time.Second)},
+		{name: "long interval", ruleId: "a-4", interval: 5 * time.Minute, now: base.Add(4 * time.Minute)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := firstEvalTime(tt.ruleId, tt.interval, tt.now)
+			if next.Before(tt.now) || !next.Before(tt.now.Add(tt.interval)) {
+				t.Fatalf("next %s not within one interval after %s", next, tt.now)
+			}
+
+			// 同一规则每个周期的偏移固定
+			offset := time.Duration(tools.HashAdd(tools.HashNew(), tt.ruleId) % uint64(tt.interval))
+			if got := next.Sub(next.Truncate(tt.interval)); got != offset {
+				t.Errorf("offset %s, want %s", got, offset)
+			}
+			if later := firstEvalTime(tt.ruleId, tt.interval, tt.now.Add(tt.interval)); later.Sub(next) != tt.interval {
+				t.Errorf("next period %s, want %s", later, next.Add(tt.interval))
+			}
+		})
+	}
+}
+
+func TestFirstEvalTimeSpread(t *testing.T) {
+	// 同一周期的规则应分散在整个周期内, 而非集中在周期开始
+	const rules = 600
+	now := time.Unix(1700000000, 0).Truncate(time.Minute)
+	buckets := make([]int, 6)
+	for i := 0; i < rules; i++ {
+		next := firstEvalTime(fmt.Sprintf("a-%d", i), time.Minute, now)
+		buckets[int(next.Sub(now)/(10*time.Second))]++
+	}
+
+	for i, count := range buckets {
+		if count < rules/6/2 {
+			t.Errorf("bucket %d has %d rules, want about %d", i, count, rules/6)
+		}
+	}
+}
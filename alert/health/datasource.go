@@ -0,0 +1,237 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+	"watchAlert/alert/process"
+	"watchAlert/internal/ctx"
+	"watchAlert/internal/models"
+	"watchAlert/pkg/provider"
+
+	"github.com/zeromicro/go-zero/core/logc"
+)
+
+const (
+	// breakerFailureThreshold 连续失败次数达到该值后打开熔断
+	breakerFailureThreshold = 3
+	// breakerMaxCooldown 熔断的最大冷却时间
+	breakerMaxCooldown = 10 * time.Minute
+)
+
+// DatasourceMonitor 周期检查数据源健康状态, 供规则评估读取, 连续失败后打开熔断并向故障中心推送告警事件
+type DatasourceMonitor struct {
+	ctx    *ctx.Context
+	cancel context.CancelFunc
+	mu     sync.Mutex
+	// owns 当前节点是否负责检查该数据源
+	owns func(datasourceId string) bool
+}
+
+func NewDatasourceMonitor(ctx *ctx.Context, owns func(datasourceId string) bool) *DatasourceMonitor {
+	return &DatasourceMonitor{ctx: ctx, owns: owns}
+}
+
+// Start 启动健康检查任务, 重复调用时不会启动多个任务
+func (m *DatasourceMonitor) Start() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.cancel != nil {
+		return
+	}
+
+	c, cancel := context.WithCancel(m.ctx.Ctx)
+	m.cancel = cancel
+	go m.run(c)
+}
+
+// Stop 停止健康检查任务
+func (m *DatasourceMonitor) Stop() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.cancel == nil {
+		return
+	}
+
+	m.cancel()
+	m.cancel = nil
+}
+
+func (m *DatasourceMonitor) run(c context.Context) {
+	ticker := time.NewTicker(models.DatasourceCheckInterval)
+	defer ticker.Stop()
+
+	m.checkAll()
+	for {
+		select {
+		case <-c.Done():
+			logc.Infof(m.ctx.Ctx, "数据源健康检查任务已停止")
+			return
+		case <-ticker.C:
+			m.checkAll()
+		}
+	}
+}
+
+func (m *DatasourceMonitor) checkAll() {
+	datasources, err := m.ctx.DB.Datasource().List("", "", "", "")
+	if err != nil {
+		logc.Errorf(m.ctx.Ctx, "获取数据源列表失败: %v", err)
+		return
+	}
+
+	var wg sync.WaitGroup
+	for _, datasource := range datasources {
+		if !m.owns(datasource.ID) {
+			continue
+		}
+		wg.Add(1)
+		go func(datasource models.AlertDataSource) {
+			defer wg.Done()
+			m.check(datasource)
+		}(datasource)
+	}
+	wg.Wait()
+}
+
+// check 检查单个数据源并更新健康状态, 禁用的数据源不检查
+func (m *DatasourceMonitor) check(datasource models.AlertDataSource) {
+	health := m.ctx.Redis.DatasourceHealth().Get(datasource.ID)
+	if !datasource.GetEnabled() {
+		health.Status = models.DatasourceUnknown
+		health.ConsecutiveFailures = 0
+		health.Breaker = models.BreakerClosed
+		health.OpenUntil = 0
+		m.resolve(datasource, &health)
+		m.ctx.Redis.DatasourceHealth().Set(datasource.ID, health)
+		return
+	}
+
+	now := time.Now().Unix()
+	// 熔断冷却期内不请求数据源
+	if health.Breaker == models.BreakerOpen {
+		if now < health.OpenUntil {
+			return
+		}
+		health.Breaker = models.BreakerHalfOpen
+	}
+
+	health.LastCheckTime = now
+	ok, err := m.checkClient(datasource)
+	if ok {
+		if health.Breaker == models.BreakerHalfOpen {
+			logc.Infof(m.ctx.Ctx, "数据源 %s(%s) 已恢复, 关闭熔断", datasource.Name, datasource.ID)
+		}
+		health.Status = models.DatasourceHealthy
+		health.LastError = ""
+		health.LastSuccessTime = now
+		health.ConsecutiveFailures = 0
+		health.Breaker = models.BreakerClosed
+		health.OpenUntil = 0
+		m.resolve(datasource, &health)
+	} else {
+		health.Status = models.DatasourceUnhealthy
+		health.LastError = "健康检查失败"
+		if err != nil {
+			health.LastError = err.Error()
+		}
+		health.ConsecutiveFailures++
+		if health.Breaker == models.BreakerHalfOpen || health.ConsecutiveFailures >= breakerFailureThreshold {
+			health.Breaker = models.BreakerOpen
+			health.OpenUntil = now + int64(breakerCooldown(health.ConsecutiveFailures)/time.Second)
+		}
+		m.alert(datasource, &health)
+	}
+
+	m.ctx.Redis.DatasourceHealth().Set(datasource.ID, health)
+}
+
+// checkClient 优先使用客户端存储池中的客户端检查, 不存在时新建客户端检查
+func (m *DatasourceMonitor) checkClient(datasource models.AlertDataSource) (bool, error) {
+	cli, err := m.ctx.Redis.ProviderPools().GetClient(datasource.ID)
+	if err == nil {
+		if checker, ok := cli.(provider.HealthChecker); ok {
+			return checker.Check()
+		}
+	}
+
+	return provider.CheckDatasourceHealth(datasource)
+}
+
+// breakerCooldown 熔断冷却时间, 随连续失败次数翻倍, 不超过 breakerMaxCooldown
+func breakerCooldown(failures int) time.Duration {
+	cooldown := models.DatasourceCheckInterval
+	for i := breakerFailureThreshold; i < failures && cooldown < breakerMaxCooldown; i++ {
+		cooldown *= 2
+	}
+	if cooldown > breakerMaxCooldown {
+		cooldown = breakerMaxCooldown
+	}
+
+	return cooldown
+}
+
+// alert 连续失败次数达到阈值后推送告警事件, 关闭健康告警或更换故障中心时恢复之前的事件
+func (m *DatasourceMonitor) alert(datasource models.AlertDataSource, health *models.DatasourceHealth) {
+	config := datasource.HealthAlert
+	if health.AlertFaultCenterId != "" && (!config.GetEnabled() || health.AlertFaultCenterId != config.FaultCenterId) {
+		m.resolve(datasource, health)
+	}
+	if !config.GetEnabled() || health.ConsecutiveFailures < config.GetFailureThreshold() {
+		return
+	}
+
+	event := buildDatasourceEvent(datasource, *health)
+	process.PushExternalEvent(m.ctx, &event)
+	if health.AlertFaultCenterId == "" {
+		logc.Infof(m.ctx.Ctx, "数据源 %s(%s) 连续 %d 次健康检查失败, 已推送告警事件", datasource.Name, datasource.ID, health.ConsecutiveFailures)
+	}
+	health.AlertFaultCenterId = config.FaultCenterId
+}
+
+// resolve 恢复已推送的健康告警事件, 待恢复 -> 已恢复由故障中心消费者完成
+func (m *DatasourceMonitor) resolve(datasource models.AlertDataSource, health *models.DatasourceHealth) {
+	if health.AlertFaultCenterId == "" {
+		return
+	}
+
+	process.ResolveExternalEvent(m.ctx, datasource.TenantId, health.AlertFaultCenterId, DatasourceEventFingerprint(datasource.ID))
+	logc.Infof(m.ctx.Ctx, "数据源 %s(%s) 健康告警已恢复", datasource.Name, datasource.ID)
+	health.AlertFaultCenterId = ""
+}
+
+// DatasourceEventFingerprint 数据源健康告警事件的指纹
+func DatasourceEventFingerprint(datasourceId string) string {
+	return provider.Metrics{Labels: map[string]interface{}{
+		"source":        models.ExternalSourceDatasourceHealth,
+		"datasource_id": datasourceId,
+	}}.GetFingerprint()
+}
+
+func buildDatasourceEvent(datasource models.AlertDataSource, health models.DatasourceHealth) models.AlertCurEvent {
+	labels := make(map[string]interface{}, len(datasource.Labels)+5)
+	for k, v := range datasource.Labels {
+		labels[k] = v
+	}
+	labels["datasource_id"] = datasource.ID
+	labels["datasource_name"] = datasource.Name
+	labels["datasource_type"] = datasource.Type
+	labels["severity"] = datasource.HealthAlert.GetSeverity()
+	labels["fingerprint"] = DatasourceEventFingerprint(datasource.ID)
+
+	return models.AlertCurEvent{
+		TenantId:       datasource.TenantId,
+		DatasourceType: models.ExternalSourceDatasourceHealth,
+		DatasourceId:   datasource.ID,
+		RuleId:         models.ExternalSourceDatasourceHealth + "-" + datasource.ID,
+		RuleName:       fmt.Sprintf("数据源 %s 不可用", datasource.Name),
+		Fingerprint:    DatasourceEventFingerprint(datasource.ID),
+		Severity:       datasource.HealthAlert.GetSeverity(),
+		Labels:         labels,
+		Annotations:    fmt.Sprintf("数据源「%s」(%s) 已连续 %d 次健康检查失败, 使用该数据源的告警规则均已停止评估。最近错误: %s", datasource.Name, datasource.Type, health.ConsecutiveFailures, health.LastError),
+		FaultCenterId:  datasource.HealthAlert.FaultCenterId,
+	}
+}
@@ -0,0 +1,77 @@
+package alert
+
+import (
+	"watchAlert/internal/ctx"
+	"watchAlert/internal/models"
+	"watchAlert/pkg/metrics"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/zeromicro/go-zero/core/logc"
+)
+
+// eventStatuses 故障中心事件的状态, 没有事件时也输出 0
+var eventStatuses = []models.AlertStatus{
+	models.StatePreAlert,
+	models.StateAlerting,
+	models.StatePendingRecovery,
+	models.StateRecovered,
+}
+
+// eventCollector 采集时统计本节点负责的故障中心当前的事件数, 避免多个节点重复输出
+type eventCollector struct {
+	desc *prometheus.Desc
+}
+
+// registerMetrics 注册需要在采集时计算的指标, 需在确定运行模式后调用
+func registerMetrics() {
+	prometheus.MustRegister(eventCollector{
+		desc: prometheus.NewDesc("watchalert_fault_center_events", "Current number of events per fault center and status.",
+			[]string{"tenant_id", "fault_center_id", "fault_center_name", "status"}, nil),
+	})
+
+	// 仅 Leader 选举模式下输出, 单节点及分片模式下没有 Leader
+	if LeaderElector != nil {
+		prometheus.MustRegister(metrics.NewGauge("leader", "Whether this node is the leader (1) or not (0).", func() float64 {
+			if LeaderElector.IsLeader() {
+				return 1
+			}
+			return 0
+		}))
+	}
+}
+
+func (c eventCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c eventCollector) Collect(ch chan<- prometheus.Metric) {
+	// Leader 选举模式下由 Leader 输出
+	if !shardingEnabled && !IsLeader() {
+		return
+	}
+
+	list, err := ctx.DB.FaultCenter().List("", "")
+	if err != nil {
+		logc.Errorf(ctx.Ctx, "获取故障中心列表失败: %v", err)
+		return
+	}
+
+	for _, fc := range list {
+		if !IsOwner(fc.ID) {
+			continue
+		}
+
+		events, err := ctx.Redis.Alert().GetAllEvents(models.BuildAlertEventCacheKey(fc.TenantId, fc.ID))
+		if err != nil {
+			continue
+		}
+
+		counts := make(map[models.AlertStatus]int, len(eventStatuses))
+		for _, event := range events {
+			counts[event.Status]++
+		}
+		for _, status := range eventStatuses {
+			ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(counts[status]), fc.TenantId, fc.ID, fc.Name, string(status))
+		}
+	}
+}
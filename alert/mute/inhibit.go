@@ -0,0 +1,98 @@
+package mute
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+	models "watchAlert/internal/models"
+)
+
+// matcherRegexps 已编译的匹配条件正则, 按表达式缓存, 避免每次匹配重复编译
+var matcherRegexps sync.Map
+
+// IsInhibited 判断事件是否被抑制, 返回抑制该事件的源事件指纹
+func IsInhibited(rules []models.InhibitRule, event *models.AlertCurEvent, events map[string]*models.AlertCurEvent) (string, bool) {
+	if event.IsRecovered || len(rules) == 0 {
+		return "", false
+	}
+
+	for _, rule := range rules {
+		if !matchLabels(event.Labels, rule.TargetMatchers) {
+			continue
+		}
+
+		for _, source := range events {
+			// 仅告警中的事件可以作为抑制源, 且事件不能抑制自身
+			if source.Fingerprint == event.Fingerprint || source.Status != models.StateAlerting || source.IsRecovered {
+				continue
+			}
+			if !matchLabels(source.Labels, rule.SourceMatchers) {
+				continue
+			}
+			if equalLabels(source.Labels, event.Labels, rule.Equal) {
+				return source.Fingerprint, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// matchLabels 匹配全部条件, 条件为空时视为不匹配, 避免误抑制全部事件
+func matchLabels(labels map[string]interface{}, matchers []models.NoticeLabels) bool {
+	if len(matchers) == 0 {
+		return false
+	}
+
+	for _, matcher := range matchers {
+		val := fmt.Sprintf("%v", labels[matcher.Key])
+		if _, exists := labels[matcher.Key]; !exists {
+			val = ""
+		}
+
+		var matched bool
+		switch matcher.Operator {
+		case "==", "=":
+			matched = val == matcher.Value
+		case "!=":
+			matched = val != matcher.Value
+		case "=~", "!~":
+			re, err := CompileMatcher(matcher.Value)
+			if err != nil {
+				return false
+			}
+			matched = re.MatchString(val) == (matcher.Operator == "=~")
+		}
+
+		if !matched {
+			return false
+		}
+	}
+
+	return true
+}
+
+// CompileMatcher 编译匹配条件中的正则表达式, 表达式需完整匹配标签值
+func CompileMatcher(pattern string) (*regexp.Regexp, error) {
+	if re, ok := matcherRegexps.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+
+	re, err := regexp.Compile("^(?:" + pattern + ")$")
+	if err != nil {
+		return nil, err
+	}
+	matcherRegexps.Store(pattern, re)
+
+	return re, nil
+}
+
+// equalLabels 源事件与目标事件的 equal 标签值需要一致
+func equalLabels(source, target map[string]interface{}, equal []string) bool {
+	for _, key := range equal {
+		if fmt.Sprintf("%v", source[key]) != fmt.Sprintf("%v", target[key]) {
+			return false
+		}
+	}
+	return true
+}
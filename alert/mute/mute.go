@@ -4,6 +4,7 @@ import (
 	"regexp"
 	"watchAlert/internal/ctx"
 	models "watchAlert/internal/models"
+	"watchAlert/pkg/metrics"
 
 	"github.com/zeromicro/go-zero/core/logc"
 )
@@ -33,14 +34,29 @@ func RecoverNotify(mp MuteParams) bool {
 	return mp.IsRecovered && !*mp.RecoverNotify
 }
 
+// IsMutedOnSend 发送通知前判断是否静默, 命中静默规则时记录命中次数, 页面查询等其他场景使用 IsMuted
+func IsMutedOnSend(mute MuteParams) bool {
+	if id := matchSilence(mute); id != "" {
+		metrics.SilenceHits.WithLabelValues(mute.FaultCenterId, id).Inc()
+		return true
+	}
+
+	return RecoverNotify(mute)
+}
+
 // IsSilence 判断是否静默
 func IsSilence(mute MuteParams) bool {
+	return matchSilence(mute) != ""
+}
+
+// matchSilence 获取命中的生效中静默规则 ID, 未命中时返回空字符串
+func matchSilence(mute MuteParams) string {
 	silenceCtx := ctx.Redis.Silence()
 	// 获取静默列表中所有的id
 	ids, err := silenceCtx.GetAlertMutes(mute.TenantId, mute.FaultCenterId)
 	if err != nil {
 		logc.Errorf(ctx.Ctx, err.Error())
-		return false
+		return ""
 	}
 
 	// 根据ID获取到详细的静默规则
@@ -48,7 +64,7 @@ func IsSilence(mute MuteParams) bool {
 		muteRule, err := silenceCtx.WithIdGetMuteFromCache(mute.TenantId, mute.FaultCenterId, id)
 		if err != nil {
 			logc.Errorf(ctx.Ctx, err.Error())
-			return false
+			return ""
 		}
 
 		if muteRule.Status != 1 {
@@ -56,11 +72,11 @@ func IsSilence(mute MuteParams) bool {
 		}
 
 		if evalCondition(mute.Labels, muteRule.Labels) {
-			return true
+			return id
 		}
 	}
 
-	return false
+	return ""
 }
 
 func evalCondition(metrics map[string]interface{}, muteLabels []models.SilenceLabel) bool {
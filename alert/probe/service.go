@@ -8,6 +8,7 @@ import (
 	"time"
 	"watchAlert/internal/ctx"
 	"watchAlert/internal/models"
+	w8tMetrics "watchAlert/pkg/metrics"
 	"watchAlert/pkg/provider"
 
 	"github.com/zeromicro/go-zero/core/logc"
@@ -18,7 +19,9 @@ import (
 type ProbeService struct {
 	ctx         *ctx.Context
 	watchCtxMap map[string]context.CancelFunc
-	mu          sync.RWMutex
+	// versions 各拨测任务启动时规则的更新时间
+	versions map[string]int64
+	mu       sync.RWMutex
 }
 
 // NewProbeService 创建新的拨测服务
@@ -26,6 +29,7 @@ func NewProbeService(ctx *ctx.Context) *ProbeService {
 	return &ProbeService{
 		ctx:         ctx,
 		watchCtxMap: make(map[string]context.CancelFunc),
+		versions:    make(map[string]int64),
 	}
 }
 
@@ -41,6 +45,7 @@ func (s *ProbeService) Add(rule models.ProbeRule) error {
 
 	c, cancel := context.WithCancel(s.ctx.Ctx)
 	s.watchCtxMap[rule.RuleId] = cancel
+	s.versions[rule.RuleId] = rule.UpdateAt
 
 	// 启动拨测协程
 	go s.runProbing(c, rule)
@@ -61,6 +66,7 @@ func (s *ProbeService) Stop(ruleID string) error {
 
 	cancel()
 	delete(s.watchCtxMap, ruleID)
+	delete(s.versions, ruleID)
 	return nil
 }
 
@@ -80,6 +86,7 @@ func (s *ProbeService) StopAll() error {
 	for ruleID, cancel := range s.watchCtxMap {
 		cancel()
 		delete(s.watchCtxMap, ruleID)
+		delete(s.versions, ruleID)
 	}
 
 	logc.Infof(s.ctx.Ctx, "All probing tasks stopped")
@@ -93,6 +100,18 @@ func (s *ProbeService) GetActiveRules() int {
 	return len(s.watchCtxMap)
 }
 
+// Running 获取运行中的拨测任务及启动时规则的更新时间
+func (s *ProbeService) Running() map[string]int64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	running := make(map[string]int64, len(s.versions))
+	for ruleId, updateAt := range s.versions {
+		running[ruleId] = updateAt
+	}
+	return running
+}
+
 // runProbing 运行拨测
 func (s *ProbeService) runProbing(ctx context.Context, rule models.ProbeRule) {
 	timer := time.NewTicker(time.Second * time.Duration(rule.ProbingEndpointConfig.Strategy.EvalInterval))
@@ -116,6 +135,7 @@ func (s *ProbeService) runProbing(ctx context.Context, rule models.ProbeRule) {
 func (s *ProbeService) executeProbing(rule models.ProbeRule) {
 	// 执行拨测并获取指标
 	metrics, err := s.executeProbeWithMetrics(rule)
+	w8tMetrics.ProbeExecutions.WithLabelValues(rule.RuleType, w8tMetrics.Status(err)).Inc()
 	if err != nil {
 		logc.Errorf(s.ctx.Ctx, "Probing failed for rule %s: %v", rule.RuleId, err)
 		return
@@ -0,0 +1,95 @@
+package process
+
+import (
+	"time"
+	"watchAlert/internal/ctx"
+	"watchAlert/internal/models"
+
+	"github.com/go-redis/redis"
+	"github.com/zeromicro/go-zero/core/logc"
+)
+
+// defaultRecoverWaitTime 默认恢复等待时间，单位（秒）
+const defaultRecoverWaitTime = 1
+
+// PushExternalEvent 推送外部来源的告警事件
+// 外部来源已自行完成持续时间判断，事件写入故障中心后直接转为告警中。
+func PushExternalEvent(ctx *ctx.Context, event *models.AlertCurEvent) {
+	if event == nil || NotInTheEffectiveTime(event.EffectiveTime) {
+		return
+	}
+
+	// 读取及写回缓存事件需与其他写入方互斥, 避免覆盖并发更新的状态
+	ctx.Mux.Lock()
+	defer ctx.Mux.Unlock()
+
+	cacheEvent, err := ctx.Redis.Alert().GetEventFromCache(event.TenantId, event.FaultCenterId, event.Fingerprint)
+	if err == nil && cacheEvent.Status == models.StatePendingRecovery {
+		// 待恢复期间再次触发，重新转为告警中
+		if err := cacheEvent.TransitionStatus(models.StateAlerting); err == nil {
+			ctx.Redis.Alert().PushAlertEvent(&cacheEvent)
+			ctx.Redis.PendingRecover().Delete(event.TenantId, event.RuleId, event.Fingerprint)
+		}
+	}
+
+	pushEventToFaultCenter(ctx, event)
+
+	if event.Status == models.StatePreAlert {
+		if err := event.TransitionStatus(models.StateAlerting); err != nil {
+			logc.Errorf(ctx.Ctx, "Failed to transition to「alerting」state for fingerprint %s: %v", event.Fingerprint, err)
+			return
+		}
+		ctx.Redis.Alert().PushAlertEvent(event)
+	}
+}
+
+// ResolveExternalEvent 处理外部来源的恢复事件
+// 告警中 -> 待恢复，超过故障中心的恢复等待时间后 -> 已恢复。
+func ResolveExternalEvent(ctx *ctx.Context, tenantId, faultCenterId, fingerprint string) {
+	ctx.Mux.Lock()
+	defer ctx.Mux.Unlock()
+
+	event, err := ctx.Redis.Alert().GetEventFromCache(tenantId, faultCenterId, fingerprint)
+	if err != nil {
+		return
+	}
+
+	curTime := time.Now().Unix()
+	switch event.Status {
+	case models.StatePreAlert:
+		ctx.Redis.Alert().RemoveAlertEvent(tenantId, faultCenterId, fingerprint)
+
+	case models.StateAlerting:
+		if err := event.TransitionStatus(models.StatePendingRecovery); err != nil {
+			logc.Errorf(ctx.Ctx, "Failed to transition to「pending_recovery」state for fingerprint %s: %v", fingerprint, err)
+			return
+		}
+		ctx.Redis.PendingRecover().Set(tenantId, event.RuleId, fingerprint, curTime)
+		ctx.Redis.Alert().PushAlertEvent(&event)
+
+	case models.StatePendingRecovery:
+		wTime, err := ctx.Redis.PendingRecover().Get(tenantId, event.RuleId, fingerprint)
+		if err == redis.Nil {
+			ctx.Redis.PendingRecover().Set(tenantId, event.RuleId, fingerprint, curTime)
+			return
+		} else if err != nil {
+			logc.Errorf(ctx.Ctx, "Failed to get「pending_recovery」time for fingerprint %s: %v", fingerprint, err)
+			return
+		}
+
+		recoverWaitTime := ctx.Redis.FaultCenter().GetFaultCenterInfo(models.BuildFaultCenterInfoCacheKey(tenantId, faultCenterId)).RecoverWaitTime
+		if recoverWaitTime == 0 {
+			recoverWaitTime = defaultRecoverWaitTime
+		}
+		if curTime < wTime+recoverWaitTime {
+			return
+		}
+
+		if err := event.TransitionStatus(models.StateRecovered); err != nil {
+			logc.Errorf(ctx.Ctx, "Failed to transition to recovered state for fingerprint %s: %v", fingerprint, err)
+			return
+		}
+		ctx.Redis.Alert().PushAlertEvent(&event)
+		ctx.Redis.PendingRecover().Delete(tenantId, event.RuleId, fingerprint)
+	}
+}
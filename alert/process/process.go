@@ -40,6 +40,11 @@ func PushEventToFaultCenter(ctx *ctx.Context, event *models.AlertCurEvent) {
 
 	ctx.Mux.Lock()
 	defer ctx.Mux.Unlock()
+	pushEventToFaultCenter(ctx, event)
+}
+
+// pushEventToFaultCenter 合并缓存中的事件状态并写入故障中心, 调用方需持有 ctx.Mux
+func pushEventToFaultCenter(ctx *ctx.Context, event *models.AlertCurEvent) {
 	if len(event.TenantId) <= 0 || len(event.Fingerprint) <= 0 {
 		return
 	}
@@ -48,10 +53,15 @@ func PushEventToFaultCenter(ctx *ctx.Context, event *models.AlertCurEvent) {
 	cacheEvent, _ := cache.Alert().GetEventFromCache(event.TenantId, event.FaultCenterId, event.Fingerprint)
 
 	// 获取基础信息
-	event.FirstTriggerTime = cacheEvent.GetFirstTime()
+	// 外部推送的新事件会携带首次触发时间
+	if event.FirstTriggerTime == 0 || cacheEvent.FirstTriggerTime != 0 {
+		event.FirstTriggerTime = cacheEvent.GetFirstTime()
+	}
 	event.LastEvalTime = cacheEvent.GetLastEvalTime()
 	event.LastSendTime = cacheEvent.GetLastSendTime()
 	event.ConfirmState = cacheEvent.GetLastConfirmState()
+	event.UpgradeState = cacheEvent.UpgradeState
+	event.TicketKey = cacheEvent.TicketKey
 	event.EventId = cacheEvent.GetEventId()
 	event.FaultCenter = cache.FaultCenter().GetFaultCenterInfo(models.BuildFaultCenterInfoCacheKey(event.TenantId, event.FaultCenterId))
 
@@ -145,6 +155,7 @@ func RecordAlertHisEvent(ctx *ctx.Context, alert models.AlertCurEvent) error {
 		ConfirmState:     alert.ConfirmState,
 		AlarmDuration:    alert.RecoverTime - alert.FirstTriggerTime,
 		SearchQL:         alert.SearchQL,
+		TicketKey:        alert.TicketKey,
 	}
 
 	err := ctx.DB.Event().CreateHistoryEvent(hisData)
@@ -0,0 +1,256 @@
+package alert
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"watchAlert/internal/ctx"
+	"watchAlert/internal/models"
+	"watchAlert/pkg/client"
+	"watchAlert/pkg/metrics"
+	"watchAlert/pkg/tools"
+
+	"github.com/zeromicro/go-zero/core/logc"
+)
+
+const (
+	// rebalanceInterval 定期重新分配任务的间隔, 用于补偿丢失的重载消息
+	rebalanceInterval = time.Minute
+	// handoffDelay 成员变更后延迟启动新分配的任务, 原负责节点在下一次心跳时感知变更并停止任务
+	handoffDelay = (tools.ClusterHeartbeatInterval + 1) * time.Second
+)
+
+var (
+	// rebalanceMux 避免成员变更与定期检查同时分配任务
+	rebalanceMux sync.Mutex
+	// handingOff 成员变更后的交接期间, 不启动本节点尚未运行的任务, 由 rebalanceMux 保护
+	handingOff bool
+
+	// shardCtx 记录各任务取消函数的上下文
+	shardCtx *ctx.Context
+)
+
+// startSharding 启动分片模式, 所有节点均订阅重载消息, 仅处理自己负责的任务
+func startSharding(c *ctx.Context) {
+	shardCtx = c
+	Cluster = tools.NewClusterMember(c.Ctx, client.Redis, rebalance)
+	Cluster.Start()
+
+	startMessageSubscribers()
+	DatasourceHealth.Start()
+
+	go func() {
+		ticker := time.NewTicker(rebalanceInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Ctx.Done():
+				return
+			case <-ticker.C:
+				rebalance()
+			}
+		}
+	}()
+}
+
+// rebalance 按当前集群成员重新分配任务, 停止不再负责、已删除或已禁用的任务, 启动新分配或配置已变更的任务
+func rebalance() {
+	rebalanceMux.Lock()
+	defer rebalanceMux.Unlock()
+
+	// 心跳超时后成员列表被清空, 其他成员已接管任务, 停止本节点的所有任务
+	if len(Cluster.Members()) == 0 {
+		stopLocalTasks()
+		return
+	}
+
+	// 交接期结束后重新分配, 启动延迟的任务
+	wait := time.Until(Cluster.ChangedAt().Add(handoffDelay))
+	if handingOff = wait > 0; handingOff {
+		time.AfterFunc(wait, rebalance)
+	}
+
+	var (
+		started, stopped int
+		complete         = true
+		running          = runningTasks()
+		expected         = make(map[string]struct{}, len(running))
+	)
+	for _, fn := range []func(map[string]string, map[string]struct{}) (int, int, bool){
+		rebalanceRules, rebalanceRecordingRules, rebalanceConsumers,
+	} {
+		s, p, ok := fn(running, expected)
+		started, stopped = started+s, stopped+p
+		complete = complete && ok
+	}
+	// 任一列表获取失败时不清理, 避免误停正常的任务
+	if complete {
+		stopped += stopOrphanTasks(running, expected)
+	}
+	s, p := rebalanceProbes()
+	started, stopped = started+s, stopped+p
+
+	if started > 0 || stopped > 0 {
+		logc.Infof(ctx.Ctx, "任务重新分配完成, 启动: %d, 停止: %d, 集群成员数: %d", started, stopped, len(Cluster.Members()))
+	}
+}
+
+func rebalanceRules(running map[string]string, expected map[string]struct{}) (started, stopped int, ok bool) {
+	var ruleList []models.AlertRule
+	if err := ctx.DB.DB().Where("enabled = ?", "1").Find(&ruleList).Error; err != nil {
+		logc.Errorf(ctx.Ctx, "获取告警规则列表失败: %v", err)
+		return
+	}
+
+	for _, rule := range ruleList {
+		expected[rule.RuleId] = struct{}{}
+		version, exists := running[rule.RuleId]
+		switch owns := Cluster.Owns(rule.RuleId); {
+		case owns && !exists && handingOff:
+			// 等待原负责节点停止任务
+		case owns && (!exists || version != strconv.FormatInt(rule.UpdateAt, 10)):
+			AlertRule.Submit(rule)
+			started++
+		case !owns && exists:
+			AlertRule.Stop(rule.RuleId)
+			stopped++
+		}
+	}
+
+	return started, stopped, true
+}
+
+func rebalanceRecordingRules(running map[string]string, expected map[string]struct{}) (started, stopped int, ok bool) {
+	var ruleList []models.RecordingRule
+	if err := ctx.DB.DB().Where("enabled = ?", "1").Find(&ruleList).Error; err != nil {
+		logc.Errorf(ctx.Ctx, "获取记录规则列表失败: %v", err)
+		return
+	}
+
+	for _, rule := range ruleList {
+		expected[rule.RuleId] = struct{}{}
+		version, exists := running[rule.RuleId]
+		switch owns := Cluster.Owns(rule.RuleId); {
+		case owns && !exists && handingOff:
+			// 等待原负责节点停止任务
+		case owns && (!exists || version != strconv.FormatInt(rule.UpdateAt, 10)):
+			RecordingRule.Submit(rule)
+			started++
+		case !owns && exists:
+			RecordingRule.Stop(rule.RuleId)
+			stopped++
+		}
+	}
+
+	return started, stopped, true
+}
+
+func rebalanceConsumers(running map[string]string, expected map[string]struct{}) (started, stopped int, ok bool) {
+	list, err := ctx.DB.FaultCenter().List("", "")
+	if err != nil {
+		logc.Errorf(ctx.Ctx, "获取故障中心列表失败: %v", err)
+		return
+	}
+
+	for _, fc := range list {
+		// 故障中心信息供所有节点的规则评估使用
+		ctx.Redis.FaultCenter().PushFaultCenterInfo(fc)
+
+		expected[fc.ID] = struct{}{}
+		version, exists := running[fc.ID]
+		switch owns := Cluster.Owns(fc.ID); {
+		case owns && !exists && handingOff:
+			// 等待原负责节点停止任务
+		case owns && (!exists || version != fc.ConfigVersion()):
+			ConsumerWork.Submit(fc)
+			started++
+		case !owns && exists:
+			ConsumerWork.Stop(fc.ID)
+			stopped++
+		}
+	}
+
+	return started, stopped, true
+}
+
+func rebalanceProbes() (started, stopped int) {
+	var ruleList []models.ProbeRule
+	if err := ctx.DB.DB().Where("enabled = ?", true).Find(&ruleList).Error; err != nil {
+		logc.Errorf(ctx.Ctx, "获取拨测规则列表失败: %v", err)
+		return
+	}
+
+	running := Probe.Running()
+	for _, rule := range ruleList {
+		updateAt, exists := running[rule.RuleId]
+		delete(running, rule.RuleId)
+
+		if !Cluster.Owns(rule.RuleId) {
+			if exists && Probe.Stop(rule.RuleId) == nil {
+				stopped++
+			}
+			continue
+		}
+		if (exists && updateAt == rule.UpdateAt) || (!exists && handingOff) {
+			continue
+		}
+
+		// 配置变更时先停止旧任务
+		if exists {
+			_ = Probe.Stop(rule.RuleId)
+		}
+		if err := Probe.Add(rule); err == nil {
+			started++
+		} else if !strings.Contains(err.Error(), "already exists") {
+			logc.Errorf(ctx.Ctx, "启动拨测任务失败: %s, err: %v", rule.RuleName, err)
+		}
+	}
+
+	// 已删除或已禁用的拨测规则
+	for ruleId := range running {
+		if Probe.Stop(ruleId) == nil {
+			stopped++
+		}
+	}
+
+	return
+}
+
+// stopLocalTasks 停止本节点运行的所有任务, 消息订阅及数据源健康检查按 Owns 判断, 无需停止
+func stopLocalTasks() {
+	AlertRule.StopAllEvals()
+	RecordingRule.StopAllEvals()
+	ConsumerWork.StopAllConsumers()
+	if err := Probe.StopAll(); err != nil {
+		logc.Errorf(ctx.Ctx, "停止所有拨测任务失败: %v", err)
+	}
+}
+
+// runningTasks 当前节点运行的告警规则、记录规则及故障中心消费者, 以及启动时的配置版本
+func runningTasks() map[string]string {
+	shardCtx.Mux.Lock()
+	defer shardCtx.Mux.Unlock()
+
+	running := make(map[string]string, len(shardCtx.ContextMap))
+	for id := range shardCtx.ContextMap {
+		running[id] = shardCtx.TaskVersions[id]
+	}
+	return running
+}
+
+// stopOrphanTasks 停止已删除或已禁用但仍在运行的任务, 如丢失了禁用或删除的重载消息
+func stopOrphanTasks(running map[string]string, expected map[string]struct{}) (stopped int) {
+	for id := range running {
+		if _, ok := expected[id]; ok {
+			continue
+		}
+		// 告警规则、记录规则及消费者共用同一个任务上下文, 停止方式相同
+		AlertRule.Stop(id)
+		metrics.DeleteRule(id)
+		stopped++
+	}
+
+	return
+}
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"watchAlert/internal/middleware"
+	"watchAlert/internal/services"
+	"watchAlert/internal/types"
+
+	"github.com/gin-gonic/gin"
+)
+
+type alertReceiverController struct{}
+
+var AlertReceiverController = new(alertReceiverController)
+
+/*
+告警接收 API
+/api/v2
+*/
+func (alertReceiverController alertReceiverController) API(gin *gin.RouterGroup) {
+	a := gin.Group("")
+	a.Use(
+		middleware.ReceiverAuth(),
+	)
+	{
+		// 兼容 Alertmanager v2 接口, Prometheus 等客户端可直接将其配置为 Alertmanager 地址
+		a.POST("alerts", alertReceiverController.Alertmanager)
+	}
+
+	b := gin.Group("")
+	b.Use(
+		middleware.Auth(),
+	)
+	{
+		// 通用事件接口, 兼容 PagerDuty Events v2 格式
+		b.POST("events", alertReceiverController.Events)
+	}
+}
+
+func (alertReceiverController alertReceiverController) Alertmanager(ctx *gin.Context) {
+	r := new(types.RequestAlertmanagerReceive)
+	BindJson(ctx, &r.Alerts)
+
+	userId, _ := ctx.Get("UserId")
+	r.UserId = userId.(string)
+	apiKey, _ := ctx.Get("ApiKey")
+	r.ApiKey = apiKey.(string)
+
+	Service(ctx, func() (interface{}, interface{}) {
+		return services.AlertReceiverService.Alertmanager(r)
+	})
+}
+
+func (alertReceiverController alertReceiverController) Events(ctx *gin.Context) {
+	r := new(types.RequestEventsReceive)
+	BindJson(ctx, r)
+
+	userId, _ := ctx.Get("UserId")
+	r.UserId = userId.(string)
+	r.ApiKey = ctx.Request.Header.Get(middleware.ApiKeyHeader)
+
+	Service(ctx, func() (interface{}, interface{}) {
+		return services.AlertReceiverService.Events(r)
+	})
+}
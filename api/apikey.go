@@ -51,6 +51,9 @@ func (apiKeyController apiKeyController) Create(ctx *gin.Context) {
 
 	BindJson(ctx, r)
 
+	tid, _ := ctx.Get("TenantID")
+	r.TenantId = tid.(string)
+
 	Service(ctx, func() (interface{}, interface{}) {
 		return services.ApiKeyService.Create(r)
 	})
@@ -96,6 +99,9 @@ func (apiKeyController apiKeyController) Update(ctx *gin.Context) {
 
 	BindJson(ctx, r)
 
+	tid, _ := ctx.Get("TenantID")
+	r.TenantId = tid.(string)
+
 	Service(ctx, func() (interface{}, interface{}) {
 		return services.ApiKeyService.Update(r)
 	})
@@ -0,0 +1,98 @@
+package api
+
+import (
+	"net/http"
+	"watchAlert/internal/services"
+	"watchAlert/internal/types"
+	"watchAlert/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+type chatOpsController struct{}
+
+var ChatOpsController = new(chatOpsController)
+
+/*
+IM 卡片交互及事件平台认领回调 API
+/api/w8t/chatops
+由 IM 平台及 PagerDuty、Opsgenie 调用, 通过平台签名鉴权
+*/
+func (chatOpsController chatOpsController) API(gin *gin.RouterGroup) {
+	a := gin.Group("chatops")
+	{
+		a.POST("feishu", chatOpsController.FeiShuCallback)
+		a.POST("dingding", chatOpsController.DingDingCallback)
+		a.POST("slack", chatOpsController.SlackCallback)
+		a.POST("pagerduty", chatOpsController.PagerDutyCallback)
+		a.POST("opsgenie", chatOpsController.OpsgenieCallback)
+	}
+}
+
+func (chatOpsController chatOpsController) FeiShuCallback(ctx *gin.Context) {
+	r := &types.RequestChatOpsCallback{
+		Timestamp: ctx.GetHeader("X-Lark-Request-Timestamp"),
+		Nonce:     ctx.GetHeader("X-Lark-Request-Nonce"),
+		Signature: ctx.GetHeader("X-Lark-Signature"),
+	}
+
+	chatOpsCallback(ctx, r, services.ChatOpsService.FeiShuCallback)
+}
+
+func (chatOpsController chatOpsController) DingDingCallback(ctx *gin.Context) {
+	r := &types.RequestChatOpsCallback{
+		Timestamp: ctx.GetHeader("timestamp"),
+		Signature: ctx.GetHeader("sign"),
+	}
+
+	chatOpsCallback(ctx, r, services.ChatOpsService.DingDingCallback)
+}
+
+func (chatOpsController chatOpsController) SlackCallback(ctx *gin.Context) {
+	r := &types.RequestChatOpsCallback{
+		Timestamp: ctx.GetHeader("X-Slack-Request-Timestamp"),
+		Signature: ctx.GetHeader("X-Slack-Signature"),
+	}
+
+	chatOpsCallback(ctx, r, services.ChatOpsService.SlackCallback)
+}
+
+func (chatOpsController chatOpsController) PagerDutyCallback(ctx *gin.Context) {
+	r := &types.RequestChatOpsCallback{
+		Signature: ctx.GetHeader("X-PagerDuty-Signature"),
+	}
+
+	chatOpsCallback(ctx, r, services.ChatOpsService.PagerDutyCallback)
+}
+
+func (chatOpsController chatOpsController) OpsgenieCallback(ctx *gin.Context) {
+	r := &types.RequestChatOpsCallback{
+		Signature: ctx.GetHeader("X-W8t-Token"),
+	}
+
+	chatOpsCallback(ctx, r, services.ChatOpsService.OpsgenieCallback)
+}
+
+// chatOpsCallback 读取原始请求体用于签名校验, 并按平台要求的格式直接返回结果
+func chatOpsCallback(ctx *gin.Context, r *types.RequestChatOpsCallback, fu func(req interface{}) (interface{}, interface{})) {
+	body, err := ctx.GetRawData()
+	if err != nil {
+		response.Fail(ctx, err.Error(), "failed")
+		ctx.Abort()
+		return
+	}
+	r.Body = body
+
+	data, e := fu(r)
+	if e != nil {
+		response.Response(ctx, http.StatusUnauthorized, http.StatusUnauthorized, e.(error).Error(), "failed")
+		ctx.Abort()
+		return
+	}
+
+	if data == nil {
+		ctx.Status(http.StatusOK)
+		return
+	}
+	ctx.JSON(http.StatusOK, data)
+}
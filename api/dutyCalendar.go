@@ -1,10 +1,14 @@
 package api
 
 import (
+	"net/http"
+
 	"github.com/gin-gonic/gin"
 	middleware "watchAlert/internal/middleware"
 	"watchAlert/internal/services"
 	"watchAlert/internal/types"
+	"watchAlert/pkg/response"
+	"watchAlert/pkg/tools"
 )
 
 type dutyCalendarController struct{}
@@ -26,6 +30,10 @@ func (dutyCalendarController dutyCalendarController) API(gin *gin.RouterGroup) {
 	{
 		a.POST("calendarCreate", dutyCalendarController.Create)
 		a.POST("calendarUpdate", dutyCalendarController.Update)
+		a.POST("overrideCreate", dutyCalendarController.OverrideCreate)
+		a.POST("overrideDelete", dutyCalendarController.OverrideDelete)
+		a.POST("icsTokenReset", dutyCalendarController.IcsTokenReset)
+		a.POST("icsImport", dutyCalendarController.IcsImport)
 	}
 
 	b := gin.Group("calendar")
@@ -36,6 +44,8 @@ func (dutyCalendarController dutyCalendarController) API(gin *gin.RouterGroup) {
 	)
 	{
 		b.GET("calendarSearch", dutyCalendarController.Search)
+		b.GET("shiftSearch", dutyCalendarController.ShiftSearch)
+		b.GET("icsToken", dutyCalendarController.IcsToken)
 	}
 
 	c := gin.Group("calendar")
@@ -45,6 +55,13 @@ func (dutyCalendarController dutyCalendarController) API(gin *gin.RouterGroup) {
 	)
 	{
 		c.GET("getCalendarUsers", dutyCalendarController.GetCalendarUsers)
+		c.GET("onCall", dutyCalendarController.OnCall)
+	}
+
+	// 日历订阅地址, 供 Google/Outlook 等日历客户端拉取, 通过签名 token 鉴权
+	d := gin.Group("calendar")
+	{
+		d.GET("ics", dutyCalendarController.IcsFeed)
 	}
 }
 
@@ -66,6 +83,7 @@ func (dutyCalendarController dutyCalendarController) Update(ctx *gin.Context) {
 
 	tid, _ := ctx.Get("TenantID")
 	r.TenantId = tid.(string)
+	r.UpdateBy = tools.GetUser(ctx.Request.Header.Get("Authorization"))
 
 	Service(ctx, func() (interface{}, interface{}) {
 		return services.DutyCalendarService.Update(r)
@@ -95,3 +113,103 @@ func (dutyCalendarController dutyCalendarController) GetCalendarUsers(ctx *gin.C
 		return services.DutyCalendarService.GetCalendarUsers(r)
 	})
 }
+
+func (dutyCalendarController dutyCalendarController) ShiftSearch(ctx *gin.Context) {
+	r := new(types.RequestDutyCalendarQuery)
+	BindQuery(ctx, r)
+
+	tid, _ := ctx.Get("TenantID")
+	r.TenantId = tid.(string)
+
+	Service(ctx, func() (interface{}, interface{}) {
+		return services.DutyCalendarService.ShiftSearch(r)
+	})
+}
+
+func (dutyCalendarController dutyCalendarController) OnCall(ctx *gin.Context) {
+	r := new(types.RequestDutyCalendarQuery)
+	BindQuery(ctx, r)
+
+	tid, _ := ctx.Get("TenantID")
+	r.TenantId = tid.(string)
+
+	Service(ctx, func() (interface{}, interface{}) {
+		return services.DutyCalendarService.OnCall(r)
+	})
+}
+
+func (dutyCalendarController dutyCalendarController) OverrideCreate(ctx *gin.Context) {
+	r := new(types.RequestDutyOverrideCreate)
+	BindJson(ctx, r)
+
+	tid, _ := ctx.Get("TenantID")
+	r.TenantId = tid.(string)
+	r.CreateBy = tools.GetUser(ctx.Request.Header.Get("Authorization"))
+
+	Service(ctx, func() (interface{}, interface{}) {
+		return services.DutyCalendarService.OverrideCreate(r)
+	})
+}
+
+func (dutyCalendarController dutyCalendarController) OverrideDelete(ctx *gin.Context) {
+	r := new(types.RequestDutyOverrideDelete)
+	BindJson(ctx, r)
+
+	tid, _ := ctx.Get("TenantID")
+	r.TenantId = tid.(string)
+
+	Service(ctx, func() (interface{}, interface{}) {
+		return services.DutyCalendarService.OverrideDelete(r)
+	})
+}
+
+func (dutyCalendarController dutyCalendarController) IcsToken(ctx *gin.Context) {
+	r := new(types.RequestDutyIcsQuery)
+	BindQuery(ctx, r)
+
+	tid, _ := ctx.Get("TenantID")
+	r.TenantId = tid.(string)
+
+	Service(ctx, func() (interface{}, interface{}) {
+		return services.DutyCalendarService.IcsToken(r)
+	})
+}
+
+func (dutyCalendarController dutyCalendarController) IcsTokenReset(ctx *gin.Context) {
+	r := new(types.RequestDutyIcsQuery)
+	BindJson(ctx, r)
+
+	tid, _ := ctx.Get("TenantID")
+	r.TenantId = tid.(string)
+
+	Service(ctx, func() (interface{}, interface{}) {
+		return services.DutyCalendarService.IcsTokenReset(r)
+	})
+}
+
+func (dutyCalendarController dutyCalendarController) IcsFeed(ctx *gin.Context) {
+	r := new(types.RequestDutyIcsQuery)
+	BindQuery(ctx, r)
+
+	data, err := services.DutyCalendarService.IcsFeed(r)
+	if err != nil {
+		response.Fail(ctx, err.(error).Error(), "failed")
+		ctx.Abort()
+		return
+	}
+
+	ctx.Header("Content-Disposition", "inline; filename=duty.ics")
+	ctx.Data(http.StatusOK, "text/calendar; charset=utf-8", []byte(data.(string)))
+}
+
+func (dutyCalendarController dutyCalendarController) IcsImport(ctx *gin.Context) {
+	r := new(types.RequestDutyIcsImport)
+	BindJson(ctx, r)
+
+	tid, _ := ctx.Get("TenantID")
+	r.TenantId = tid.(string)
+
+	Service(ctx, func() (interface{}, interface{}) {
+		return services.DutyCalendarService.IcsImport(r)
+	})
+}
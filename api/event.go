@@ -1,6 +1,9 @@
 package api
 
 import (
+	"bytes"
+	"html/template"
+	"net/http"
 	"time"
 	"watchAlert/internal/middleware"
 	"watchAlert/internal/services"
@@ -43,6 +46,13 @@ func (alertEventController alertEventController) API(gin *gin.RouterGroup) {
 		b.GET("curEvent", alertEventController.ListCurrentEvent)
 		b.GET("hisEvent", alertEventController.ListHistoryEvent)
 	}
+
+	// 一键认领链接, 通过签名 token 鉴权, GET 展示确认页面, POST 执行认领
+	c := gin.Group("event")
+	{
+		c.GET("ack", alertEventController.AckLinkPage)
+		c.POST("ack", alertEventController.AckByLink)
+	}
 }
 
 func (alertEventController alertEventController) ProcessAlertEvent(ctx *gin.Context) {
@@ -142,3 +152,60 @@ func (alertEventController alertEventController) DeleteComment(ctx *gin.Context)
 		return services.EventService.DeleteComment(r)
 	})
 }
+
+// ackLinkPage 一键认领确认页面, 邮件客户端可能预先访问链接, 因此需要用户确认后才执行认领
+var ackLinkPage = template.Must(template.New("ack").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><meta name="viewport" content="width=device-width, initial-scale=1"><title>WatchAlert</title></head>
+<body style="font-family: sans-serif; max-width: 480px; margin: 40px auto; padding: 0 16px;">
+{{- if .Error }}
+<h3>{{ .Error }}</h3>
+{{- else }}
+<h3>[{{ .Data.Severity }}] {{ .Data.RuleName }}</h3>
+<pre style="white-space: pre-wrap;">{{ .Data.Annotations }}</pre>
+{{- if .Data.Acked }}
+<p>告警已由 {{ .Data.ConfirmUsername }} 认领</p>
+{{- else }}
+<form method="post">
+<input type="hidden" name="token" value="{{ .Token }}">
+<button type="submit" style="padding: 8px 24px;">以 {{ .Data.Username }} 的身份认领</button>
+</form>
+{{- end }}
+{{- end }}
+</body>
+</html>`))
+
+func (alertEventController alertEventController) AckLinkPage(ctx *gin.Context) {
+	r := new(types.RequestEventAckLink)
+	BindQuery(ctx, r)
+
+	data, err := services.EventService.AckLinkInfo(r)
+	renderAckLinkPage(ctx, r.Token, data, err)
+}
+
+func (alertEventController alertEventController) AckByLink(ctx *gin.Context) {
+	r := &types.RequestEventAckLink{
+		Token:     ctx.PostForm("token"),
+		IPAddress: ctx.ClientIP(),
+	}
+
+	data, err := services.EventService.AckByLink(r)
+	renderAckLinkPage(ctx, r.Token, data, err)
+}
+
+func renderAckLinkPage(ctx *gin.Context, token string, data, err interface{}) {
+	page := map[string]interface{}{
+		"Token": token,
+		"Data":  data,
+	}
+	if err != nil {
+		page["Error"] = err.(error).Error()
+	}
+
+	var buf bytes.Buffer
+	if e := ackLinkPage.Execute(&buf, page); e != nil {
+		response.Fail(ctx, e.Error(), "failed")
+		return
+	}
+	ctx.Data(http.StatusOK, "text/html; charset=utf-8", buf.Bytes())
+}
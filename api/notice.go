@@ -30,6 +30,8 @@ func (noticeController noticeController) API(gin *gin.RouterGroup) {
 		a.POST("noticeCreate", noticeController.Create)
 		a.POST("noticeUpdate", noticeController.Update)
 		a.POST("noticeDelete", noticeController.Delete)
+		a.POST("noticeDeadLetterReplay", noticeController.ReplayDeadLetter)
+		a.POST("noticeDeadLetterDelete", noticeController.DeleteDeadLetter)
 	}
 
 	b := gin.Group("notice")
@@ -41,6 +43,7 @@ func (noticeController noticeController) API(gin *gin.RouterGroup) {
 	{
 		b.GET("noticeList", noticeController.List)
 		b.GET("noticeRecordList", noticeController.ListRecord)
+		b.GET("noticeDeadLetterList", noticeController.ListDeadLetter)
 	}
 
 	c := gin.Group("notice")
@@ -139,6 +142,42 @@ func (noticeController noticeController) ListRecord(ctx *gin.Context) {
 	})
 }
 
+func (noticeController noticeController) ListDeadLetter(ctx *gin.Context) {
+	r := new(types.RequestNoticeDeadLetterQuery)
+	BindQuery(ctx, r)
+
+	tid, _ := ctx.Get("TenantID")
+	r.TenantId = tid.(string)
+
+	Service(ctx, func() (interface{}, interface{}) {
+		return services.NoticeService.ListDeadLetter(r)
+	})
+}
+
+func (noticeController noticeController) ReplayDeadLetter(ctx *gin.Context) {
+	r := new(types.RequestNoticeDeadLetterReplay)
+	BindJson(ctx, r)
+
+	tid, _ := ctx.Get("TenantID")
+	r.TenantId = tid.(string)
+
+	Service(ctx, func() (interface{}, interface{}) {
+		return services.NoticeService.ReplayDeadLetter(r)
+	})
+}
+
+func (noticeController noticeController) DeleteDeadLetter(ctx *gin.Context) {
+	r := new(types.RequestNoticeDeadLetterDelete)
+	BindJson(ctx, r)
+
+	tid, _ := ctx.Get("TenantID")
+	r.TenantId = tid.(string)
+
+	Service(ctx, func() (interface{}, interface{}) {
+		return services.NoticeService.DeleteDeadLetter(r)
+	})
+}
+
 func (noticeController noticeController) GetRecordMetric(ctx *gin.Context) {
 	r := new(types.RequestNoticeQuery)
 	BindQuery(ctx, r)
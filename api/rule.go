@@ -40,6 +40,8 @@ func (ruleController ruleController) API(gin *gin.RouterGroup) {
 	{
 		b.GET("ruleList", ruleController.List)
 		b.GET("ruleSearch", ruleController.Search)
+		b.POST("ruleBacktest", ruleController.Backtest)
+		b.POST("ruleTest", ruleController.Test)
 	}
 	c := gin.Group("rule")
 	c.Use(
@@ -125,6 +127,30 @@ func (ruleController ruleController) Search(ctx *gin.Context) {
 	})
 }
 
+func (ruleController ruleController) Backtest(ctx *gin.Context) {
+	r := new(types.RequestRuleBacktest)
+	BindJson(ctx, r)
+
+	tid, _ := ctx.Get("TenantID")
+	r.TenantId = tid.(string)
+
+	Service(ctx, func() (interface{}, interface{}) {
+		return services.RuleService.Backtest(r)
+	})
+}
+
+func (ruleController ruleController) Test(ctx *gin.Context) {
+	r := new(types.RequestRuleTest)
+	BindJson(ctx, r)
+
+	tid, _ := ctx.Get("TenantID")
+	r.TenantId = tid.(string)
+
+	Service(ctx, func() (interface{}, interface{}) {
+		return services.RuleService.Test(r)
+	})
+}
+
 func (ruleController ruleController) ChangeStatus(ctx *gin.Context) {
 	r := new(types.RequestRuleChangeStatus)
 	BindJson(ctx, r)
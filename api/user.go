@@ -43,6 +43,15 @@ func (userController userController) API(gin *gin.RouterGroup) {
 		b.GET("userList", userController.List)
 	}
 
+	c := gin.Group("user")
+	c.Use(
+		middleware.Auth(),
+	)
+	{
+		// 用户仅可修改自己的通知偏好
+		c.POST("userPreferenceUpdate", userController.UpdatePreference)
+	}
+
 }
 
 func (userController userController) List(ctx *gin.Context) {
@@ -135,3 +144,17 @@ func (userController userController) ChangePass(ctx *gin.Context) {
 		return services.UserService.ChangePass(r)
 	})
 }
+
+func (userController userController) UpdatePreference(ctx *gin.Context) {
+	r := new(types.RequestUserPreferenceUpdate)
+	BindJson(ctx, r)
+
+	Service(ctx, func() (interface{}, interface{}) {
+		r.UserId = jwtUtils.GetUserID(ctx.Request.Header.Get("Authorization"))
+		if r.UserId == "" {
+			return nil, errors.New("token is empty")
+		}
+
+		return services.UserService.UpdatePreference(r)
+	})
+}
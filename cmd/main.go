@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	_ "net/http/pprof"
+	"os"
 	"sync"
 	"watchAlert/alert"
 	"watchAlert/config"
@@ -26,6 +27,11 @@ import (
 var Version string
 
 func main() {
+	// 规则单元测试不依赖配置及存储
+	if len(os.Args) > 1 && os.Args[1] == "rules" {
+		os.Exit(runRulesCommand(os.Args[2:]))
+	}
+
 	// 初始化配置
 	config.InitConfig(Version)
 	logc.Info(context.Background(), "服务启动")
@@ -61,6 +67,7 @@ func main() {
 
 func initRouter(engine *gin.Engine) {
 	routers.HealthCheck(engine)
+	routers.Metrics(engine)
 	v1.Router(engine)
 }
 
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"watchAlert/alert/eval"
+	"watchAlert/internal/models"
+)
+
+const rulesUsage = `用法: w8t rules test <测试文件>...
+
+使用内存序列执行告警规则单元测试, 测试文件格式参照 promtool test rules, 全部通过时退出码为 0`
+
+// runRulesCommand 执行 rules 子命令, 返回进程退出码
+func runRulesCommand(args []string) int {
+	if len(args) < 2 || args[0] != "test" {
+		fmt.Fprintln(os.Stderr, rulesUsage)
+		return 2
+	}
+
+	exitCode := 0
+	for _, path := range args[1:] {
+		fmt.Printf("Unit Testing: %s\n", path)
+
+		file, err := eval.LoadRuleTestFile(path)
+		if err != nil {
+			fmt.Printf("  FAILED:\n    %v\n\n", err)
+			exitCode = 1
+			continue
+		}
+
+		result := eval.RunRuleTests(file)
+		if result.Success {
+			fmt.Printf("  SUCCESS (%d)\n\n", result.Total)
+			continue
+		}
+
+		exitCode = 1
+		fmt.Printf("  FAILED (%d/%d):\n", result.Failed, result.Total)
+		for _, c := range result.Cases {
+			if c.Success {
+				continue
+			}
+
+			fmt.Printf("    group: %s, alertname: %s, eval_time: %s\n", c.Group, c.AlertName, c.EvalTime)
+			if c.Error != "" {
+				fmt.Printf("        %s\n", c.Error)
+				continue
+			}
+			fmt.Printf("        exp: %s\n        got: %s\n", formatTestAlerts(c.ExpAlerts), formatTestAlerts(c.GotAlerts))
+		}
+		fmt.Println()
+	}
+
+	return exitCode
+}
+
+func formatTestAlerts(alerts []models.RuleTestExpAlert) string {
+	if len(alerts) == 0 {
+		return "[]"
+	}
+
+	var items []string
+	for _, alert := range alerts {
+		items = append(items, fmt.Sprintf("{labels: %v, annotations: %q}", alert.ExpLabels, alert.ExpAnnotations))
+	}
+
+	return "[" + strings.Join(items, ", ") + "]"
+}
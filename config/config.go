@@ -12,12 +12,17 @@ type App struct {
 	Redis    Redis    `json:"Redis"`
 	Jwt      Jwt      `json:"Jwt"`
 	Jaeger   Jaeger   `json:"Jaeger"`
+	Metrics  Metrics  `json:"Metrics"`
 }
 
 type Server struct {
 	Mode           string `json:"mode"`
 	Port           string `json:"port"`
 	EnableElection bool   `json:"enableElection"`
+	// 分片模式, 告警规则、故障中心消费及拨测任务按一致性哈希分配到各节点, 优先于选举模式
+	EnableSharding bool `json:"enableSharding"`
+	// 每个数据源并发执行的告警规则评估数, 默认 10
+	EvalWorkersPerDatasource int `json:"evalWorkersPerDatasource"`
 }
 
 type Database struct {
@@ -46,6 +51,13 @@ type Jaeger struct {
 	URL string `json:"url"`
 }
 
+// Metrics 自身指标接口, 默认关闭
+type Metrics struct {
+	Enabled bool `json:"enabled"`
+	// 访问 /metrics 需携带的 Bearer Token, 为空时不校验
+	Token string `json:"token"`
+}
+
 var (
 	Application App
 	Version     string
@@ -0,0 +1,56 @@
+package cache
+
+import (
+	"watchAlert/internal/models"
+	"watchAlert/pkg/tools"
+
+	"github.com/bytedance/sonic"
+	"github.com/go-redis/redis"
+)
+
+type (
+	// AlertGroupCache 用于管理标签分组的通知状态
+	AlertGroupCache struct {
+		rc *redis.Client
+	}
+
+	// AlertGroupCacheInterface 定义了分组状态缓存的操作接口
+	AlertGroupCacheInterface interface {
+		Set(tenantId, faultCenterId string, state models.AlertGroupState)
+		Delete(tenantId, faultCenterId, groupKey string)
+		List(tenantId, faultCenterId string) map[string]models.AlertGroupState
+	}
+)
+
+// newAlertGroupCacheInterface 创建一个新的 AlertGroupCache 实例
+func newAlertGroupCacheInterface(r *redis.Client) AlertGroupCacheInterface {
+	return &AlertGroupCache{
+		rc: r,
+	}
+}
+
+func (a *AlertGroupCache) Set(tenantId, faultCenterId string, state models.AlertGroupState) {
+	a.rc.HSet(string(models.BuildAlertGroupCacheKey(tenantId, faultCenterId)), state.GroupKey, tools.JsonMarshalToString(state))
+}
+
+func (a *AlertGroupCache) Delete(tenantId, faultCenterId, groupKey string) {
+	a.rc.HDel(string(models.BuildAlertGroupCacheKey(tenantId, faultCenterId)), groupKey)
+}
+
+func (a *AlertGroupCache) List(tenantId, faultCenterId string) map[string]models.AlertGroupState {
+	result, err := a.rc.HGetAll(string(models.BuildAlertGroupCacheKey(tenantId, faultCenterId))).Result()
+	if err != nil {
+		return map[string]models.AlertGroupState{}
+	}
+
+	states := make(map[string]models.AlertGroupState, len(result))
+	for k, v := range result {
+		var state models.AlertGroupState
+		if err := sonic.Unmarshal([]byte(v), &state); err != nil {
+			continue
+		}
+		states[k] = state
+	}
+
+	return states
+}
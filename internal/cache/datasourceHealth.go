@@ -0,0 +1,53 @@
+package cache
+
+import (
+	"watchAlert/internal/models"
+	"watchAlert/pkg/tools"
+
+	"github.com/bytedance/sonic"
+	"github.com/go-redis/redis"
+)
+
+// datasourceHealthCacheKey 数据源健康状态, field 为数据源 ID
+const datasourceHealthCacheKey = "w8t:datasourceHealth"
+
+type (
+	// DatasourceHealthCache 用于管理数据源的健康状态
+	DatasourceHealthCache struct {
+		rc *redis.Client
+	}
+
+	// DatasourceHealthCacheInterface 定义了数据源健康状态缓存的操作接口
+	DatasourceHealthCacheInterface interface {
+		Set(datasourceId string, health models.DatasourceHealth)
+		Get(datasourceId string) models.DatasourceHealth
+		Delete(datasourceId string)
+	}
+)
+
+// newDatasourceHealthCacheInterface 创建一个新的 DatasourceHealthCache 实例
+func newDatasourceHealthCacheInterface(r *redis.Client) DatasourceHealthCacheInterface {
+	return &DatasourceHealthCache{
+		rc: r,
+	}
+}
+
+func (d *DatasourceHealthCache) Set(datasourceId string, health models.DatasourceHealth) {
+	d.rc.HSet(datasourceHealthCacheKey, datasourceId, tools.JsonMarshalToString(health))
+}
+
+// Get 获取数据源健康状态, 未检查过的数据源状态为 unknown
+func (d *DatasourceHealthCache) Get(datasourceId string) models.DatasourceHealth {
+	health := models.DatasourceHealth{Status: models.DatasourceUnknown}
+	result, err := d.rc.HGet(datasourceHealthCacheKey, datasourceId).Result()
+	if err != nil {
+		return health
+	}
+
+	_ = sonic.UnmarshalString(result, &health)
+	return health
+}
+
+func (d *DatasourceHealthCache) Delete(datasourceId string) {
+	d.rc.HDel(datasourceHealthCacheKey, datasourceId)
+}
@@ -19,6 +19,8 @@ type (
 		ProviderPools() *ProviderPoolStore
 		FaultCenter() FaultCenterCacheInterface
 		PendingRecover() PendingRecoverCacheInterface
+		AlertGroup() AlertGroupCacheInterface
+		DatasourceHealth() DatasourceHealthCacheInterface
 	}
 )
 
@@ -42,3 +44,9 @@ func (e entryCache) FaultCenter() FaultCenterCacheInterface {
 func (e entryCache) PendingRecover() PendingRecoverCacheInterface {
 	return newPendingRecoverCacheInterface(e.redis)
 }
+func (e entryCache) AlertGroup() AlertGroupCacheInterface {
+	return newAlertGroupCacheInterface(e.redis)
+}
+func (e entryCache) DatasourceHealth() DatasourceHealthCacheInterface {
+	return newDatasourceHealthCacheInterface(e.redis)
+}
@@ -8,13 +8,16 @@ import (
 // ProviderPoolStore 提供商客户端存储池
 type ProviderPoolStore struct {
 	clients map[string]interface{}
-	mux     sync.RWMutex
+	// 客户端对应的配置版本
+	versions map[string]string
+	mux      sync.RWMutex
 }
 
 // NewClientPoolStore 创建一个新的 ProviderPoolStore 实例
 func NewClientPoolStore() *ProviderPoolStore {
 	return &ProviderPoolStore{
-		clients: make(map[string]interface{}),
+		clients:  make(map[string]interface{}),
+		versions: make(map[string]string),
 	}
 }
 
@@ -24,6 +27,25 @@ func (p *ProviderPoolStore) SetClient(key string, client interface{}) {
 	defer p.mux.Unlock()
 
 	p.clients[key] = client
+	delete(p.versions, key)
+}
+
+// SetClientWithVersion 设置客户端及其配置版本
+func (p *ProviderPoolStore) SetClientWithVersion(key, version string, client interface{}) {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	p.clients[key] = client
+	p.versions[key] = version
+}
+
+// HasClientVersion 客户端是否存在且配置版本一致
+func (p *ProviderPoolStore) HasClientVersion(key, version string) bool {
+	p.mux.RLock()
+	defer p.mux.RUnlock()
+
+	_, exists := p.clients[key]
+	return exists && p.versions[key] == version
 }
 
 // GetClient 获取通用客户端
@@ -44,4 +66,5 @@ func (p *ProviderPoolStore) RemoveClient(key string) {
 	defer p.mux.Unlock()
 
 	delete(p.clients, key)
+	delete(p.versions, key)
 }
@@ -13,6 +13,8 @@ type Context struct {
 	Ctx        context.Context
 	Mux        sync.RWMutex
 	ContextMap map[string]context.CancelFunc
+	// TaskVersions 各任务启动时的配置版本, 定期分配任务时用于判断配置是否变更
+	TaskVersions map[string]string
 }
 
 var (
@@ -26,18 +28,20 @@ func NewContext(ctx context.Context, db repo.InterEntryRepo, redis cache.InterEn
 	Redis = redis
 	Ctx = ctx
 	return &Context{
-		DB:         db,
-		Redis:      redis,
-		Ctx:        ctx,
-		ContextMap: make(map[string]context.CancelFunc),
+		DB:           db,
+		Redis:        redis,
+		Ctx:          ctx,
+		ContextMap:   make(map[string]context.CancelFunc),
+		TaskVersions: make(map[string]string),
 	}
 }
 
 func DO() *Context {
 	return &Context{
-		DB:         DB,
-		Redis:      Redis,
-		Ctx:        Ctx,
-		ContextMap: make(map[string]context.CancelFunc),
+		DB:           DB,
+		Redis:        Redis,
+		Ctx:          Ctx,
+		ContextMap:   make(map[string]context.CancelFunc),
+		TaskVersions: make(map[string]string),
 	}
 }
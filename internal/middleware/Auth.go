@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"strings"
 	"time"
 	"watchAlert/internal/ctx"
 	"watchAlert/internal/models"
@@ -55,6 +56,39 @@ func Auth() gin.HandlerFunc {
 	}
 }
 
+// ReceiverAuth 告警接收接口认证
+// 兼容 Alertmanager 客户端, API Key 可通过 X-API-Key、Bearer Token 或 Basic Auth 密码携带。
+func ReceiverAuth() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		apiKey := context.Request.Header.Get(ApiKeyHeader)
+		if apiKey == "" {
+			tokenStr := context.Request.Header.Get("Authorization")
+			if len(tokenStr) > len(tools.TokenType)+1 && strings.EqualFold(tokenStr[:len(tools.TokenType)], tools.TokenType) {
+				apiKey = tokenStr[len(tools.TokenType)+1:]
+			} else if _, password, ok := context.Request.BasicAuth(); ok {
+				apiKey = password
+			}
+		}
+
+		if apiKey == "" {
+			response.TokenFail(context)
+			context.Abort()
+			return
+		}
+
+		userId, ok := IsApiKeyValid(ctx.DO(), apiKey)
+		if !ok {
+			response.TokenFail(context)
+			context.Abort()
+			return
+		}
+		context.Set("UserId", userId)
+		context.Set("ApiKey", apiKey)
+
+		context.Next()
+	}
+}
+
 func IsTokenValid(ctx *ctx.Context, tokenStr string) bool {
 	// Bearer Token, 获取 Token 值
 	tokenStr = tokenStr[len(tools.TokenType)+1:]
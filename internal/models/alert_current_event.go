@@ -21,6 +21,14 @@ const (
 	StateRecovered       AlertStatus = "recovered"        // 已恢复
 )
 
+// 外部推送事件的来源类型
+const (
+	ExternalSourceAlertmanager = "Alertmanager" // Alertmanager 兼容接口
+	ExternalSourceEvents       = "Events"       // 通用事件接口
+	// 数据源健康检查产生的事件, 与外部事件一样没有评估器
+	ExternalSourceDatasourceHealth = "DatasourceHealth"
+)
+
 type AlertCurEvent struct {
 	TenantId             string                 `json:"tenantId"`
 	EventId              string                 `json:"eventId"`
@@ -47,7 +55,11 @@ type AlertCurEvent struct {
 	FaultCenterId        string                 `json:"faultCenterId"`
 	FaultCenter          FaultCenter            `json:"faultCenter" gorm:"-"`
 	ConfirmState         ConfirmState           `json:"confirmState" gorm:"-"`
-	Status               AlertStatus            `json:"status" gorm:"-"` // 事件状态
+	Status               AlertStatus            `json:"status" gorm:"-"`                // 事件状态
+	UpgradeState         UpgradeState           `json:"upgradeState" gorm:"-"`          // 告警升级进度
+	GroupAlerts          []AlertCurEvent        `json:"groupAlerts,omitempty" gorm:"-"` // 标签分组通知时的全部成员事件
+	TicketKey            string                 `json:"ticketKey" gorm:"-"`             // 工单编号
+	EndsAt               int64                  `json:"endsAt" gorm:"-"`                // 外部来源声明的恢复时间, 超过后未再推送时自动恢复
 }
 
 type ConfirmState struct {
@@ -57,6 +69,42 @@ type ConfirmState struct {
 	ConfirmUsername        string `json:"confirmUsername"`
 }
 
+// 告警升级阶段
+const (
+	UpgradeStageConfirm = "confirm" // 未认领
+	UpgradeStageAcked   = "acked"   // 已认领未恢复
+)
+
+// UpgradeState 事件的告警升级进度
+type UpgradeState struct {
+	Level           int             `json:"level"`           // 当前已升级到的级别, 0 表示未升级
+	LastNoticeTime  int64           `json:"lastNoticeTime"`  // 当前级别最后一次通知时间
+	AckedNoticeTime int64           `json:"ackedNoticeTime"` // 已认领阶段最后一次通知时间
+	Records         []UpgradeRecord `json:"records"`         // 升级通知记录
+}
+
+// MaxUpgradeRecords 每个事件保留的升级通知记录数, 重复通知会不断追加记录, 仅保留最近的记录
+const MaxUpgradeRecords = 20
+
+// AddRecord 追加升级通知记录, 超出上限时丢弃最早的记录
+func (u *UpgradeState) AddRecord(record UpgradeRecord) {
+	u.Records = append(u.Records, record)
+	if n := len(u.Records); n > MaxUpgradeRecords {
+		u.Records = append([]UpgradeRecord(nil), u.Records[n-MaxUpgradeRecords:]...)
+	}
+}
+
+// UpgradeRecord 升级通知记录
+type UpgradeRecord struct {
+	Stage    string   `json:"stage"`
+	Level    int      `json:"level"`
+	Name     string   `json:"name"`
+	NoticeId string   `json:"noticeId"`
+	DutyId   string   `json:"dutyId"`
+	Users    []string `json:"users"` // 被通知的值班人员
+	Time     int64    `json:"time"`
+}
+
 const (
 	SortOrderASC  string = "ascend"
 	SortOrderDesc string = "descend"
@@ -190,6 +238,33 @@ func (alert *AlertCurEvent) GetEventId() string {
 	return alert.EventId
 }
 
+// IsExternalEvent 是否为外部推送的事件, 此类事件没有评估器, 由推送方决定恢复
+func (alert *AlertCurEvent) IsExternalEvent() bool {
+	return alert.DatasourceType == ExternalSourceAlertmanager || alert.DatasourceType == ExternalSourceEvents || alert.DatasourceType == ExternalSourceDatasourceHealth
+}
+
+// GroupFiring 分组中告警中的事件, 模版中可通过 {{ range .GroupFiring }} 遍历
+func (alert AlertCurEvent) GroupFiring() []AlertCurEvent {
+	var events []AlertCurEvent
+	for _, e := range alert.GroupAlerts {
+		if !e.IsRecovered {
+			events = append(events, e)
+		}
+	}
+	return events
+}
+
+// GroupResolved 分组中已恢复的事件
+func (alert AlertCurEvent) GroupResolved() []AlertCurEvent {
+	var events []AlertCurEvent
+	for _, e := range alert.GroupAlerts {
+		if e.IsRecovered {
+			events = append(events, e)
+		}
+	}
+	return events
+}
+
 func (alert *AlertCurEvent) GetJsonString() string {
 	b, err := json.Marshal(alert)
 	if err != nil {
@@ -0,0 +1,22 @@
+package models
+
+// AlertGroupState 标签分组的通知状态
+type AlertGroupState struct {
+	GroupKey     string                 `json:"groupKey"`
+	NoticeId     string                 `json:"noticeId"`
+	GroupLabels  map[string]interface{} `json:"groupLabels"`
+	CreateAt     int64                  `json:"createAt"`     // 分组创建时间
+	LastNotifyAt int64                  `json:"lastNotifyAt"` // 最后一次通知时间
+	Notified     []string               `json:"notified"`     // 最后一次通知时告警中的事件指纹
+	Resolved     []AlertCurEvent        `json:"resolved"`     // 等待通知的已恢复事件
+}
+
+// AddResolved 记录等待通知的已恢复事件
+func (g *AlertGroupState) AddResolved(event AlertCurEvent) {
+	for _, e := range g.Resolved {
+		if e.Fingerprint == event.Fingerprint {
+			return
+		}
+	}
+	g.Resolved = append(g.Resolved, event)
+}
@@ -21,4 +21,5 @@ type AlertHisEvent struct {
 	ConfirmState     ConfirmState           `json:"confirmState" gorm:"metric;serializer:json"`
 	AlarmDuration    int64                  `json:"alarmDuration"` // 告警持续时长
 	SearchQL         string                 `json:"searchQL"`
+	TicketKey        string                 `json:"ticketKey"` // 工单编号
 }
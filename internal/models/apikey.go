@@ -3,12 +3,14 @@ package models
 import "time"
 
 type ApiKey struct {
-	ID          int       `json:"id" gorm:"primaryKey;autoIncrement"`
-	UserId      string    `json:"userId" gorm:"column:user_id;not null"`
-	Name        string    `json:"name" gorm:"column:name;size:255;not null"`
-	Description string    `json:"description" gorm:"column:description;size:500"`
-	Key         string    `json:"key" gorm:"column:key;size:255;not null;uniqueIndex"`
-	CreatedAt   time.Time `json:"createdAt" gorm:"column:created_at"`
+	ID            int       `json:"id" gorm:"primaryKey;autoIncrement"`
+	UserId        string    `json:"userId" gorm:"column:user_id;not null"`
+	Name          string    `json:"name" gorm:"column:name;size:255;not null"`
+	Description   string    `json:"description" gorm:"column:description;size:500"`
+	Key           string    `json:"key" gorm:"column:key;size:255;not null;uniqueIndex"`
+	TenantId      string    `json:"tenantId" gorm:"column:tenant_id"`            // 绑定故障中心所属租户
+	FaultCenterId string    `json:"faultCenterId" gorm:"column:fault_center_id"` // 绑定的故障中心, 告警接收接口默认推送至该故障中心
+	CreatedAt     time.Time `json:"createdAt" gorm:"column:created_at"`
 }
 
 func (ApiKey) TableName() string {
@@ -44,10 +44,12 @@ var AuditEventMap = map[string]string{
 	"/api/w8t/silence/silenceDelete": "删除静默规则",
 
 	// ========== 通知对象相关 ==========
-	"/api/w8t/notice/noticeCreate": "创建通知对象",
-	"/api/w8t/notice/noticeUpdate": "更新通知对象",
-	"/api/w8t/notice/noticeDelete": "删除通知对象",
-	"/api/w8t/notice/noticeTest":   "测试通知",
+	"/api/w8t/notice/noticeCreate":           "创建通知对象",
+	"/api/w8t/notice/noticeUpdate":           "更新通知对象",
+	"/api/w8t/notice/noticeDelete":           "删除通知对象",
+	"/api/w8t/notice/noticeTest":             "测试通知",
+	"/api/w8t/notice/noticeDeadLetterReplay": "重新投递通知死信",
+	"/api/w8t/notice/noticeDeadLetterDelete": "删除通知死信",
 
 	// ========== 通知模版相关 ==========
 	"/api/w8t/noticeTemplate/noticeTemplateCreate": "创建通知模版",
@@ -70,6 +72,10 @@ var AuditEventMap = map[string]string{
 	"/api/w8t/calendar/calendarCreate": "创建值班表",
 	"/api/w8t/calendar/calendarUpdate": "更新值班表",
 	"/api/w8t/calendar/calendarDelete": "删除值班表",
+	"/api/w8t/calendar/overrideCreate": "创建替班",
+	"/api/w8t/calendar/overrideDelete": "删除替班",
+	"/api/w8t/calendar/icsTokenReset":  "重置值班日历订阅地址",
+	"/api/w8t/calendar/icsImport":      "导入值班日历",
 
 	// ========== 仪表盘相关 ==========
 	"/api/w8t/dashboard/createFolder": "创建仪表盘目录",
@@ -107,6 +113,7 @@ var AuditEventMap = map[string]string{
 	"/api/w8t/event/addComment":    "添加评论",
 	"/api/w8t/event/deleteComment": "删除评论",
 	"/api/w8t/event/process":       "处理告警事件",
+	"/api/w8t/event/ack":           "一键认领告警事件",
 
 	// ========== 记录规则相关 ==========
 	"/api/w8t/recordingRule/recordingRuleCreate":       "创建记录规则",
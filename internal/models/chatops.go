@@ -0,0 +1,144 @@
+package models
+
+import (
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	ChatOpsActionAck        = "ack"
+	ChatOpsActionSilence1h  = "silence_1h"
+	ChatOpsActionSilence4h  = "silence_4h"
+	ChatOpsActionViewDetail = "view_detail"
+)
+
+// ChatOpsActionValue 卡片按钮回传的内容
+type ChatOpsActionValue struct {
+	Action        string `json:"action"`
+	TenantId      string `json:"tenantId"`
+	FaultCenterId string `json:"faultCenterId"`
+	Fingerprint   string `json:"fingerprint"`
+	NoticeTmplId  string `json:"noticeTmplId"`
+}
+
+// GetSilenceDuration 静默操作的静默时长
+func (v ChatOpsActionValue) GetSilenceDuration() (time.Duration, bool) {
+	switch v.Action {
+	case ChatOpsActionSilence1h:
+		return time.Hour, true
+	case ChatOpsActionSilence4h:
+		return 4 * time.Hour, true
+	}
+	return 0, false
+}
+
+// NewChatOpsActionValue 生成事件的卡片按钮回传内容
+func NewChatOpsActionValue(action string, alert AlertCurEvent, noticeTmplId string) ChatOpsActionValue {
+	return ChatOpsActionValue{
+		Action:        action,
+		TenantId:      alert.TenantId,
+		FaultCenterId: alert.FaultCenterId,
+		Fingerprint:   alert.Fingerprint,
+		NoticeTmplId:  noticeTmplId,
+	}
+}
+
+// GetEventDetailUrl 告警事件详情地址, 未配置访问地址时为空
+func (c ChatOpsConfig) GetEventDetailUrl(alert AlertCurEvent) string {
+	if c.SiteUrl == "" {
+		return ""
+	}
+
+	return strings.TrimRight(c.SiteUrl, "/") + "/faultCenter/detail/" + url.PathEscape(alert.FaultCenterId) +
+		"?fingerprint=" + url.QueryEscape(alert.Fingerprint)
+}
+
+// GetAckLinkUrl 一键认领链接地址, 未开启或未配置访问地址时为空
+func (c ChatOpsConfig) GetAckLinkUrl(token string) string {
+	if c.SiteUrl == "" || !c.AckLink.GetEnable() {
+		return ""
+	}
+
+	return strings.TrimRight(c.SiteUrl, "/") + "/api/w8t/event/ack?token=" + url.QueryEscape(token)
+}
+
+// FeiShuCallback 飞书卡片回传交互, 同时兼容 URL 校验请求
+type FeiShuCallback struct {
+	Encrypt   string               `json:"encrypt"`
+	Type      string               `json:"type"`
+	Challenge string               `json:"challenge"`
+	Token     string               `json:"token"`
+	Schema    string               `json:"schema"`
+	Header    FeiShuCallbackHeader `json:"header"`
+	Event     FeiShuCallbackEvent  `json:"event"`
+}
+
+type FeiShuCallbackHeader struct {
+	EventType string `json:"event_type"`
+	Token     string `json:"token"`
+}
+
+type FeiShuCallbackEvent struct {
+	Operator struct {
+		OpenId string `json:"open_id"`
+		UserId string `json:"user_id"`
+	} `json:"operator"`
+	Action struct {
+		Tag   string             `json:"tag"`
+		Value ChatOpsActionValue `json:"value"`
+	} `json:"action"`
+}
+
+// DingDingCallback 钉钉互动卡片回传请求
+type DingDingCallback struct {
+	OutTrackId string `json:"outTrackId"`
+	UserId     string `json:"userId"`
+	// Content 为 JSON 字符串, 如 {"cardPrivateData":{"actionIds":["ack"],"params":{...}}}
+	Content string `json:"content"`
+}
+
+type DingDingCallbackContent struct {
+	CardPrivateData struct {
+		ActionIds []string           `json:"actionIds"`
+		Params    ChatOpsActionValue `json:"params"`
+	} `json:"cardPrivateData"`
+}
+
+// SlackCallback Slack 交互组件回传内容
+type SlackCallback struct {
+	Type        string `json:"type"`
+	ResponseUrl string `json:"response_url"`
+	User        struct {
+		Id       string `json:"id"`
+		Username string `json:"username"`
+	} `json:"user"`
+	Actions []struct {
+		ActionId string `json:"action_id"`
+		Value    string `json:"value"`
+	} `json:"actions"`
+}
+
+// PagerDutyCallback PagerDuty Webhook V3 事件, incident_key 即发送时的 dedup_key
+type PagerDutyCallback struct {
+	Event struct {
+		EventType string `json:"event_type"`
+		Agent     struct {
+			Summary string `json:"summary"`
+		} `json:"agent"`
+		Data struct {
+			Id          string `json:"id"`
+			IncidentKey string `json:"incident_key"`
+		} `json:"data"`
+	} `json:"event"`
+}
+
+// OpsgenieCallback Opsgenie Webhook 集成推送的告警操作, alias 即发送时的事件指纹
+type OpsgenieCallback struct {
+	Action string `json:"action"`
+	Alert  struct {
+		AlertId  string `json:"alertId"`
+		Alias    string `json:"alias"`
+		Username string `json:"username"`
+	} `json:"alert"`
+}
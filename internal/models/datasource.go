@@ -1,5 +1,10 @@
 package models
 
+import (
+	"time"
+	"watchAlert/pkg/tools"
+)
+
 type AlertDataSource struct {
 	TenantId         string                 `json:"tenantId"`
 	ID               string                 `json:"id"`
@@ -17,6 +22,89 @@ type AlertDataSource struct {
 	UpdateBy         string                 `json:"updateBy"`
 	UpdateAt         int64                  `json:"updateAt"`
 	Enabled          *bool                  `json:"enabled" `
+	// 数据源健康告警配置
+	HealthAlert DatasourceHealthAlert `json:"healthAlert" gorm:"healthAlert;serializer:json"`
+	// 健康状态, 由健康检查任务维护, 不落库
+	Health DatasourceHealth `json:"health" gorm:"-"`
+}
+
+const (
+	// DatasourceCheckInterval 数据源健康检查周期
+	DatasourceCheckInterval = 30 * time.Second
+	// datasourceHealthStaleAfter 超过该时间未更新的健康状态视为未知, 避免检查节点下线后规则一直被跳过
+	datasourceHealthStaleAfter = 3 * DatasourceCheckInterval
+)
+
+// 数据源健康状态及熔断状态
+const (
+	DatasourceHealthy   = "healthy"
+	DatasourceUnhealthy = "unhealthy"
+	DatasourceUnknown   = "unknown"
+
+	// 熔断关闭, 正常检查
+	BreakerClosed = "closed"
+	// 熔断打开, 冷却期内不检查, 规则跳过评估
+	BreakerOpen = "open"
+	// 冷却期结束, 下一次检查成功后关闭熔断
+	BreakerHalfOpen = "half_open"
+)
+
+// DatasourceHealthAlert 数据源连续健康检查失败后, 向故障中心推送告警事件, 恢复健康后自动恢复
+type DatasourceHealthAlert struct {
+	Enabled       *bool  `json:"enabled"`
+	FaultCenterId string `json:"faultCenterId"`
+	// 连续失败次数阈值, 默认 3
+	FailureThreshold int    `json:"failureThreshold"`
+	Severity         string `json:"severity"`
+}
+
+func (d DatasourceHealthAlert) GetEnabled() bool {
+	return d.Enabled != nil && *d.Enabled && d.FaultCenterId != ""
+}
+
+func (d DatasourceHealthAlert) GetFailureThreshold() int {
+	if d.FailureThreshold <= 0 {
+		return 3
+	}
+	return d.FailureThreshold
+}
+
+func (d DatasourceHealthAlert) GetSeverity() string {
+	if d.Severity == "" {
+		return "P1"
+	}
+	return d.Severity
+}
+
+// DatasourceHealth 数据源健康状态
+type DatasourceHealth struct {
+	Status              string `json:"status"`
+	LastError           string `json:"lastError"`
+	LastSuccessTime     int64  `json:"lastSuccessTime"`
+	LastCheckTime       int64  `json:"lastCheckTime"`
+	ConsecutiveFailures int    `json:"consecutiveFailures"`
+	// 已推送健康告警的故障中心, 恢复后清空
+	AlertFaultCenterId string `json:"alertFaultCenterId"`
+	Breaker            string `json:"breaker"`
+	// 熔断冷却结束时间
+	OpenUntil int64 `json:"openUntil"`
+}
+
+// IsAvailable 规则是否可以使用该数据源评估, 未检查过或状态过期的数据源视为可用
+func (d DatasourceHealth) IsAvailable() bool {
+	if d.Status != DatasourceUnhealthy {
+		return true
+	}
+
+	now := time.Now().Unix()
+	// 熔断冷却期内不会检查, 冷却期结束前规则跳过评估
+	if d.Breaker == BreakerOpen && now < d.OpenUntil {
+		return false
+	}
+
+	// 冷却期结束后由下一次检查更新状态, 从冷却结束时开始计算是否过期
+	lastUpdate := max(d.LastCheckTime, d.OpenUntil)
+	return now-lastUpdate > int64(datasourceHealthStaleAfter/time.Second)
 }
 
 type Write struct {
@@ -67,6 +155,21 @@ type AWSCloudWatch struct {
 //	Value  []interface{}          `json:"value"`
 //}
 
+// GetClientVersion 客户端连接配置的摘要, 配置未变化时复用客户端存储池中的客户端
+func (d *AlertDataSource) GetClientVersion() string {
+	return tools.Md5Hash([]byte(tools.JsonMarshalToString(struct {
+		Type             string
+		Labels           map[string]interface{}
+		HTTP             HTTP
+		Write            Write
+		Auth             Auth
+		DsAliCloudConfig DsAliCloudConfig
+		AWSCloudWatch    AWSCloudWatch
+		ClickHouseConfig DsClickHouseConfig
+		KubeConfig       string
+	}{d.Type, d.Labels, d.HTTP, d.Write, d.Auth, d.DsAliCloudConfig, d.AWSCloudWatch, d.ClickHouseConfig, d.KubeConfig})))
+}
+
 func (d *AlertDataSource) GetEnabled() bool {
 	if d.Enabled == nil {
 		isOk := false
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDatasourceHealthIsAvailable(t *testing.T) {
+	now := time.Now().Unix()
+	tests := []struct {
+		name   string
+		health DatasourceHealth
+		want   bool
+	}{
+		{name: "never checked", health: DatasourceHealth{}, want: true},
+		{name: "healthy", health: DatasourceHealth{Status: DatasourceHealthy, LastCheckTime: now}, want: true},
+		{
+			name:   "healthy with stale breaker",
+			health: DatasourceHealth{Status: DatasourceHealthy, LastCheckTime: now, Breaker: BreakerOpen, OpenUntil: now + 600},
+			want:   true,
+		},
+		{name: "unhealthy", health: DatasourceHealth{Status: DatasourceUnhealthy, LastCheckTime: now}, want: false},
+		{name: "unhealthy but stale", health: DatasourceHealth{Status: DatasourceUnhealthy, LastCheckTime: now - 300}, want: true},
+		{
+			name:   "cooling down",
+			health: DatasourceHealth{Status: DatasourceUnhealthy, LastCheckTime: now - 300, Breaker: BreakerOpen, OpenUntil: now + 60},
+			want:   false,
+		},
+		{
+			name:   "cooldown just ended",
+			health: DatasourceHealth{Status: DatasourceUnhealthy, LastCheckTime: now - 600, Breaker: BreakerOpen, OpenUntil: now - 10},
+			want:   false,
+		},
+		{
+			name:   "cooldown ended long ago",
+			health: DatasourceHealth{Status: DatasourceUnhealthy, LastCheckTime: now - 900, Breaker: BreakerOpen, OpenUntil: now - 300},
+			want:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.health.IsAvailable(); got != tt.want {
+				t.Errorf("IsAvailable() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
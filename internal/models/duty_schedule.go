@@ -1,5 +1,10 @@
 package models
 
+import (
+	"fmt"
+	"time"
+)
+
 type DutyManagement struct {
 	TenantId    string     `json:"tenantId"`
 	ID          string     `json:"id"`
@@ -36,10 +41,93 @@ type DutyUser struct {
 }
 
 type DutyCalendarInfo struct {
-	TenantId   string       `json:"tenantId"`
-	DutyId     string       `json:"dutyId"`
+	TenantId     string       `json:"tenantId"`
+	DutyId       string       `json:"dutyId"`
+	DutyPeriod   int          `json:"dutyPeriod"`
+	Month        string       `json:"month"`
+	UserGroup    [][]DutyUser `json:"userGroup"  gorm:"userGroup;serializer:json"`
+	DateType     string       `json:"dateType"`
+	Timezone     string       `json:"timezone"`                             // IANA 时区, 如 Asia/Shanghai, 默认为服务所在时区
+	HandoverTime string       `json:"handoverTime"`                         // 交接班时间, 如 09:00, 默认 00:00
+	Layers       []DutyLayer  `json:"layers" gorm:"layers;serializer:json"` // 副值班等其他值班层级
+	IcsKey       string       `json:"-"`                                    // 日历订阅签名密钥, 重置后旧订阅地址失效
+}
+
+// DutyLayer 值班层级, 每个层级独立轮换
+type DutyLayer struct {
+	Name       string       `json:"name"`
 	DutyPeriod int          `json:"dutyPeriod"`
-	Month      string       `json:"month"`
-	UserGroup  [][]DutyUser `json:"userGroup"  gorm:"userGroup;serializer:json"`
-	DateType   string       `json:"dateType"`
+	UserGroup  [][]DutyUser `json:"userGroup"`
+	DateType   string       `json:"dateType"` // hour、day、week、month、year
+}
+
+// DutyLayerPrimary 主值班层级
+const DutyLayerPrimary = 0
+
+// GetLayers 获取全部值班层级, 主值班为第 0 层
+func (d DutyCalendarInfo) GetLayers() []DutyLayer {
+	layers := []DutyLayer{{
+		Name:       "primary",
+		DutyPeriod: d.DutyPeriod,
+		UserGroup:  d.UserGroup,
+		DateType:   d.DateType,
+	}}
+	return append(layers, d.Layers...)
+}
+
+// GetLocation 获取值班表时区
+func (d DutyCalendarInfo) GetLocation() *time.Location {
+	if d.Timezone == "" {
+		return time.Local
+	}
+	loc, err := time.LoadLocation(d.Timezone)
+	if err != nil {
+		return time.Local
+	}
+	return loc
+}
+
+// GetHandover 获取交接班的时、分
+func (d DutyCalendarInfo) GetHandover() (int, int) {
+	var hour, minute int
+	if d.HandoverTime == "" {
+		return 0, 0
+	}
+	if _, err := fmt.Sscanf(d.HandoverTime, "%d:%d", &hour, &minute); err != nil {
+		return 0, 0
+	}
+	return hour, minute
+}
+
+// DutyShift 值班班次, 精确到时间点
+type DutyShift struct {
+	TenantId  string     `json:"tenantId"`
+	DutyId    string     `json:"dutyId" gorm:"index"`
+	Layer     int        `json:"layer"`     // 值班层级, 0 为主值班
+	StartTime int64      `json:"startTime"` // 开始时间（包含）
+	EndTime   int64      `json:"endTime"`   // 结束时间（不包含）
+	Users     []DutyUser `json:"users" gorm:"users;serializer:json"`
+	Status    string     `json:"status"`
+}
+
+func (DutyShift) TableName() string {
+	return "w8t_duty_shifts"
+}
+
+// DutyOverride 临时替班/换班, 生效期间优先于班次
+type DutyOverride struct {
+	TenantId  string     `json:"tenantId"`
+	ID        string     `json:"id"`
+	DutyId    string     `json:"dutyId" gorm:"index"`
+	Layer     int        `json:"layer"`
+	StartTime int64      `json:"startTime"`
+	EndTime   int64      `json:"endTime"`
+	Users     []DutyUser `json:"users" gorm:"users;serializer:json"`
+	Reason    string     `json:"reason"`
+	CreateBy  string     `json:"createBy"`
+	CreateAt  int64      `json:"createAt"`
+}
+
+func (DutyOverride) TableName() string {
+	return "w8t_duty_overrides"
 }
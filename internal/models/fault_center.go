@@ -3,6 +3,7 @@ package models
 import (
 	"fmt"
 	"slices"
+	"watchAlert/pkg/tools"
 )
 
 // 常量定义
@@ -29,6 +30,14 @@ type FaultCenter struct {
 	IsUpgradeEnabled      *bool           `json:"isUpgradeEnabled" gorm:"column:isUpgradeEnabled"`
 	UpgradableSeverity    []string        `json:"upgradableSeverity" gorm:"column:upgradableSeverity;serializer:json"`
 	UpgradeStrategy       UpgradeStrategy `json:"upgradeStrategy" gorm:"column:upgradeStrategy;serializer:json"`
+	GroupStrategy         GroupStrategy   `json:"groupStrategy" gorm:"column:groupStrategy;serializer:json"`
+	InhibitRules          []InhibitRule   `json:"inhibitRules" gorm:"column:inhibitRules;serializer:json"`
+	TicketConfig          TicketConfig    `json:"ticketConfig" gorm:"column:ticketConfig;serializer:json"`
+}
+
+// ConfigVersion 故障中心没有更新时间, 使用配置内容的摘要判断配置是否变更
+func (f FaultCenter) ConfigVersion() string {
+	return tools.Md5Hash(tools.JsonMarshalToByte(f))
 }
 
 func (f *FaultCenter) GetRepeatNoticeInterval(level string) int {
@@ -42,10 +51,137 @@ func (f *FaultCenter) GetRepeatNoticeInterval(level string) int {
 }
 
 type UpgradeStrategy struct {
-	Enabled        *bool  `json:"enabled"`        // 是否启用告警升级
-	Timeout        int64  `json:"timeout"`        // 超时时间
-	RepeatInterval int64  `json:"repeatInterval"` // 重复通知间隔时间
-	NoticeId       string `json:"noticeId"`       // 通知对象ID
+	Enabled        *bool          `json:"enabled"`        // 是否启用告警升级
+	Timeout        int64          `json:"timeout"`        // 超时时间
+	RepeatInterval int64          `json:"repeatInterval"` // 重复通知间隔时间
+	NoticeId       string         `json:"noticeId"`       // 通知对象ID
+	Levels         []UpgradeLevel `json:"levels"`         // 多级升级, 未配置时使用上述单级策略
+	Acked          *UpgradeLevel  `json:"acked"`          // 已认领但未恢复阶段, 超时时间从认领时间开始计算
+}
+
+// UpgradeLevel 升级级别, 通知对象与值班表二选一
+type UpgradeLevel struct {
+	Name           string   `json:"name"`           // 级别名称, 如: 一线值班、二线值班、负责人
+	Timeout        int64    `json:"timeout"`        // 超时时间, 单位（分钟）
+	RepeatInterval int64    `json:"repeatInterval"` // 当前级别的重复通知间隔, 单位（分钟）
+	NoticeId       string   `json:"noticeId"`       // 通知对象ID
+	DutyId         string   `json:"dutyId"`         // 值班表ID, 直接通知当前值班人员
+	NoticeTypes    []string `json:"noticeTypes"`    // 通知值班人员的方式, 支持 Email、SMS、Phone
+	NoticeTmplId   string   `json:"noticeTmplId"`   // 邮件通知使用的模版
+}
+
+// GetLevels 获取按超时时间排序的升级级别
+func (u *UpgradeStrategy) GetLevels() []UpgradeLevel {
+	if len(u.Levels) == 0 {
+		return []UpgradeLevel{{
+			Name:           "L1",
+			Timeout:        u.Timeout,
+			RepeatInterval: u.RepeatInterval,
+			NoticeId:       u.NoticeId,
+		}}
+	}
+
+	levels := slices.Clone(u.Levels)
+	slices.SortStableFunc(levels, func(a, b UpgradeLevel) int {
+		return int(a.Timeout - b.Timeout)
+	})
+	return levels
+}
+
+// 告警聚合类型
+const (
+	AggregationTypeRule   = "Rule"   // 按规则聚合
+	AggregationTypeLabels = "Labels" // 按标签分组
+)
+
+// GroupStrategy 标签分组策略, 语义与 Alertmanager 的 group_by、group_wait、group_interval、repeat_interval 一致
+type GroupStrategy struct {
+	GroupBy        []string `json:"groupBy"`        // 分组标签, "..." 表示按全部标签分组
+	GroupWait      int64    `json:"groupWait"`      // 新分组首次通知前的等待时间, 单位（秒）
+	GroupInterval  int64    `json:"groupInterval"`  // 分组内事件变化后的通知间隔, 单位（秒）
+	RepeatInterval int64    `json:"repeatInterval"` // 分组无变化时的重复通知间隔, 单位（秒）
+}
+
+func (g GroupStrategy) GetGroupWait() int64 {
+	if g.GroupWait <= 0 {
+		return 30
+	}
+	return g.GroupWait
+}
+
+func (g GroupStrategy) GetGroupInterval() int64 {
+	if g.GroupInterval <= 0 {
+		return 300
+	}
+	return g.GroupInterval
+}
+
+func (g GroupStrategy) GetRepeatInterval() int64 {
+	if g.RepeatInterval <= 0 {
+		return 14400
+	}
+	return g.RepeatInterval
+}
+
+// InhibitRule 抑制规则, 存在匹配源条件的告警时, 抑制匹配目标条件且 equal 标签值相同的告警
+type InhibitRule struct {
+	SourceMatchers []NoticeLabels `json:"sourceMatchers"`
+	TargetMatchers []NoticeLabels `json:"targetMatchers"`
+	Equal          []string       `json:"equal"`
+}
+
+const (
+	TicketTypeJira = "Jira"
+	TicketTypeHttp = "Http"
+)
+
+// TicketConfig 工单配置, 满足告警等级及持续时长条件的事件自动创建工单, 恢复时流转工单状态
+type TicketConfig struct {
+	Enabled   *bool            `json:"enabled"`
+	Type      string           `json:"type"`      // Jira, Http
+	Severitys []string         `json:"severitys"` // 创建工单的告警等级, 为空时不限制
+	Duration  int64            `json:"duration"`  // 告警持续时长, 单位（分钟）
+	Jira      JiraTicketConfig `json:"jira"`
+	Http      HttpTicketConfig `json:"http"`
+}
+
+func (t TicketConfig) GetEnabled() bool {
+	return t.Enabled != nil && *t.Enabled
+}
+
+// IsMatched 判断事件是否满足创建工单的条件
+func (t TicketConfig) IsMatched(event AlertCurEvent, now int64) bool {
+	if len(t.Severitys) > 0 && !slices.Contains(t.Severitys, event.Severity) {
+		return false
+	}
+	return event.FirstTriggerTime > 0 && now-event.FirstTriggerTime >= t.Duration*60
+}
+
+// JiraTicketConfig Jira 工单, 未配置用户名时使用 Token 作为 Bearer 认证 (Personal Access Token)
+type JiraTicketConfig struct {
+	Url        string `json:"url"`
+	Username   string `json:"username"`
+	Token      string `json:"token"`
+	ProjectKey string `json:"projectKey"`
+	IssueType  string `json:"issueType"`
+	Transition string `json:"transition"` // 恢复时执行的流转, 如 Done
+}
+
+// HttpTicketConfig 通用工单接口, 请求体为模版, 可引用 .Event、.Key、.Comment、.Username
+type HttpTicketConfig struct {
+	Create  TicketRequest `json:"create"`
+	Comment TicketRequest `json:"comment"`
+	Resolve TicketRequest `json:"resolve"`
+	// KeyPath 创建接口响应中工单编号的字段路径, 如 key、data.id
+	KeyPath string `json:"keyPath"`
+}
+
+// TicketRequest 工单接口请求, Url 同样支持模版
+type TicketRequest struct {
+	Method  string            `json:"method"`
+	Url     string            `json:"url"`
+	Headers map[string]string `json:"headers"`
+	Body    string            `json:"body"`
 }
 
 type NoticeRoute struct {
@@ -106,6 +242,11 @@ func (f *FaultCenter) GetAlarmAggregationType() string {
 	return f.AggregationType
 }
 
+// IsLabelGrouping 是否按标签分组发送通知
+func (f *FaultCenter) IsLabelGrouping() bool {
+	return f.AggregationType == AggregationTypeLabels
+}
+
 type AlertEventCacheKey string
 
 func BuildAlertEventCacheKey(tenantId, faultCenterId string) AlertEventCacheKey {
@@ -123,3 +264,9 @@ type FaultCenterInfoCacheKey string
 func BuildFaultCenterInfoCacheKey(tenantId, faultCenterId string) FaultCenterInfoCacheKey {
 	return FaultCenterInfoCacheKey(fmt.Sprintf("w8t:%s:%s:%s.info", tenantId, FaultCenterPrefix, faultCenterId))
 }
+
+type AlertGroupCacheKey string
+
+func BuildAlertGroupCacheKey(tenantId, faultCenterId string) AlertGroupCacheKey {
+	return AlertGroupCacheKey(fmt.Sprintf("w8t:%s:%s:%s.groups", tenantId, FaultCenterPrefix, faultCenterId))
+}
@@ -6,8 +6,10 @@ type AlertNotice struct {
 	Name     string  `json:"name"`
 	DutyId   *string `json:"dutyId"`
 	Routes   []Route `json:"routes" gorm:"column:routes;serializer:json"`
-	UpdateAt int64   `json:"updateAt"`
-	UpdateBy string  `json:"updateBy"`
+	// 通知限流
+	RateLimit NoticeRateLimit `json:"rateLimit" gorm:"column:rate_limit;serializer:json"`
+	UpdateAt  int64           `json:"updateAt"`
+	UpdateBy  string          `json:"updateBy"`
 }
 
 func (alertNotice *AlertNotice) GetDutyId() *string {
@@ -17,6 +19,26 @@ func (alertNotice *AlertNotice) GetDutyId() *string {
 	return alertNotice.DutyId
 }
 
+// NoticeRateLimit 通知限流, 超过限制的通知合并为摘要消息发送
+type NoticeRateLimit struct {
+	Enable *bool `json:"enable"`
+	// 通知对象每分钟最大发送条数, 0 为不限制
+	MaxPerMinute int `json:"maxPerMinute"`
+	// 各通知类型每分钟最大发送条数, 如 {"Phone": 5}
+	MediumLimits map[string]int `json:"mediumLimits"`
+	// 触发限流时是否向值班人员发送告警风暴通知
+	StormNotice bool `json:"stormNotice"`
+}
+
+func (l NoticeRateLimit) GetEnable() bool {
+	return l.Enable != nil && *l.Enable
+}
+
+// GetMediumLimit 获取通知类型每分钟最大发送条数, 0 为不限制
+func (l NoticeRateLimit) GetMediumLimit(noticeType string) int {
+	return l.MediumLimits[noticeType]
+}
+
 type Route struct {
 	// 通知类型
 	NoticeType string `json:"noticeType"`
@@ -24,18 +46,36 @@ type Route struct {
 	NoticeTmplId string `json:"noticeTmplId"`
 	// 告警等级
 	Severitys []string `json:"severitys"`
-	// WebHook
+	// WebHook, Telegram 为 Bot Token, PagerDuty、Opsgenie 为 API 地址, 为空时使用默认地址
 	Hook string `json:"hook"`
-	// 签名
+	// 签名, PagerDuty 为 Routing Key, Opsgenie 为 API Key
 	Sign string `json:"sign"`
 	// 邮件主题
 	Subject string `json:"subject"`
-	// 收件人
+	// 收件人, Telegram 为 Chat ID
 	To []string `json:"to" gorm:"column:to;serializer:json"`
 	// 抄送人
 	CC []string `json:"cc" gorm:"column:cc;serializer:json"`
 	// 生效时间
 	EffectiveTime EffectiveTime `json:"effectiveTime"`
+	// 自定义 WebHook
+	WebHook WebHookConfig `json:"webhook"`
+}
+
+// WebHookConfig 自定义 WebHook 请求, 配置签名时通过 X-W8t-Signature 请求头携带 HMAC-SHA256 签名
+type WebHookConfig struct {
+	// 请求体模版, 为空时发送默认的 JSON 内容, 可引用 .Alarm、.DutyUsers、.History
+	BodyTemplate string            `json:"bodyTemplate"`
+	Headers      map[string]string `json:"headers"`
+	// 认证方式: basic, bearer
+	AuthType string `json:"authType"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Token    string `json:"token"`
+	// 不携带值班人员信息
+	ExcludeDutyUsers bool `json:"excludeDutyUsers"`
+	// 携带该事件最近的历史记录
+	IncludeHistory bool `json:"includeHistory"`
 }
 
 type Email struct {
@@ -52,6 +92,11 @@ type Phone struct {
 	To []string `json:"to" gorm:"column:to;serializer:json"`
 }
 
+// Telegram 接收消息的会话, 可为群组、频道或用户的 Chat ID
+type Telegram struct {
+	To []string `json:"to" gorm:"column:to;serializer:json"`
+}
+
 type NoticeRecord struct {
 	EventId  string `json:"eventId"`  // 事件ID
 	Date     string `json:"date"`     // 记录日期
@@ -61,15 +106,56 @@ type NoticeRecord struct {
 	NType    string `json:"nType"`    // 通知类型
 	NObj     string `json:"nObj"`     // 通知对象
 	Severity string `json:"severity"` // 告警等级
-	Status   int    `json:"status"`   // 通知状态 0 成功 1 失败
+	Status   int    `json:"status"`   // 通知状态 0 成功 1 失败 2 限流抑制
 	AlarmMsg string `json:"alarmMsg"` // 告警信息
 	ErrMsg   string `json:"errMsg"`   // 错误信息
+	Retries  int    `json:"retries"`  // 重试次数
+}
+
+const NoticeRecordSuppressed = 2
+
+const (
+	NoticeTaskPending = "pending"
+	NoticeTaskDead    = "dead"
+)
+
+// NoticeTask 通知发送任务, 发送失败后按通知类型的重试策略退避重试, 超过最大重试次数后进入死信
+type NoticeTask struct {
+	TenantId   string `json:"tenantId"`
+	ID         string `json:"id"`
+	EventId    string `json:"eventId"`
+	RuleName   string `json:"ruleName"`
+	Severity   string `json:"severity"`
+	NoticeType string `json:"noticeType"`
+	NoticeId   string `json:"noticeId"`
+	NoticeName string `json:"noticeName"`
+	// IsRecovered 是否为恢复通知
+	IsRecovered bool `json:"isRecovered"`
+	// Params 发送参数的 JSON
+	Params      string `json:"params"`
+	Status      string `json:"status"`
+	Retries     int    `json:"retries"`
+	NextRetryAt int64  `json:"nextRetryAt"`
+	LastError   string `json:"lastError"`
+	CreateAt    int64  `json:"createAt"`
+	UpdateAt    int64  `json:"updateAt"`
+}
+
+func (NoticeTask) TableName() string {
+	return "w8t_notice_tasks"
+}
+
+type ResponseNoticeTasks struct {
+	List []NoticeTask `json:"list"`
+	Page
 }
 
 type CountRecord struct {
 	Date     string `json:"date"`     // 记录日期
 	TenantId string `json:"tenantId"` // 租户
 	Severity string `json:"severity"` // 告警等级
+	// 仅统计限流抑制的记录, 否则统计实际发送的记录
+	Suppressed bool `json:"suppressed"`
 }
 
 type ResponseNoticeRecords struct {
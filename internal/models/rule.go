@@ -44,12 +44,58 @@ type AlertRule struct {
 
 	LogEvalCondition string `json:"logEvalCondition" gorm:"logEvalCondition;serializer:json"`
 
+	// 无数据处理策略, 仅 Prometheus 及日志类规则生效
+	NoDataConfig NoDataConfig `json:"noDataConfig" gorm:"noDataConfig;serializer:json"`
+
 	FaultCenterId string `json:"faultCenterId"`
 	UpdateAt      int64  `json:"updateAt"`
 	UpdateBy      string `json:"updateBy"`
 	Enabled       *bool  `json:"enabled" gorm:"enabled"`
 }
 
+const (
+	// NoDataResolve 视为恢复, 默认策略
+	NoDataResolve = "Resolve"
+	// NoDataKeepLast 保持告警事件的当前状态
+	NoDataKeepLast = "KeepLast"
+	// NoDataAlerting 保持告警事件的当前状态, 并产生无数据告警
+	NoDataAlerting = "Alerting"
+)
+
+// NoDataConfig 查询无数据或数据源不可用时的处理策略
+type NoDataConfig struct {
+	Policy string `json:"policy"`
+	// 无数据告警的等级, 默认为规则中的最高等级
+	Severity string `json:"severity"`
+	// 检测消失的序列, 之前出现过的序列不再返回时按策略处理, 仅 Prometheus 规则生效
+	AbsentDetection bool `json:"absentDetection"`
+	// 序列消失超过该时间（秒）后不再检测, 默认 86400
+	AbsentRetention int64 `json:"absentRetention"`
+}
+
+func (n NoDataConfig) GetPolicy() string {
+	if n.Policy == "" {
+		return NoDataResolve
+	}
+	return n.Policy
+}
+
+func (n NoDataConfig) Validate() error {
+	switch n.Policy {
+	case "", NoDataResolve, NoDataKeepLast, NoDataAlerting:
+		return nil
+	default:
+		return fmt.Errorf("unsupported NoData policy: %s", n.Policy)
+	}
+}
+
+func (n NoDataConfig) GetAbsentRetention() int64 {
+	if n.AbsentRetention <= 0 {
+		return 86400
+	}
+	return n.AbsentRetention
+}
+
 type ElasticSearchConfig struct {
 	Index           string            `json:"index"`
 	Scope           int64             `json:"scope"`
@@ -186,5 +232,5 @@ func (t *AlertRule) Validate() error {
 	if t.EvalInterval < 5 {
 		return fmt.Errorf("EvalInterval must be greater than 5")
 	}
-	return nil
+	return t.NoDataConfig.Validate()
 }
@@ -0,0 +1,33 @@
+package models
+
+// RuleBacktestSeries 回测结果, 按数据源、序列及告警等级分组
+type RuleBacktestSeries struct {
+	DatasourceId string                 `json:"datasourceId"`
+	Fingerprint  string                 `json:"fingerprint"`
+	Labels       map[string]interface{} `json:"labels"`
+	Severity     string                 `json:"severity"`
+	Intervals    []RuleBacktestInterval `json:"intervals"`
+}
+
+// RuleBacktestInterval 模拟的一次告警, 时间均为秒级时间戳
+type RuleBacktestInterval struct {
+	// 首次满足告警条件的时间
+	ActiveAt int64 `json:"activeAt"`
+	// 达到持续时间后触发告警的时间
+	FiringAt int64 `json:"firingAt"`
+	// 告警恢复的时间, 回测结束时仍在告警中则为 0
+	ResolvedAt int64   `json:"resolvedAt"`
+	FirstValue float64 `json:"firstValue"`
+	LastValue  float64 `json:"lastValue"`
+}
+
+// RuleBacktestSummary 回测结果汇总
+type RuleBacktestSummary struct {
+	StartTime    int64                `json:"startTime"`
+	EndTime      int64                `json:"endTime"`
+	EvalInterval int64                `json:"evalInterval"`
+	Total        int                  `json:"total"`
+	Severitys    map[string]int       `json:"severitys"`
+	Series       []RuleBacktestSeries `json:"series"`
+	Errors       map[string]string    `json:"errors,omitempty"`
+}
@@ -0,0 +1,60 @@
+package models
+
+// RuleTestFile 告警规则单元测试文件, 格式参照 promtool test rules, 支持 YAML 及 JSON
+type RuleTestFile struct {
+	// 规则文件路径, 相对于测试文件所在目录, 内容为 WatchAlert 导出的规则
+	RuleFiles []string `json:"rule_files"`
+	// 内联的规则
+	Rules []AlertRule `json:"rules"`
+	// 规则未配置评估周期时使用, 默认 1m
+	EvaluationInterval string `json:"evaluation_interval"`
+	// 模拟数据源的外部标签
+	ExternalLabels map[string]interface{} `json:"external_labels"`
+	Tests          []RuleTestGroup        `json:"tests"`
+}
+
+type RuleTestGroup struct {
+	Name string `json:"name"`
+	// 相邻样本的时间间隔, 默认与 EvaluationInterval 一致
+	Interval       string           `json:"interval"`
+	InputSeries    []RuleTestSeries `json:"input_series"`
+	AlertRuleTests []RuleTestAlert  `json:"alert_rule_test"`
+}
+
+// RuleTestSeries 输入序列, 语法与 promtool 一致, 如 series: 'up{job="node"}', values: '1 1 0x10 _ stale'
+type RuleTestSeries struct {
+	Series string `json:"series"`
+	Values string `json:"values"`
+}
+
+type RuleTestAlert struct {
+	// 评估时间, 相对于第一个样本, 如 10m
+	EvalTime string `json:"eval_time"`
+	// 规则名称
+	AlertName string             `json:"alertname"`
+	ExpAlerts []RuleTestExpAlert `json:"exp_alerts"`
+}
+
+// RuleTestExpAlert 期望的告警, 标签不包含 fingerprint、value、first_value、rule_name 等内置标签
+type RuleTestExpAlert struct {
+	ExpLabels      map[string]string `json:"exp_labels"`
+	ExpAnnotations string            `json:"exp_annotations"`
+}
+
+// RuleTestResult 单元测试结果
+type RuleTestResult struct {
+	Success bool                 `json:"success"`
+	Total   int                  `json:"total"`
+	Failed  int                  `json:"failed"`
+	Cases   []RuleTestCaseResult `json:"cases"`
+}
+
+type RuleTestCaseResult struct {
+	Group     string             `json:"group"`
+	AlertName string             `json:"alertname"`
+	EvalTime  string             `json:"eval_time"`
+	Success   bool               `json:"success"`
+	Error     string             `json:"error,omitempty"`
+	ExpAlerts []RuleTestExpAlert `json:"exp_alerts"`
+	GotAlerts []RuleTestExpAlert `json:"got_alerts"`
+}
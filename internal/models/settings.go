@@ -3,6 +3,7 @@ package models
 import (
 	"encoding/json"
 	"strconv"
+	"time"
 )
 
 const (
@@ -19,6 +20,7 @@ type Settings struct {
 	AiConfig            AiConfig            `json:"aiConfig" gorm:"aiConfig;serializer:json"`
 	LdapConfig          LdapConfig          `json:"ldapConfig" gorm:"ldapConfig;serializer:json"`
 	OidcConfig          OidcConfig          `json:"oidcConfig" gorm:"oidcConfig;serializer:json"`
+	ChatOpsConfig       ChatOpsConfig       `json:"chatOpsConfig" gorm:"chatOpsConfig;serializer:json"`
 }
 
 type communicationConfig struct {
@@ -141,6 +143,75 @@ type OidcConfig struct {
 	Domain       string `json:"domain"`
 }
 
+// ChatOpsConfig IM 卡片交互配置
+type ChatOpsConfig struct {
+	Enable *bool `json:"enable"`
+	// SiteUrl WatchAlert 的访问地址, 用于卡片中的查看详情
+	SiteUrl  string                `json:"siteUrl"`
+	FeiShu   feiShuChatOpsConfig   `json:"feishu"`
+	DingDing dingDingChatOpsConfig `json:"dingding"`
+	Slack    slackChatOpsConfig    `json:"slack"`
+	AckLink  AckLinkConfig         `json:"ackLink"`
+	// PagerDuty、Opsgenie 的认领回调
+	PagerDuty pagerDutyChatOpsConfig `json:"pagerduty"`
+	Opsgenie  opsgenieChatOpsConfig  `json:"opsgenie"`
+}
+
+// AckLinkConfig 邮件、短信通知中的一键认领链接
+type AckLinkConfig struct {
+	Enable *bool `json:"enable"`
+	// Expire 链接有效期, 单位分钟
+	Expire int `json:"expire"`
+}
+
+type feiShuChatOpsConfig struct {
+	EncryptKey        string `json:"encryptKey"`
+	VerificationToken string `json:"verificationToken"`
+}
+
+type dingDingChatOpsConfig struct {
+	AppSecret string `json:"appSecret"`
+}
+
+type slackChatOpsConfig struct {
+	SigningSecret string `json:"signingSecret"`
+}
+
+type pagerDutyChatOpsConfig struct {
+	// WebhookSecret Webhook V3 订阅的签名密钥
+	WebhookSecret string `json:"webhookSecret"`
+}
+
+type opsgenieChatOpsConfig struct {
+	// WebhookToken Opsgenie Webhook 集成中配置的 X-W8t-Token 请求头
+	WebhookToken string `json:"webhookToken"`
+}
+
+func (c ChatOpsConfig) GetEnable() bool {
+	if c.Enable == nil {
+		return false
+	}
+
+	return *c.Enable
+}
+
+func (a AckLinkConfig) GetEnable() bool {
+	if a.Enable == nil {
+		return false
+	}
+
+	return *a.Enable
+}
+
+// GetExpire 链接有效期, 默认 60 分钟
+func (a AckLinkConfig) GetExpire() time.Duration {
+	if a.Expire <= 0 {
+		return time.Hour
+	}
+
+	return time.Duration(a.Expire) * time.Minute
+}
+
 func (a AiConfig) GetEnable() bool {
 	if a.Enable == nil {
 		return false
@@ -1,16 +1,73 @@
 package models
 
+import "strings"
+
 type AlertSubscribe struct {
-	SId               string   `json:"sId"`                                                // 订阅规则ID
-	STenantId         string   `json:"sTenantId"`                                          // 订阅规则的租户
-	SUserId           string   `json:"sUserId"`                                            // 订阅的用户 ID
-	SUserEmail        string   `json:"sUserEmail"`                                         // 订阅的用户邮箱
-	SRuleId           string   `json:"sRuleId"`                                            // 订阅的规则 ID
-	SRuleName         string   `json:"sRuleName"`                                          // 订阅的规则名称
-	SRuleType         string   `json:"sRuleType"`                                          // 订阅的规则类型
-	SRuleSeverity     []string `json:"sRuleSeverity" gorm:"sRuleSeverity;serializer:json"` // 订阅的告警等级
-	SNoticeSubject    string   `json:"sNoticeSubject"`                                     // 发布订阅消息的 Title
-	SNoticeTemplateId string   `json:"sNoticeTemplateId"`                                  // 发送订阅消息的通知模版 ID
-	SFilter           []string `json:"sFilter" gorm:"sFilter;serializer:json"`             // 过滤
-	SCreateAt         int64    `json:"sCreateAt"`
+	SId               string           `json:"sId"`                                                // 订阅规则ID
+	STenantId         string           `json:"sTenantId"`                                          // 订阅规则的租户
+	SUserId           string           `json:"sUserId"`                                            // 订阅的用户 ID
+	SUserEmail        string           `json:"sUserEmail"`                                         // 订阅的用户邮箱
+	SRuleId           string           `json:"sRuleId"`                                            // 订阅的规则 ID
+	SRuleName         string           `json:"sRuleName"`                                          // 订阅的规则名称
+	SRuleType         string           `json:"sRuleType"`                                          // 订阅的规则类型
+	SRuleSeverity     []string         `json:"sRuleSeverity" gorm:"sRuleSeverity;serializer:json"` // 订阅的告警等级
+	SNoticeSubject    string           `json:"sNoticeSubject"`                                     // 发布订阅消息的 Title
+	SNoticeTemplateId string           `json:"sNoticeTemplateId"`                                  // 发送订阅消息的通知模版 ID
+	SNoticeRoutes     []SubscribeRoute `json:"sNoticeRoutes" gorm:"sNoticeRoutes;serializer:json"` // 邮件以外的通知方式
+	SFilter           []string         `json:"sFilter" gorm:"sFilter;serializer:json"`             // 过滤
+	SCreateAt         int64            `json:"sCreateAt"`
+}
+
+// SubscribeRoute 订阅的通知方式
+type SubscribeRoute struct {
+	NoticeType   string `json:"noticeType"`
+	NoticeTmplId string `json:"noticeTmplId"` // 为空时使用订阅的通知模版
+	Hook         string `json:"hook"`
+	Sign         string `json:"sign"`
+}
+
+// GetNoticeRoutes 获取订阅的通知路由, 配置了邮箱时默认通过邮件发送
+func (s AlertSubscribe) GetNoticeRoutes() []Route {
+	var routes []Route
+	if s.SUserEmail != "" {
+		routes = append(routes, Route{
+			NoticeType:   "Email",
+			NoticeTmplId: s.SNoticeTemplateId,
+			Subject:      s.SNoticeSubject,
+			To:           []string{s.SUserEmail},
+		})
+	}
+
+	for _, r := range s.SNoticeRoutes {
+		tmplId := r.NoticeTmplId
+		if tmplId == "" {
+			tmplId = s.SNoticeTemplateId
+		}
+		routes = append(routes, Route{
+			NoticeType:   r.NoticeType,
+			NoticeTmplId: tmplId,
+			Hook:         r.Hook,
+			Sign:         r.Sign,
+			Subject:      s.SNoticeSubject,
+		})
+	}
+
+	return routes
+}
+
+// ParseSubscribeFilter 解析订阅过滤条件, 支持 key=value、key!=value、key=~regex、key!~regex
+func ParseSubscribeFilter(filter string) (NoticeLabels, bool) {
+	for _, op := range []string{"=~", "!~", "!=", "="} {
+		idx := strings.Index(filter, op)
+		if idx <= 0 {
+			continue
+		}
+		return NoticeLabels{
+			Key:      strings.TrimSpace(filter[:idx]),
+			Operator: op,
+			Value:    strings.TrimSpace(filter[idx+len(op):]),
+		}, true
+	}
+
+	return NoticeLabels{}, false
 }
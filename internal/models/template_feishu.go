@@ -66,6 +66,16 @@ type Elements struct {
 	Text           Texts              `json:"text"`
 	Columns        []Columns          `json:"columns"`
 	Elements       []ElementsElements `json:"elements"`
+	Actions        []Buttons          `json:"actions,omitempty"`
+}
+
+// Buttons 卡片交互按钮, 回传交互使用 Value, 跳转链接使用 URL
+type Buttons struct {
+	Tag   string      `json:"tag"`
+	Text  ActionsText `json:"text"`
+	Type  string      `json:"type"`
+	Value interface{} `json:"value,omitempty"`
+	URL   string      `json:"url,omitempty"`
 }
 
 type ElementsElements struct {
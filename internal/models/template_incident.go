@@ -0,0 +1,55 @@
+package models
+
+// PagerDutyEvent PagerDuty Events API v2 事件, 发送时填充 routing_key
+type PagerDutyEvent struct {
+	RoutingKey  string            `json:"routing_key,omitempty"`
+	EventAction string            `json:"event_action"`
+	DedupKey    string            `json:"dedup_key"`
+	Payload     *PagerDutyPayload `json:"payload,omitempty"`
+	Client      string            `json:"client,omitempty"`
+	ClientUrl   string            `json:"client_url,omitempty"`
+}
+
+type PagerDutyPayload struct {
+	Summary       string                 `json:"summary"`
+	Source        string                 `json:"source"`
+	Severity      string                 `json:"severity"`
+	Timestamp     string                 `json:"timestamp,omitempty"`
+	Group         string                 `json:"group,omitempty"`
+	Class         string                 `json:"class,omitempty"`
+	CustomDetails map[string]interface{} `json:"custom_details,omitempty"`
+}
+
+// OpsgenieAlert Opsgenie Alert API 告警, 恢复时按 alias 关闭
+type OpsgenieAlert struct {
+	Message     string            `json:"message,omitempty"`
+	Alias       string            `json:"alias"`
+	Description string            `json:"description,omitempty"`
+	Priority    string            `json:"priority,omitempty"`
+	Source      string            `json:"source,omitempty"`
+	Tags        []string          `json:"tags,omitempty"`
+	Details     map[string]string `json:"details,omitempty"`
+	Note        string            `json:"note,omitempty"`
+}
+
+// 告警等级映射, 未匹配的等级使用最低级别
+var (
+	pagerDutySeverities = map[string]string{"P0": "critical", "P1": "error", "P2": "warning"}
+	opsgeniePriorities  = map[string]string{"P0": "P1", "P1": "P2", "P2": "P3"}
+)
+
+// GetPagerDutySeverity 获取告警等级对应的 PagerDuty 严重程度
+func GetPagerDutySeverity(severity string) string {
+	if s, ok := pagerDutySeverities[severity]; ok {
+		return s
+	}
+	return "info"
+}
+
+// GetOpsgeniePriority 获取告警等级对应的 Opsgenie 优先级
+func GetOpsgeniePriority(severity string) string {
+	if p, ok := opsgeniePriorities[severity]; ok {
+		return p
+	}
+	return "P5"
+}
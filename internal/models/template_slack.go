@@ -1,5 +1,6 @@
 package models
 
 type SlackMsgTemplate struct {
-	Text string `json:"text"`
+	Text   string                   `json:"text"`
+	Blocks []map[string]interface{} `json:"blocks,omitempty"`
 }
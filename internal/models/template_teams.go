@@ -0,0 +1,22 @@
+package models
+
+// TeamsMsgTemplate Teams 传入 Webhook 及工作流的 Adaptive Card 消息
+type TeamsMsgTemplate struct {
+	Type        string            `json:"type"`
+	Attachments []TeamsAttachment `json:"attachments"`
+}
+
+type TeamsAttachment struct {
+	ContentType string            `json:"contentType"`
+	ContentUrl  *string           `json:"contentUrl"`
+	Content     TeamsAdaptiveCard `json:"content"`
+}
+
+type TeamsAdaptiveCard struct {
+	Schema  string                   `json:"$schema"`
+	Type    string                   `json:"type"`
+	Version string                   `json:"version"`
+	Body    []map[string]interface{} `json:"body"`
+	Actions []map[string]interface{} `json:"actions,omitempty"`
+	MsTeams map[string]interface{}   `json:"msteams,omitempty"`
+}
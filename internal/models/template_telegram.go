@@ -0,0 +1,10 @@
+package models
+
+// TelegramMsgTemplate Telegram Bot API sendMessage 消息, 发送时按会话填充 chat_id
+type TelegramMsgTemplate struct {
+	ChatId                string                 `json:"chat_id,omitempty"`
+	Text                  string                 `json:"text"`
+	ParseMode             string                 `json:"parse_mode,omitempty"`
+	DisableWebPagePreview bool                   `json:"disable_web_page_preview,omitempty"`
+	ReplyMarkup           map[string]interface{} `json:"reply_markup,omitempty"`
+}
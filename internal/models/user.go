@@ -1,5 +1,11 @@
 package models
 
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
 type Member struct {
 	UserId     string   `json:"userid"`
 	UserName   string   `json:"username"`
@@ -12,6 +18,125 @@ type Member struct {
 	JoinDuty   string   `json:"joinDuty" `
 	DutyUserId string   `json:"dutyUserId"`
 	Tenants    []string `json:"tenants" gorm:"tenants;serializer:json"`
+	// 通知偏好, 未配置时按通知对象的路由通知
+	ContactMethods    ContactMethods     `json:"contactMethods" gorm:"contactMethods;serializer:json"`
+	NotifyPreferences []NotifyPreference `json:"notifyPreferences" gorm:"notifyPreferences;serializer:json"`
+	QuietHours        QuietHours         `json:"quietHours" gorm:"quietHours;serializer:json"`
+}
+
+// ContactMethods 联系方式, 为空时使用用户的邮箱、手机号及 DutyUserId
+type ContactMethods struct {
+	Email    string `json:"email"`
+	Phone    string `json:"phone"`
+	SMS      string `json:"sms"`
+	FeiShu   string `json:"feishu"`
+	DingDing string `json:"dingding"`
+	Slack    string `json:"slack"`
+	WeChat   string `json:"wechat"`
+	Teams    string `json:"teams"`    // Teams 用户的 UPN, 通常为邮箱
+	Telegram string `json:"telegram"` // Telegram 用户 ID 或用户名
+}
+
+// NotifyPreference 按告警等级的通知方式
+// 如 P0 使用 Phone、SMS 且免打扰时段同样通知; P2 在免打扰时段（非工作时间）仅通过 Email 通知
+type NotifyPreference struct {
+	Severity      string   `json:"severity"`
+	Channels      []string `json:"channels"`      // 通知方式: Email、Phone、SMS、FeiShu、DingDing、Slack、WeChat、Teams、Telegram
+	QuietChannels []string `json:"quietChannels"` // 免打扰时段内的通知方式, 为空时免打扰时段内不通知
+}
+
+// QuietHours 免打扰时段, 即非工作时间, 支持跨天如 19:00 - 09:00
+type QuietHours struct {
+	Enabled  bool   `json:"enabled"`
+	Start    string `json:"start"`
+	End      string `json:"end"`
+	Timezone string `json:"timezone"`
+	Weekends bool   `json:"weekends"` // 周末全天免打扰
+}
+
+// GetContact 获取通知方式对应的联系方式
+func (m Member) GetContact(channel string) string {
+	var contact, fallback string
+	switch channel {
+	case "Email":
+		contact, fallback = m.ContactMethods.Email, m.Email
+	case "Phone":
+		contact, fallback = m.ContactMethods.Phone, m.Phone
+	case "SMS":
+		contact, fallback = m.ContactMethods.SMS, m.GetContact("Phone")
+	case "FeiShu":
+		contact, fallback = m.ContactMethods.FeiShu, m.DutyUserId
+	case "DingDing":
+		contact, fallback = m.ContactMethods.DingDing, m.DutyUserId
+	case "Slack":
+		contact, fallback = m.ContactMethods.Slack, m.DutyUserId
+	case "WeChat":
+		contact, fallback = m.ContactMethods.WeChat, m.UserName
+	case "Teams":
+		contact, fallback = m.ContactMethods.Teams, m.GetContact("Email")
+	case "Telegram":
+		contact, fallback = m.ContactMethods.Telegram, m.DutyUserId
+	}
+
+	if contact != "" {
+		return contact
+	}
+	return fallback
+}
+
+// GetNotifyChannels 获取告警等级在指定时间的通知方式, 未配置该等级的偏好时返回 false
+func (m Member) GetNotifyChannels(severity string, at time.Time) ([]string, bool) {
+	idx := slices.IndexFunc(m.NotifyPreferences, func(p NotifyPreference) bool {
+		return p.Severity == severity
+	})
+	if idx < 0 {
+		return nil, false
+	}
+
+	preference := m.NotifyPreferences[idx]
+	if m.QuietHours.IsQuiet(at) {
+		return preference.QuietChannels, true
+	}
+	return preference.Channels, true
+}
+
+// IsQuiet 判断是否处于免打扰时段
+func (q QuietHours) IsQuiet(at time.Time) bool {
+	if !q.Enabled {
+		return false
+	}
+
+	if q.Timezone != "" {
+		if loc, err := time.LoadLocation(q.Timezone); err == nil {
+			at = at.In(loc)
+		}
+	}
+
+	if q.Weekends && (at.Weekday() == time.Saturday || at.Weekday() == time.Sunday) {
+		return true
+	}
+
+	start, ok1 := parseClock(q.Start)
+	end, ok2 := parseClock(q.End)
+	if !ok1 || !ok2 || start == end {
+		return false
+	}
+
+	cur := at.Hour()*60 + at.Minute()
+	if start < end {
+		return cur >= start && cur < end
+	}
+	// 跨天
+	return cur >= start || cur < end
+}
+
+// parseClock 解析 HH:MM 为当天的分钟数
+func parseClock(clock string) (int, bool) {
+	var hour, minute int
+	if _, err := fmt.Sscanf(clock, "%d:%d", &hour, &minute); err != nil {
+		return 0, false
+	}
+	return hour*60 + minute, true
 }
 
 type ResponseLoginInfo struct {
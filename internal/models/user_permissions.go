@@ -23,6 +23,30 @@ func PermissionsInfo() map[string]UserPermissions {
 			Key: "更新值班表",
 			API: "/api/w8t/calendar/calendarUpdate",
 		},
+		"shiftSearch": {
+			Key: "预览值班班次",
+			API: "/api/w8t/calendar/shiftSearch",
+		},
+		"overrideCreate": {
+			Key: "创建替班",
+			API: "/api/w8t/calendar/overrideCreate",
+		},
+		"overrideDelete": {
+			Key: "删除替班",
+			API: "/api/w8t/calendar/overrideDelete",
+		},
+		"icsToken": {
+			Key: "获取值班日历订阅地址",
+			API: "/api/w8t/calendar/icsToken",
+		},
+		"icsTokenReset": {
+			Key: "重置值班日历订阅地址",
+			API: "/api/w8t/calendar/icsTokenReset",
+		},
+		"icsImport": {
+			Key: "导入值班日历",
+			API: "/api/w8t/calendar/icsImport",
+		},
 		"dataSourceCreate": {
 			Key: "创建数据源",
 			API: "/api/w8t/datasource/dataSourceCreate",
@@ -123,6 +147,14 @@ func PermissionsInfo() map[string]UserPermissions {
 			Key: "查看告警规则列表",
 			API: "/api/w8t/rule/ruleList",
 		},
+		"ruleBacktest": {
+			Key: "回测告警规则",
+			API: "/api/w8t/rule/ruleBacktest",
+		},
+		"ruleTest": {
+			Key: "告警规则单元测试",
+			API: "/api/w8t/rule/ruleTest",
+		},
 		"ruleTmplCreate": {
 			Key: "创建规则模版",
 			API: "/api/w8t/ruleTmpl/ruleTmplCreate",
@@ -223,6 +255,18 @@ func PermissionsInfo() map[string]UserPermissions {
 			Key: "查看通知记录列表",
 			API: "/api/w8t/notice/noticeRecordList",
 		},
+		"noticeDeadLetterList": {
+			Key: "查看通知死信列表",
+			API: "/api/w8t/notice/noticeDeadLetterList",
+		},
+		"noticeDeadLetterReplay": {
+			Key: "重新投递通知死信",
+			API: "/api/w8t/notice/noticeDeadLetterReplay",
+		},
+		"noticeDeadLetterDelete": {
+			Key: "删除通知死信",
+			API: "/api/w8t/notice/noticeDeadLetterDelete",
+		},
 		"faultCenterList": {
 			Key: "查看故障中心列表",
 			API: "/api/w8t/faultCenter/faultCenterList",
@@ -124,6 +124,20 @@ func (d DutyRepo) Delete(tenantId, id string) error {
 		return err
 	}
 
+	// 同时清理班次、替班记录及值班表配置
+	for _, table := range []interface{}{models.DutyShift{}, models.DutyOverride{}, models.DutyCalendarInfo{}} {
+		err = d.g.Delete(Delete{
+			Table: table,
+			Where: map[string]interface{}{
+				"tenant_id = ?": tenantId,
+				"duty_id = ?":   id,
+			},
+		})
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
 	"time"
 	"watchAlert/internal/models"
 	"watchAlert/pkg/tools"
@@ -24,11 +25,20 @@ type (
 		GetCalendarInfo(dutyId string) (models.DutyCalendarInfo, error)
 		ListCalendarInfo(tenantId string) ([]models.DutyCalendarInfo, error)
 		GetCalendarData(dutyId, time string) models.DutySchedule
-		GetDutyUserData(dutyId, time string) ([]models.Member, bool)
+		GetDutyUserData(dutyId string, at time.Time) ([]models.Member, bool)
+		GetOnCallUsers(dutyId string, at time.Time) []models.DutyUser
 		Create(r models.DutySchedule) error
 		Update(r models.DutySchedule) error
 		Search(tenantId, dutyId, time string) ([]models.DutySchedule, error)
 		GetCalendarUsers(tenantId, dutyId string) ([][]models.DutyUser, error)
+		CreateShifts(shifts []models.DutyShift) error
+		DeleteShifts(tenantId, dutyId string, from int64) error
+		ReplaceShifts(tenantId, dutyId string, layer int, shifts []models.DutyShift) error
+		ListShifts(tenantId, dutyId string, start, end int64) ([]models.DutyShift, error)
+		GetLastShift(tenantId, dutyId string, layer int) (models.DutyShift, error)
+		CreateOverride(r models.DutyOverride) error
+		DeleteOverride(tenantId, id string) error
+		ListOverrides(tenantId, dutyId string, start, end int64) ([]models.DutyOverride, error)
 	}
 )
 
@@ -65,22 +75,29 @@ func (dc DutyCalendarRepo) ListCalendarInfo(tenantId string) ([]models.DutyCalen
 	return calendarInfos, err
 }
 
-// GetCalendarData 获取值班表数据
+// GetCalendarData 获取值班表指定日期的正式值班数据
 func (dc DutyCalendarRepo) GetCalendarData(dutyId, time string) models.DutySchedule {
 	var dutySchedule models.DutySchedule
 
 	dc.db.Model(models.DutySchedule{}).
-		Where("duty_id = ? AND time = ?", dutyId, time).
+		Where("duty_id = ? AND time = ? AND status = ?", dutyId, time, models.CalendarFormalStatus).
 		First(&dutySchedule)
 
 	return dutySchedule
 }
 
-// GetDutyUserData 获取值班用户数据
-func (dc DutyCalendarRepo) GetDutyUserData(dutyId, time string) ([]models.Member, bool) {
-	var users []models.Member
-	schedule := dc.GetCalendarData(dutyId, time)
-	for _, user := range schedule.Users {
+// GetDutyUserData 获取指定时间点的值班用户数据
+func (dc DutyCalendarRepo) GetDutyUserData(dutyId string, at time.Time) ([]models.Member, bool) {
+	var (
+		users []models.Member
+		seen  = make(map[string]struct{})
+	)
+	for _, user := range dc.GetOnCallUsers(dutyId, at) {
+		if _, ok := seen[user.UserId]; ok {
+			continue
+		}
+		seen[user.UserId] = struct{}{}
+
 		var userData models.Member
 		db := dc.db.Model(models.Member{}).Where("user_id = ?", user.UserId)
 		if err := db.First(&userData).Error; err != nil {
@@ -97,6 +114,50 @@ func (dc DutyCalendarRepo) GetDutyUserData(dutyId, time string) ([]models.Member
 	return users, true
 }
 
+// GetOnCallUsers 获取指定时间点各层级的值班人员, 按层级排序, 替班记录优先于班次
+// 未生成班次的历史值班表按值班表所在时区的日期查询
+func (dc DutyCalendarRepo) GetOnCallUsers(dutyId string, at time.Time) []models.DutyUser {
+	var (
+		ts        = at.Unix()
+		shifts    []models.DutyShift
+		overrides []models.DutyOverride
+		layers    = make(map[int][]models.DutyUser)
+	)
+
+	dc.db.Model(&models.DutyShift{}).
+		Where("duty_id = ? AND start_time <= ? AND end_time > ?", dutyId, ts, ts).
+		Find(&shifts)
+	for _, shift := range shifts {
+		layers[shift.Layer] = shift.Users
+	}
+
+	// 同一层级存在多条替班记录时以最新创建的为准
+	dc.db.Model(&models.DutyOverride{}).
+		Where("duty_id = ? AND start_time <= ? AND end_time > ?", dutyId, ts, ts).
+		Order("create_at ASC").
+		Find(&overrides)
+	for _, override := range overrides {
+		layers[override.Layer] = override.Users
+	}
+
+	if len(layers) == 0 {
+		info, _ := dc.GetCalendarInfo(dutyId)
+		return dc.GetCalendarData(dutyId, at.In(info.GetLocation()).Format("2006-1-2")).Users
+	}
+
+	keys := make([]int, 0, len(layers))
+	for layer := range layers {
+		keys = append(keys, layer)
+	}
+	sort.Ints(keys)
+
+	var users []models.DutyUser
+	for _, layer := range keys {
+		users = append(users, layers[layer]...)
+	}
+	return users
+}
+
 func (dc DutyCalendarRepo) Create(r models.DutySchedule) error {
 	err := dc.g.Create(models.DutySchedule{}, r)
 	if err != nil {
@@ -137,8 +198,42 @@ func (dc DutyCalendarRepo) Search(tenantId, dutyId, time string) ([]models.DutyS
 }
 
 // GetCalendarUsers 获取值班用户
-// 只获取当前月份到月底正在值班的用户，避免已经移除过的用户仍存在值班用户列表当中；
+// 只获取当前时间到年底正在值班的用户，避免已经移除过的用户仍存在值班用户列表当中；
 func (dc DutyCalendarRepo) GetCalendarUsers(tenantId, dutyId string) ([][]models.DutyUser, error) {
+	var (
+		shifts       []models.DutyShift
+		groupedUsers [][]models.DutyUser
+	)
+
+	now := time.Now()
+	endOfYear := time.Date(now.Year()+1, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	db := dc.db.Model(&models.DutyShift{})
+	db.Where("tenant_id = ? AND duty_id = ? AND status = ?", tenantId, dutyId, models.CalendarFormalStatus)
+	db.Where("end_time > ? AND start_time < ?", now.Unix(), endOfYear.Unix())
+	if err := db.Order("layer ASC, start_time ASC").Find(&shifts).Error; err != nil {
+		return nil, fmt.Errorf("failed to get calendar users: %w", err)
+	}
+	if len(shifts) == 0 {
+		return dc.getScheduleUsers(tenantId, dutyId)
+	}
+
+	user := make(map[string]struct{})
+	for _, shift := range shifts {
+		key := tools.JsonMarshalToString(shift.Users)
+		if _, ok := user[key]; ok {
+			continue
+		}
+
+		groupedUsers = append(groupedUsers, shift.Users)
+		user[key] = struct{}{}
+	}
+
+	return groupedUsers, nil
+}
+
+// getScheduleUsers 获取按天生成的历史值班表中的值班用户
+func (dc DutyCalendarRepo) getScheduleUsers(tenantId, dutyId string) ([][]models.DutyUser, error) {
 	var (
 		entries      []models.DutySchedule
 		groupedUsers [][]models.DutyUser
@@ -174,3 +269,75 @@ func (dc DutyCalendarRepo) GetCalendarUsers(tenantId, dutyId string) ([][]models
 
 	return groupedUsers, nil
 }
+
+func (dc DutyCalendarRepo) CreateShifts(shifts []models.DutyShift) error {
+	if len(shifts) == 0 {
+		return nil
+	}
+	return dc.db.Model(&models.DutyShift{}).CreateInBatches(shifts, 500).Error
+}
+
+// DeleteShifts 删除开始时间不早于 from 的班次, 用于重新生成值班表
+func (dc DutyCalendarRepo) DeleteShifts(tenantId, dutyId string, from int64) error {
+	return dc.db.Where("tenant_id = ? AND duty_id = ? AND start_time >= ?", tenantId, dutyId, from).
+		Delete(&models.DutyShift{}).Error
+}
+
+// ReplaceShifts 删除层级中与各新班次时间重叠的班次后创建新班次, 新班次之间的空档不受影响
+func (dc DutyCalendarRepo) ReplaceShifts(tenantId, dutyId string, layer int, shifts []models.DutyShift) error {
+	return dc.db.Transaction(func(tx *gorm.DB) error {
+		for _, shift := range shifts {
+			err := tx.Where("tenant_id = ? AND duty_id = ? AND layer = ? AND end_time > ? AND start_time < ?", tenantId, dutyId, layer, shift.StartTime, shift.EndTime).
+				Delete(&models.DutyShift{}).Error
+			if err != nil {
+				return err
+			}
+		}
+		if len(shifts) == 0 {
+			return nil
+		}
+		return tx.Model(&models.DutyShift{}).CreateInBatches(shifts, 500).Error
+	})
+}
+
+// ListShifts 获取与时间范围重叠的班次
+func (dc DutyCalendarRepo) ListShifts(tenantId, dutyId string, start, end int64) ([]models.DutyShift, error) {
+	var shifts []models.DutyShift
+	db := dc.db.Model(&models.DutyShift{})
+	db.Where("tenant_id = ? AND duty_id = ? AND end_time > ? AND start_time < ?", tenantId, dutyId, start, end)
+	err := db.Order("layer ASC, start_time ASC").Find(&shifts).Error
+	return shifts, err
+}
+
+// GetLastShift 获取层级中最后一个班次
+func (dc DutyCalendarRepo) GetLastShift(tenantId, dutyId string, layer int) (models.DutyShift, error) {
+	var shift models.DutyShift
+	err := dc.db.Model(&models.DutyShift{}).
+		Where("tenant_id = ? AND duty_id = ? AND layer = ?", tenantId, dutyId, layer).
+		Order("start_time DESC").
+		First(&shift).Error
+	return shift, err
+}
+
+func (dc DutyCalendarRepo) CreateOverride(r models.DutyOverride) error {
+	return dc.g.Create(models.DutyOverride{}, r)
+}
+
+func (dc DutyCalendarRepo) DeleteOverride(tenantId, id string) error {
+	return dc.g.Delete(Delete{
+		Table: models.DutyOverride{},
+		Where: map[string]interface{}{
+			"tenant_id = ?": tenantId,
+			"id = ?":        id,
+		},
+	})
+}
+
+// ListOverrides 获取与时间范围重叠的替班记录
+func (dc DutyCalendarRepo) ListOverrides(tenantId, dutyId string, start, end int64) ([]models.DutyOverride, error) {
+	var overrides []models.DutyOverride
+	db := dc.db.Model(&models.DutyOverride{})
+	db.Where("tenant_id = ? AND duty_id = ? AND end_time > ? AND start_time < ?", tenantId, dutyId, start, end)
+	err := db.Order("start_time ASC").Find(&overrides).Error
+	return overrides, err
+}
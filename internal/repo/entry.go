@@ -23,6 +23,7 @@ type (
 		DutyCalendar() InterDutyCalendar
 		Event() InterEventRepo
 		Notice() InterNoticeRepo
+		NoticeQueue() InterNoticeQueueRepo
 		NoticeTmpl() InterNoticeTmplRepo
 		Rule() InterRuleRepo
 		RuleGroup() InterRuleGroupRepo
@@ -76,6 +77,9 @@ func (e *entryRepo) NoticeTmpl() InterNoticeTmplRepo { return newNoticeTmplInter
 func (e *entryRepo) Rule() InterRuleRepo             { return newRuleInterface(e.db, e.g) }
 func (e *entryRepo) RuleGroup() InterRuleGroupRepo   { return newRuleGroupInterface(e.db, e.g) }
 func (e *entryRepo) RuleTmpl() InterRuleTmplRepo     { return newRuleTmplInterface(e.db, e.g) }
+func (e *entryRepo) NoticeQueue() InterNoticeQueueRepo {
+	return newNoticeQueueInterface(e.db, e.g)
+}
 func (e *entryRepo) RuleTmplGroup() InterRuleTmplGroupRepo {
 	return newRuleTmplGroupInterface(e.db, e.g)
 }
@@ -42,6 +42,10 @@ func (e EventRepo) GetHistoryEvent(r types.RequestAlertHisEventQuery) (types.Res
 		db = db.Where("datasource_type = ?", r.DatasourceType)
 	}
 
+	if r.Fingerprint != "" {
+		db = db.Where("fingerprint = ?", r.Fingerprint)
+	}
+
 	if r.Severity != "" {
 		db = db.Where("severity = ?", r.Severity)
 	}
@@ -203,6 +203,11 @@ func (nr NoticeRepo) CountRecord(r models.CountRecord) (int64, error) {
 	if r.Severity != "" {
 		db.Where("severity = ?", r.Severity)
 	}
+	if r.Suppressed {
+		db.Where("status = ?", models.NoticeRecordSuppressed)
+	} else {
+		db.Where("status <> ?", models.NoticeRecordSuppressed)
+	}
 	err := db.Count(&count).Error
 	if err != nil {
 		return count, err
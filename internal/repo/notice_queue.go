@@ -0,0 +1,148 @@
+package repo
+
+import (
+	"time"
+	"watchAlert/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type (
+	noticeQueueRepo struct {
+		entryRepo
+	}
+
+	InterNoticeQueueRepo interface {
+		Create(r models.NoticeTask) error
+		ListDue(now int64, limit int) ([]models.NoticeTask, error)
+		Claim(id string, nextRetryAt, leaseUntil int64) bool
+		Update(r models.NoticeTask) error
+		Delete(id string) error
+		DeleteTriggers(eventId, noticeType, noticeId string) (int64, error)
+		ListDead(tenantId, noticeType, query string, page models.Page) (models.ResponseNoticeTasks, error)
+		Replay(tenantId string, ids []string) (int64, error)
+		DeleteDead(tenantId string, ids []string) (int64, error)
+		PurgeDead(before int64) (int64, error)
+	}
+)
+
+func newNoticeQueueInterface(db *gorm.DB, g InterGormDBCli) InterNoticeQueueRepo {
+	return &noticeQueueRepo{
+		entryRepo{
+			g:  g,
+			db: db,
+		},
+	}
+}
+
+func (nq noticeQueueRepo) Create(r models.NoticeTask) error {
+	return nq.g.Create(models.NoticeTask{}, r)
+}
+
+// ListDue 获取到达发送时间的任务
+func (nq noticeQueueRepo) ListDue(now int64, limit int) ([]models.NoticeTask, error) {
+	var data []models.NoticeTask
+	err := nq.db.Model(&models.NoticeTask{}).
+		Where("status = ? AND next_retry_at <= ?", models.NoticeTaskPending, now).
+		Order("next_retry_at ASC").
+		Limit(limit).
+		Find(&data).Error
+	return data, err
+}
+
+// Claim 抢占任务直至 leaseUntil, 多个实例同时扫描时仅有一个实例抢占成功
+func (nq noticeQueueRepo) Claim(id string, nextRetryAt, leaseUntil int64) bool {
+	res := nq.db.Model(&models.NoticeTask{}).
+		Where("id = ? AND status = ? AND next_retry_at = ?", id, models.NoticeTaskPending, nextRetryAt).
+		Update("next_retry_at", leaseUntil)
+	return res.Error == nil && res.RowsAffected == 1
+}
+
+func (nq noticeQueueRepo) Update(r models.NoticeTask) error {
+	return nq.db.Model(&models.NoticeTask{}).
+		Where("id = ?", r.ID).
+		Select("status", "retries", "next_retry_at", "last_error", "update_at").
+		Updates(&r).Error
+}
+
+func (nq noticeQueueRepo) Delete(id string) error {
+	return nq.g.Delete(Delete{
+		Table: &models.NoticeTask{},
+		Where: map[string]interface{}{
+			"id = ?": id,
+		},
+	})
+}
+
+// DeleteTriggers 删除事件未发送成功的触发通知, 包括等待重试及死信
+func (nq noticeQueueRepo) DeleteTriggers(eventId, noticeType, noticeId string) (int64, error) {
+	res := nq.db.Where("event_id = ? AND notice_type = ? AND notice_id = ? AND is_recovered = ?", eventId, noticeType, noticeId, false).
+		Delete(&models.NoticeTask{})
+	return res.RowsAffected, res.Error
+}
+
+// ListDead 获取死信列表
+func (nq noticeQueueRepo) ListDead(tenantId, noticeType, query string, page models.Page) (models.ResponseNoticeTasks, error) {
+	var (
+		data  []models.NoticeTask
+		count int64
+		db    = nq.db.Model(&models.NoticeTask{})
+	)
+
+	db.Where("tenant_id = ? AND status = ?", tenantId, models.NoticeTaskDead)
+	if noticeType != "" {
+		db.Where("notice_type = ?", noticeType)
+	}
+	if query != "" {
+		db.Where("rule_name LIKE ? OR notice_name LIKE ? OR last_error LIKE ?", "%"+query+"%", "%"+query+"%", "%"+query+"%")
+	}
+
+	if err := db.Count(&count).Error; err != nil {
+		return models.ResponseNoticeTasks{}, err
+	}
+
+	err := db.Limit(int(page.Size)).Offset(int((page.Index - 1) * page.Size)).Order("update_at DESC").Find(&data).Error
+	if err != nil {
+		return models.ResponseNoticeTasks{}, err
+	}
+
+	return models.ResponseNoticeTasks{
+		List: data,
+		Page: models.Page{
+			Index: page.Index,
+			Size:  page.Size,
+			Total: count,
+		},
+	}, nil
+}
+
+// Replay 重新投递死信, ids 为空时投递租户下全部死信
+func (nq noticeQueueRepo) Replay(tenantId string, ids []string) (int64, error) {
+	db := nq.db.Model(&models.NoticeTask{}).Where("tenant_id = ? AND status = ?", tenantId, models.NoticeTaskDead)
+	if len(ids) > 0 {
+		db.Where("id IN ?", ids)
+	}
+
+	now := time.Now().Unix()
+	res := db.Updates(map[string]interface{}{
+		"status":        models.NoticeTaskPending,
+		"retries":       0,
+		"next_retry_at": now,
+		"update_at":     now,
+	})
+	return res.RowsAffected, res.Error
+}
+
+// DeleteDead 删除租户下指定的死信
+func (nq noticeQueueRepo) DeleteDead(tenantId string, ids []string) (int64, error) {
+	res := nq.db.Where("tenant_id = ? AND status = ? AND id IN ?", tenantId, models.NoticeTaskDead, ids).
+		Delete(&models.NoticeTask{})
+	return res.RowsAffected, res.Error
+}
+
+// PurgeDead 删除最后更新时间早于 before 的死信
+func (nq noticeQueueRepo) PurgeDead(before int64) (int64, error) {
+	res := nq.db.Where("status = ? AND update_at < ?", models.NoticeTaskDead, before).
+		Delete(&models.NoticeTask{})
+	return res.RowsAffected, res.Error
+}
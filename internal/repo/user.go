@@ -25,6 +25,8 @@ type (
 		Delete(userId string) error
 		ChangeCache(userId string)
 		ChangePass(userId, password string) error
+		UpdatePreference(r models.Member) error
+		GetByDutyUserId(dutyUserId string) (models.Member, bool, error)
 	}
 )
 
@@ -182,3 +184,25 @@ func (ur UserRepo) ChangePass(userId, password string) error {
 
 	return nil
 }
+
+// UpdatePreference 更新通知偏好, 允许清空已有配置
+func (ur UserRepo) UpdatePreference(r models.Member) error {
+	return ur.db.Model(&models.Member{}).
+		Where("user_id = ?", r.UserId).
+		Select("contact_methods", "notify_preferences", "quiet_hours").
+		Updates(&r).Error
+}
+
+// GetByDutyUserId 根据 IM 平台的用户 ID 获取用户
+func (ur UserRepo) GetByDutyUserId(dutyUserId string) (models.Member, bool, error) {
+	var data models.Member
+	err := ur.db.Model(&models.Member{}).Where("duty_user_id = ?", dutyUserId).First(&data).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return data, false, fmt.Errorf("用户不存在")
+		}
+		return data, false, err
+	}
+
+	return data, true, nil
+}
@@ -1,8 +1,11 @@
 package routers
 
 import (
+	"crypto/subtle"
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"net/http"
+	"watchAlert/config"
 )
 
 func HealthCheck(gin *gin.Engine) {
@@ -11,6 +14,28 @@ func HealthCheck(gin *gin.Engine) {
 
 }
 
+// Metrics WatchAlert 自身的 Prometheus 指标, 需在配置中开启
+func Metrics(engine *gin.Engine) {
+
+	conf := config.Application.Metrics
+	if !conf.Enabled {
+		return
+	}
+	engine.GET("metrics", metricsAuth(conf.Token), gin.WrapH(promhttp.Handler()))
+
+}
+
+// metricsAuth 配置了 Token 时校验 Bearer Token
+func metricsAuth(token string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if token != "" && subtle.ConstantTimeCompare([]byte(ctx.GetHeader("Authorization")), []byte("Bearer "+token)) != 1 {
+			ctx.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+		ctx.Next()
+	}
+}
+
 func health(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusOK, gin.H{
@@ -49,6 +49,12 @@ func Router(engine *gin.Engine) {
 			api.ApiKeyController.API(w8t)
 			api.RecordingRuleGroupController.API(w8t)
 			api.RecordingRuleController.API(w8t)
+			api.ChatOpsController.API(w8t)
+		}
+
+		receiver := v1.Group("v2")
+		{
+			api.AlertReceiverController.API(receiver)
 		}
 
 		oidc := v1.Group("oidc")
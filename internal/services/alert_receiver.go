@@ -0,0 +1,311 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"watchAlert/alert/process"
+	"watchAlert/internal/ctx"
+	"watchAlert/internal/models"
+	"watchAlert/internal/types"
+	"watchAlert/pkg/provider"
+	"watchAlert/pkg/tools"
+)
+
+type alertReceiverService struct {
+	ctx *ctx.Context
+}
+
+type InterAlertReceiverService interface {
+	Alertmanager(req interface{}) (interface{}, interface{})
+	Events(req interface{}) (interface{}, interface{})
+}
+
+func newInterAlertReceiverService(ctx *ctx.Context) InterAlertReceiverService {
+	return &alertReceiverService{
+		ctx: ctx,
+	}
+}
+
+// alertmanagerSeverityMap Alertmanager 常用 severity 标签与告警等级的映射
+var alertmanagerSeverityMap = map[string]string{
+	"critical": "P0",
+	"error":    "P0",
+	"warning":  "P1",
+	"info":     "P2",
+}
+
+// Alertmanager 接收 Alertmanager v2 格式的告警
+func (a alertReceiverService) Alertmanager(req interface{}) (interface{}, interface{}) {
+	r := req.(*types.RequestAlertmanagerReceive)
+
+	apiKey, _, err := a.ctx.DB.ApiKey().GetByKey(r.ApiKey)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		result       = types.ResponseAlertmanagerReceive{Received: len(r.Alerts)}
+		faultCenters = make(map[string]models.FaultCenter)
+	)
+	for _, alert := range r.Alerts {
+		faultCenter, err := a.getFaultCenter(apiKey.UserId, apiKey.FaultCenterId, alert.Labels[types.AlertmanagerReceiverFaultCenterLabel], faultCenters)
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("alertname: %s, err: %s", alert.Labels["alertname"], err.Error()))
+			continue
+		}
+
+		event := buildAlertmanagerEvent(faultCenter, alert)
+		if alert.IsResolved() {
+			process.ResolveExternalEvent(a.ctx, event.TenantId, event.FaultCenterId, event.Fingerprint)
+			result.Resolved++
+			continue
+		}
+
+		process.PushExternalEvent(a.ctx, &event)
+		result.Firing++
+	}
+
+	return result, nil
+}
+
+// Events 通用事件接口, 通过 dedup_key 触发、认领及恢复事件
+func (a alertReceiverService) Events(req interface{}) (interface{}, interface{}) {
+	r := req.(*types.RequestEventsReceive)
+
+	var boundId string
+	if r.ApiKey != "" {
+		apiKey, _, err := a.ctx.DB.ApiKey().GetByKey(r.ApiKey)
+		if err != nil {
+			return nil, err
+		}
+		boundId = apiKey.FaultCenterId
+	}
+
+	faultCenter, err := a.getFaultCenter(r.UserId, boundId, r.RoutingKey, make(map[string]models.FaultCenter))
+	if err != nil {
+		return nil, err
+	}
+
+	switch r.EventAction {
+	case types.EventsActionTrigger:
+		if r.Payload.Summary == "" {
+			return nil, fmt.Errorf("payload.summary 不能为空")
+		}
+		if r.DedupKey == "" {
+			r.DedupKey = tools.RandId()
+		}
+		event := buildEventsEvent(faultCenter, r)
+		process.PushExternalEvent(a.ctx, &event)
+
+	case types.EventsActionAcknowledge, types.EventsActionResolve:
+		if r.DedupKey == "" {
+			return nil, fmt.Errorf("dedup_key 不能为空")
+		}
+		event, err := a.ctx.Redis.Alert().GetEventFromCache(faultCenter.TenantId, faultCenter.ID, r.DedupKey)
+		if err != nil {
+			return nil, fmt.Errorf("事件 %s 不存在", r.DedupKey)
+		}
+
+		if r.EventAction == types.EventsActionAcknowledge {
+			// 认领与告警事件处理保持一致
+			user, _, _ := a.ctx.DB.User().Get(r.UserId, "", "", "")
+			_, err := EventService.ProcessAlertEvent(&types.RequestProcessAlertEvent{
+				TenantId:      faultCenter.TenantId,
+				FaultCenterId: faultCenter.ID,
+				Fingerprints:  []string{event.Fingerprint},
+				Time:          time.Now().Unix(),
+				Username:      user.UserName,
+			})
+			if err != nil {
+				return nil, err
+			}
+			return a.eventsResponse(r.DedupKey), nil
+		}
+		if err := a.resolveEvent(event); err != nil {
+			return nil, err
+		}
+		return a.eventsResponse(r.DedupKey), nil
+
+	default:
+		return nil, fmt.Errorf("不支持的 event_action: %s", r.EventAction)
+	}
+
+	return a.eventsResponse(r.DedupKey), nil
+}
+
+// resolveEvent 恢复事件, 由调用方显式恢复, 无需恢复等待时间
+func (a alertReceiverService) resolveEvent(event models.AlertCurEvent) error {
+	if event.Status == models.StatePreAlert {
+		a.ctx.Redis.Alert().RemoveAlertEvent(event.TenantId, event.FaultCenterId, event.Fingerprint)
+		return nil
+	}
+
+	if event.Status == models.StateAlerting {
+		if err := event.TransitionStatus(models.StatePendingRecovery); err != nil {
+			return err
+		}
+	}
+	if err := event.TransitionStatus(models.StateRecovered); err != nil {
+		return err
+	}
+	a.ctx.Redis.PendingRecover().Delete(event.TenantId, event.RuleId, event.Fingerprint)
+
+	if err := process.RecordAlertHisEvent(a.ctx, event); err != nil {
+		return err
+	}
+
+	// 写回故障中心, 由消费者发送恢复通知
+	a.ctx.Redis.Alert().PushAlertEvent(&event)
+
+	return nil
+}
+
+func (a alertReceiverService) eventsResponse(dedupKey string) types.ResponseEventsReceive {
+	return types.ResponseEventsReceive{
+		Status:   "success",
+		Message:  "Event processed",
+		DedupKey: dedupKey,
+	}
+}
+
+// getFaultCenter 选择故障中心, 请求中指定的故障中心优先, 其次为 API Key 绑定的故障中心
+func (a alertReceiverService) getFaultCenter(userId, boundId, routeId string, faultCenters map[string]models.FaultCenter) (models.FaultCenter, error) {
+	faultCenterId := routeId
+	if faultCenterId == "" {
+		faultCenterId = boundId
+	}
+	if faultCenterId == "" {
+		return models.FaultCenter{}, fmt.Errorf("未指定故障中心, 请为 API Key 绑定故障中心或在请求中指定故障中心 ID")
+	}
+
+	if faultCenter, ok := faultCenters[faultCenterId]; ok {
+		return faultCenter, nil
+	}
+
+	faultCenter, err := a.ctx.DB.FaultCenter().Get("", faultCenterId, "")
+	if err != nil {
+		return faultCenter, fmt.Errorf("故障中心 %s 不存在", faultCenterId)
+	}
+
+	// API Key 所属用户需要拥有故障中心所在租户的权限
+	if userId != "admin" {
+		tenantUser, err := a.ctx.DB.Tenant().GetTenantLinkedUserInfo(faultCenter.TenantId, userId)
+		if err != nil || tenantUser.UserID == "" {
+			return models.FaultCenter{}, fmt.Errorf("无权限推送至故障中心 %s", faultCenterId)
+		}
+	}
+
+	faultCenters[faultCenterId] = faultCenter
+	return faultCenter, nil
+}
+
+// buildAlertmanagerEvent 将 Alertmanager 告警转换为故障中心事件
+func buildAlertmanagerEvent(faultCenter models.FaultCenter, alert types.AlertmanagerAlert) models.AlertCurEvent {
+	labels := make(map[string]interface{}, len(alert.Labels))
+	for k, v := range alert.Labels {
+		labels[k] = v
+	}
+
+	// 与规则评估产生的事件保持一致, 指纹同时写入标签, 供静默规则按指纹匹配
+	fingerprint := provider.Metrics{Labels: labels}.GetFingerprint()
+	labels["fingerprint"] = fingerprint
+
+	alertName := alert.Labels["alertname"]
+	event := models.AlertCurEvent{
+		TenantId:       faultCenter.TenantId,
+		DatasourceType: models.ExternalSourceAlertmanager,
+		RuleId:         models.ExternalSourceAlertmanager + "-" + alertName,
+		RuleName:       alertName,
+		Fingerprint:    fingerprint,
+		Severity:       getAlertmanagerSeverity(alert.Labels["severity"]),
+		Labels:         labels,
+		Annotations:    formatAlertmanagerAnnotations(alert.Annotations),
+		SearchQL:       alert.GeneratorURL,
+		FaultCenterId:  faultCenter.ID,
+	}
+	if !alert.StartsAt.IsZero() {
+		event.FirstTriggerTime = alert.StartsAt.Unix()
+	}
+	if !alert.EndsAt.IsZero() {
+		event.EndsAt = alert.EndsAt.Unix()
+	}
+
+	return event
+}
+
+// buildEventsEvent 将通用事件转换为故障中心事件, dedup_key 即事件指纹
+func buildEventsEvent(faultCenter models.FaultCenter, r *types.RequestEventsReceive) models.AlertCurEvent {
+	labels := map[string]interface{}{
+		"dedup_key":   r.DedupKey,
+		"fingerprint": r.DedupKey,
+	}
+	for k, v := range map[string]string{
+		"source":    r.Payload.Source,
+		"component": r.Payload.Component,
+		"group":     r.Payload.Group,
+		"class":     r.Payload.Class,
+	} {
+		if v != "" {
+			labels[k] = v
+		}
+	}
+	for k, v := range r.Payload.CustomDetails {
+		labels[k] = fmt.Sprintf("%v", v)
+	}
+
+	event := models.AlertCurEvent{
+		TenantId:       faultCenter.TenantId,
+		DatasourceType: models.ExternalSourceEvents,
+		RuleId:         models.ExternalSourceEvents + "-" + r.DedupKey,
+		RuleName:       r.Payload.Summary,
+		Fingerprint:    r.DedupKey,
+		Severity:       getAlertmanagerSeverity(r.Payload.Severity),
+		Labels:         labels,
+		Annotations:    r.Payload.Summary,
+		FaultCenterId:  faultCenter.ID,
+	}
+	if !r.Payload.Timestamp.IsZero() {
+		event.FirstTriggerTime = r.Payload.Timestamp.Unix()
+	}
+
+	return event
+}
+
+func getAlertmanagerSeverity(severity string) string {
+	switch severity {
+	case "P0", "P1", "P2":
+		return severity
+	}
+
+	if s, ok := alertmanagerSeverityMap[strings.ToLower(severity)]; ok {
+		return s
+	}
+
+	return "P1"
+}
+
+// formatAlertmanagerAnnotations summary 和 description 优先展示, 其余注解按 key 排序
+func formatAlertmanagerAnnotations(annotations map[string]string) string {
+	var lines []string
+	for _, key := range []string{"summary", "description"} {
+		if v, ok := annotations[key]; ok && v != "" {
+			lines = append(lines, v)
+		}
+	}
+
+	var keys []string
+	for k := range annotations {
+		if k == "summary" || k == "description" {
+			continue
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		lines = append(lines, fmt.Sprintf("%s: %s", k, annotations[k]))
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -44,12 +44,20 @@ func (aks apiKeyService) Create(req interface{}) (interface{}, interface{}) {
 		return nil, fmt.Errorf("用户ID不能为空")
 	}
 
+	if r.FaultCenterId != "" {
+		if _, err := aks.ctx.DB.FaultCenter().Get(r.TenantId, r.FaultCenterId, ""); err != nil {
+			return nil, fmt.Errorf("绑定的故障中心不存在: %v", err)
+		}
+	}
+
 	model := models.ApiKey{
-		UserId:      userId,
-		Name:        r.Name,
-		Description: r.Description,
-		Key:         apiKey,
-		CreatedAt:   time.Now(),
+		UserId:        userId,
+		Name:          r.Name,
+		Description:   r.Description,
+		Key:           apiKey,
+		TenantId:      r.TenantId,
+		FaultCenterId: r.FaultCenterId,
+		CreatedAt:     time.Now(),
 	}
 
 	err = aks.ctx.DB.ApiKey().Create(model)
@@ -59,12 +67,14 @@ func (aks apiKeyService) Create(req interface{}) (interface{}, interface{}) {
 
 	// 返回不包含敏感信息的结果
 	result := types.ResponseApiKeyInfo{
-		ID:          model.ID,
-		UserId:      model.UserId,
-		Name:        model.Name,
-		Description: model.Description,
-		Key:         model.Key,
-		CreatedAt:   model.CreatedAt.Unix(),
+		ID:            model.ID,
+		UserId:        model.UserId,
+		Name:          model.Name,
+		Description:   model.Description,
+		Key:           model.Key,
+		TenantId:      model.TenantId,
+		FaultCenterId: model.FaultCenterId,
+		CreatedAt:     model.CreatedAt.Unix(),
 	}
 
 	return result, nil
@@ -81,12 +91,14 @@ func (aks apiKeyService) List(req interface{}) (interface{}, interface{}) {
 	var result []types.ResponseApiKeyInfo
 	for _, item := range data {
 		result = append(result, types.ResponseApiKeyInfo{
-			ID:          item.ID,
-			UserId:      item.UserId,
-			Name:        item.Name,
-			Description: item.Description,
-			Key:         item.Key,
-			CreatedAt:   item.CreatedAt.Unix(),
+			ID:            item.ID,
+			UserId:        item.UserId,
+			Name:          item.Name,
+			Description:   item.Description,
+			Key:           item.Key,
+			TenantId:      item.TenantId,
+			FaultCenterId: item.FaultCenterId,
+			CreatedAt:     item.CreatedAt.Unix(),
 		})
 	}
 
@@ -102,12 +114,14 @@ func (aks apiKeyService) Get(req interface{}) (interface{}, interface{}) {
 	}
 
 	result := types.ResponseApiKeyInfo{
-		ID:          data.ID,
-		UserId:      data.UserId,
-		Name:        data.Name,
-		Description: data.Description,
-		Key:         data.Key,
-		CreatedAt:   data.CreatedAt.Unix(),
+		ID:            data.ID,
+		UserId:        data.UserId,
+		Name:          data.Name,
+		Description:   data.Description,
+		Key:           data.Key,
+		TenantId:      data.TenantId,
+		FaultCenterId: data.FaultCenterId,
+		CreatedAt:     data.CreatedAt.Unix(),
 	}
 
 	return result, nil
@@ -122,12 +136,20 @@ func (aks apiKeyService) Update(req interface{}) (interface{}, interface{}) {
 		return nil, err
 	}
 
+	if r.FaultCenterId != "" {
+		if _, err := aks.ctx.DB.FaultCenter().Get(r.TenantId, r.FaultCenterId, ""); err != nil {
+			return nil, fmt.Errorf("绑定的故障中心不存在: %v", err)
+		}
+	}
+
 	model := models.ApiKey{
-		ID:          r.ID,
-		UserId:      existing.UserId, // 确保不能更改所属用户
-		Name:        r.Name,
-		Description: r.Description,
-		CreatedAt:   existing.CreatedAt,
+		ID:            r.ID,
+		UserId:        existing.UserId, // 确保不能更改所属用户
+		Name:          r.Name,
+		Description:   r.Description,
+		TenantId:      r.TenantId,
+		FaultCenterId: r.FaultCenterId,
+		CreatedAt:     existing.CreatedAt,
 	}
 
 	err = aks.ctx.DB.ApiKey().Update(model)
@@ -137,12 +159,14 @@ func (aks apiKeyService) Update(req interface{}) (interface{}, interface{}) {
 
 	// 返回不包含敏感信息的结果
 	result := types.ResponseApiKeyInfo{
-		ID:          model.ID,
-		UserId:      model.UserId,
-		Name:        model.Name,
-		Description: model.Description,
-		Key:         model.Key,
-		CreatedAt:   model.CreatedAt.Unix(),
+		ID:            model.ID,
+		UserId:        model.UserId,
+		Name:          model.Name,
+		Description:   model.Description,
+		Key:           model.Key,
+		TenantId:      model.TenantId,
+		FaultCenterId: model.FaultCenterId,
+		CreatedAt:     model.CreatedAt.Unix(),
 	}
 
 	return result, nil
@@ -169,12 +193,14 @@ func (aks apiKeyService) GetApiKeyByUserId(userId string) ([]types.ResponseApiKe
 	var result []types.ResponseApiKeyInfo
 	for _, item := range data {
 		result = append(result, types.ResponseApiKeyInfo{
-			ID:          item.ID,
-			UserId:      item.UserId,
-			Name:        item.Name,
-			Description: item.Description,
-			Key:         item.Key,
-			CreatedAt:   item.CreatedAt.Unix(),
+			ID:            item.ID,
+			UserId:        item.UserId,
+			Name:          item.Name,
+			Description:   item.Description,
+			Key:           item.Key,
+			TenantId:      item.TenantId,
+			FaultCenterId: item.FaultCenterId,
+			CreatedAt:     item.CreatedAt.Unix(),
 		})
 	}
 
@@ -0,0 +1,391 @@
+package services
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"math"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+	"watchAlert/internal/ctx"
+	"watchAlert/internal/models"
+	"watchAlert/internal/types"
+	"watchAlert/pkg/templates"
+	"watchAlert/pkg/tools"
+
+	"github.com/bytedance/sonic"
+	"github.com/zeromicro/go-zero/core/logc"
+)
+
+const (
+	// slackSignatureMaxAge Slack 请求时间戳的有效期, 防止重放
+	slackSignatureMaxAge = 5 * time.Minute
+	// dingDingSignatureMaxAge 钉钉请求时间戳的有效期
+	dingDingSignatureMaxAge = time.Hour
+	// feiShuSignatureMaxAge 飞书请求时间戳的有效期, 防止重放
+	feiShuSignatureMaxAge = 5 * time.Minute
+)
+
+type (
+	chatOpsService struct {
+		ctx *ctx.Context
+	}
+
+	InterChatOpsService interface {
+		FeiShuCallback(req interface{}) (interface{}, interface{})
+		DingDingCallback(req interface{}) (interface{}, interface{})
+		SlackCallback(req interface{}) (interface{}, interface{})
+		PagerDutyCallback(req interface{}) (interface{}, interface{})
+		OpsgenieCallback(req interface{}) (interface{}, interface{})
+	}
+)
+
+func newInterChatOpsService(ctx *ctx.Context) InterChatOpsService {
+	return &chatOpsService{
+		ctx: ctx,
+	}
+}
+
+// FeiShuCallback 处理飞书卡片回传交互, 返回更新后的卡片
+func (c chatOpsService) FeiShuCallback(req interface{}) (interface{}, interface{}) {
+	r := req.(*types.RequestChatOpsCallback)
+	config, err := c.getConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := config.FeiShu
+	if cfg.EncryptKey == "" && cfg.VerificationToken == "" {
+		return nil, fmt.Errorf("未配置飞书 Encrypt Key 或 Verification Token")
+	}
+
+	// 配置 Encrypt Key 后飞书会对请求签名: sha256(timestamp + nonce + encryptKey + body), 时间戳为秒
+	if cfg.EncryptKey != "" {
+		ts, err := strconv.ParseInt(r.Timestamp, 10, 64)
+		if err != nil || math.Abs(float64(time.Now().Unix()-ts)) > feiShuSignatureMaxAge.Seconds() {
+			return nil, fmt.Errorf("飞书请求时间戳无效")
+		}
+		sum := sha256.Sum256([]byte(r.Timestamp + r.Nonce + cfg.EncryptKey + string(r.Body)))
+		if !hmac.Equal([]byte(fmt.Sprintf("%x", sum)), []byte(r.Signature)) {
+			return nil, fmt.Errorf("飞书请求签名校验失败")
+		}
+	}
+
+	var callback models.FeiShuCallback
+	if err := sonic.Unmarshal(r.Body, &callback); err != nil {
+		return nil, err
+	}
+	if callback.Encrypt != "" {
+		body, err := decryptFeiShu(callback.Encrypt, cfg.EncryptKey)
+		if err != nil {
+			return nil, fmt.Errorf("飞书请求解密失败: %w", err)
+		}
+		callback = models.FeiShuCallback{}
+		if err := sonic.Unmarshal(body, &callback); err != nil {
+			return nil, err
+		}
+	}
+
+	token := callback.Token
+	if token == "" {
+		token = callback.Header.Token
+	}
+	if cfg.VerificationToken != "" && !hmac.Equal([]byte(token), []byte(cfg.VerificationToken)) {
+		return nil, fmt.Errorf("飞书 Verification Token 校验失败")
+	}
+
+	// 配置回调地址时的 URL 校验
+	if callback.Type == "url_verification" {
+		return map[string]string{"challenge": callback.Challenge}, nil
+	}
+
+	value := callback.Event.Action.Value
+	event, handled, err := c.handleAction(config, "FeiShu", value, callback.Event.Operator.UserId, callback.Event.Operator.OpenId)
+	if err != nil {
+		return feiShuToast("error", err.Error()), nil
+	}
+
+	resp := feiShuToast("success", handled)
+	tmpl, err := templates.NewHandledTemplate(c.ctx, event, models.Route{NoticeType: "FeiShu", NoticeTmplId: value.NoticeTmplId}, handled)
+	if err != nil {
+		logc.Error(c.ctx.Ctx, fmt.Sprintf("Failed to create template: %v", err))
+		return resp, nil
+	}
+
+	var msg models.FeiShuJsonCardMsg
+	if err := sonic.UnmarshalString(tmpl.CardContentMsg, &msg); err == nil {
+		resp["card"] = map[string]interface{}{"type": "raw", "data": msg.Card}
+	}
+
+	return resp, nil
+}
+
+func feiShuToast(toastType, content string) map[string]interface{} {
+	return map[string]interface{}{
+		"toast": map[string]string{"type": toastType, "content": content},
+	}
+}
+
+// decryptFeiShu 解密飞书加密推送: AES-256-CBC, 密钥为 sha256(encryptKey), 密文前 16 字节为 IV
+func decryptFeiShu(encrypt, key string) ([]byte, error) {
+	data, err := base64.StdEncoding.DecodeString(encrypt)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < aes.BlockSize || len(data)%aes.BlockSize != 0 {
+		return nil, fmt.Errorf("密文长度错误")
+	}
+
+	sum := sha256.Sum256([]byte(key))
+	block, err := aes.NewCipher(sum[:])
+	if err != nil {
+		return nil, err
+	}
+
+	plain := make([]byte, len(data)-aes.BlockSize)
+	cipher.NewCBCDecrypter(block, data[:aes.BlockSize]).CryptBlocks(plain, data[aes.BlockSize:])
+
+	// 去除 PKCS#7 填充
+	if n := len(plain); n > 0 {
+		if pad := int(plain[n-1]); pad > 0 && pad <= aes.BlockSize && pad <= n {
+			plain = plain[:n-pad]
+		}
+	}
+	// 兼容明文前后可能存在的非 JSON 字符
+	if start, end := bytes.IndexByte(plain, '{'), bytes.LastIndexByte(plain, '}'); start >= 0 && end > start {
+		plain = plain[start : end+1]
+	}
+
+	return plain, nil
+}
+
+// DingDingCallback 处理钉钉互动卡片回传, 返回需要更新的卡片数据
+func (c chatOpsService) DingDingCallback(req interface{}) (interface{}, interface{}) {
+	r := req.(*types.RequestChatOpsCallback)
+	config, err := c.getConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	// 钉钉签名: base64(HmacSHA256(timestamp + "\n" + appSecret, appSecret)), 时间戳为毫秒
+	secret := config.DingDing.AppSecret
+	if secret == "" {
+		return nil, fmt.Errorf("未配置钉钉 AppSecret")
+	}
+	ts, err := strconv.ParseInt(r.Timestamp, 10, 64)
+	if err != nil || math.Abs(float64(time.Now().UnixMilli()-ts)) > float64(dingDingSignatureMaxAge.Milliseconds()) {
+		return nil, fmt.Errorf("钉钉请求时间戳无效")
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(r.Timestamp + "\n" + secret))
+	if !hmac.Equal([]byte(base64.StdEncoding.EncodeToString(mac.Sum(nil))), []byte(r.Signature)) {
+		return nil, fmt.Errorf("钉钉请求签名校验失败")
+	}
+
+	var (
+		callback models.DingDingCallback
+		content  models.DingDingCallbackContent
+	)
+	if err := sonic.Unmarshal(r.Body, &callback); err != nil {
+		return nil, err
+	}
+	if err := sonic.UnmarshalString(callback.Content, &content); err != nil {
+		return nil, err
+	}
+
+	value := content.CardPrivateData.Params
+	if value.Action == "" && len(content.CardPrivateData.ActionIds) > 0 {
+		value.Action = content.CardPrivateData.ActionIds[0]
+	}
+
+	result := map[string]string{}
+	event, handled, err := c.handleAction(config, "DingDing", value, callback.UserId)
+	if err != nil {
+		result["error"] = err.Error()
+	} else {
+		result["handled"] = handled
+		result["confirmUsername"] = event.ConfirmState.ConfirmUsername
+	}
+
+	// 卡片模版中通过 handled 等变量展示处理结果
+	return map[string]interface{}{
+		"cardData":          map[string]interface{}{"cardParamMap": result},
+		"cardUpdateOptions": map[string]bool{"updateCardDataByKey": true},
+	}, nil
+}
+
+// SlackCallback 处理 Slack 交互组件回传, 通过 response_url 更新原消息
+func (c chatOpsService) SlackCallback(req interface{}) (interface{}, interface{}) {
+	r := req.(*types.RequestChatOpsCallback)
+	config, err := c.getConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	// Slack 签名: "v0=" + hex(HmacSHA256(signingSecret, "v0:" + timestamp + ":" + body))
+	secret := config.Slack.SigningSecret
+	if secret == "" {
+		return nil, fmt.Errorf("未配置 Slack Signing Secret")
+	}
+	ts, err := strconv.ParseInt(r.Timestamp, 10, 64)
+	if err != nil || math.Abs(float64(time.Now().Unix()-ts)) > slackSignatureMaxAge.Seconds() {
+		return nil, fmt.Errorf("Slack 请求时间戳无效")
+	}
+	if !hmac.Equal([]byte("v0="+tools.HmacSha256(secret, "v0:"+r.Timestamp+":"+string(r.Body))), []byte(r.Signature)) {
+		return nil, fmt.Errorf("Slack 请求签名校验失败")
+	}
+
+	form, err := url.ParseQuery(string(r.Body))
+	if err != nil {
+		return nil, err
+	}
+	var callback models.SlackCallback
+	if err := sonic.UnmarshalString(form.Get("payload"), &callback); err != nil {
+		return nil, err
+	}
+	// 查看详情为跳转按钮, 无需处理
+	if len(callback.Actions) == 0 || callback.Actions[0].ActionId == models.ChatOpsActionViewDetail {
+		return nil, nil
+	}
+
+	var value models.ChatOpsActionValue
+	if err := sonic.UnmarshalString(callback.Actions[0].Value, &value); err != nil {
+		return nil, err
+	}
+
+	event, handled, err := c.handleAction(config, "Slack", value, callback.User.Id)
+	if err != nil {
+		c.postSlackResponse(callback.ResponseUrl, map[string]interface{}{
+			"response_type":    "ephemeral",
+			"replace_original": false,
+			"text":             err.Error(),
+		})
+		return nil, nil
+	}
+
+	tmpl, err := templates.NewHandledTemplate(c.ctx, event, models.Route{NoticeType: "Slack", NoticeTmplId: value.NoticeTmplId}, handled)
+	if err != nil {
+		logc.Error(c.ctx.Ctx, fmt.Sprintf("Failed to create template: %v", err))
+		return nil, nil
+	}
+	msg := make(map[string]interface{})
+	if err := sonic.UnmarshalString(tmpl.CardContentMsg, &msg); err != nil {
+		return nil, err
+	}
+	msg["replace_original"] = true
+	c.postSlackResponse(callback.ResponseUrl, msg)
+
+	return nil, nil
+}
+
+func (c chatOpsService) postSlackResponse(responseUrl string, msg map[string]interface{}) {
+	if responseUrl == "" {
+		return
+	}
+
+	res, err := tools.Post(nil, responseUrl, bytes.NewReader([]byte(tools.JsonMarshalToString(msg))), 10)
+	if err != nil {
+		logc.Error(c.ctx.Ctx, fmt.Sprintf("Failed to update slack message: %v", err))
+		return
+	}
+	res.Body.Close()
+}
+
+func (c chatOpsService) getConfig() (models.ChatOpsConfig, error) {
+	setting, err := c.ctx.DB.Setting().Get()
+	if err != nil {
+		return models.ChatOpsConfig{}, err
+	}
+
+	return setting.ChatOpsConfig, nil
+}
+
+// handleAction 执行卡片操作, 返回最新的事件及处理结果
+func (c chatOpsService) handleAction(config models.ChatOpsConfig, platform string, value models.ChatOpsActionValue, imUserIds ...string) (models.AlertCurEvent, string, error) {
+	if !config.GetEnable() {
+		return models.AlertCurEvent{}, "", fmt.Errorf("未开启卡片交互")
+	}
+
+	member, err := c.getMember(platform, imUserIds...)
+	if err != nil {
+		return models.AlertCurEvent{}, "", err
+	}
+	if !slices.Contains(member.Tenants, value.TenantId) {
+		return models.AlertCurEvent{}, "", fmt.Errorf("用户 %s 无该租户的权限", member.UserName)
+	}
+
+	event, err := c.ctx.Redis.Alert().GetEventFromCache(value.TenantId, value.FaultCenterId, value.Fingerprint)
+	if err != nil {
+		return models.AlertCurEvent{}, "", fmt.Errorf("告警事件不存在或已恢复")
+	}
+
+	if value.Action == models.ChatOpsActionAck {
+		if event.ConfirmState.IsOk {
+			return event, fmt.Sprintf("%s 已认领", event.ConfirmState.ConfirmUsername), nil
+		}
+
+		_, _ = EventService.ProcessAlertEvent(&types.RequestProcessAlertEvent{
+			TenantId:      value.TenantId,
+			FaultCenterId: value.FaultCenterId,
+			Fingerprints:  []string{value.Fingerprint},
+			Time:          time.Now().Unix(),
+			Username:      member.UserName,
+		})
+		if cache, err := c.ctx.Redis.Alert().GetEventFromCache(value.TenantId, value.FaultCenterId, value.Fingerprint); err == nil {
+			event = cache
+		}
+		return event, fmt.Sprintf("%s 已认领", member.UserName), nil
+	}
+
+	duration, ok := value.GetSilenceDuration()
+	if !ok {
+		return models.AlertCurEvent{}, "", fmt.Errorf("不支持的操作: %s", value.Action)
+	}
+
+	now := time.Now()
+	_, e := SilenceService.Create(&types.RequestSilenceCreate{
+		TenantId:      value.TenantId,
+		Name:          event.RuleName,
+		Labels:        []models.SilenceLabel{{Key: "fingerprint", Value: value.Fingerprint, Operator: "=="}},
+		StartsAt:      now.Unix(),
+		EndsAt:        now.Add(duration).Unix(),
+		UpdateBy:      member.UserName,
+		FaultCenterId: value.FaultCenterId,
+		Comment:       fmt.Sprintf("%s 通过 %s 卡片静默", member.UserName, platform),
+	})
+	if e != nil {
+		return models.AlertCurEvent{}, "", e.(error)
+	}
+
+	return event, fmt.Sprintf("%s 已静默 %d 小时", member.UserName, int(duration.Hours())), nil
+}
+
+// getMember 根据 IM 用户 ID 获取用户, 优先匹配 DutyUserId, 其次匹配联系方式
+func (c chatOpsService) getMember(platform string, imUserIds ...string) (models.Member, error) {
+	for _, id := range imUserIds {
+		if id == "" {
+			continue
+		}
+		if member, ok, _ := c.ctx.DB.User().GetByDutyUserId(id); ok {
+			return member, nil
+		}
+	}
+
+	members, err := c.ctx.DB.User().List("", "")
+	if err != nil {
+		return models.Member{}, err
+	}
+	for _, member := range members {
+		if contact := member.GetContact(platform); contact != "" && slices.Contains(imUserIds, contact) {
+			return member, nil
+		}
+	}
+
+	return models.Member{}, fmt.Errorf("未找到 IM 用户对应的 WatchAlert 用户, 请配置用户的 DutyUserId")
+}
@@ -0,0 +1,119 @@
+package services
+
+import (
+	"crypto/hmac"
+	"fmt"
+	"strings"
+	"time"
+	"watchAlert/internal/models"
+	"watchAlert/internal/types"
+	"watchAlert/pkg/tools"
+
+	"github.com/bytedance/sonic"
+	"github.com/zeromicro/go-zero/core/logc"
+)
+
+// PagerDutyCallback 处理 PagerDuty 的事件认领回调, 同步认领状态至 WatchAlert
+func (c chatOpsService) PagerDutyCallback(req interface{}) (interface{}, interface{}) {
+	r := req.(*types.RequestChatOpsCallback)
+	config, err := c.getConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	// PagerDuty 签名: "v1=" + hex(HmacSHA256(secret, body)), 轮换密钥期间可能包含多个签名
+	secret := config.PagerDuty.WebhookSecret
+	if secret == "" {
+		return nil, fmt.Errorf("未配置 PagerDuty Webhook Secret")
+	}
+	expected := []byte("v1=" + tools.HmacSha256(secret, string(r.Body)))
+	var verified bool
+	for _, signature := range strings.Split(r.Signature, ",") {
+		if hmac.Equal([]byte(strings.TrimSpace(signature)), expected) {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return nil, fmt.Errorf("PagerDuty 请求签名校验失败")
+	}
+
+	var callback models.PagerDutyCallback
+	if err := sonic.Unmarshal(r.Body, &callback); err != nil {
+		return nil, err
+	}
+	// 仅处理认领事件
+	if callback.Event.EventType != "incident.acknowledged" {
+		return nil, nil
+	}
+
+	username := callback.Event.Agent.Summary
+	if member, ok, _ := c.ctx.DB.User().Get("", username, "", ""); ok {
+		username = member.UserName
+	}
+
+	return nil, c.ackIncident("PagerDuty", callback.Event.Data.IncidentKey, username)
+}
+
+// OpsgenieCallback 处理 Opsgenie 的告警认领回调, 同步认领状态至 WatchAlert
+func (c chatOpsService) OpsgenieCallback(req interface{}) (interface{}, interface{}) {
+	r := req.(*types.RequestChatOpsCallback)
+	config, err := c.getConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	token := config.Opsgenie.WebhookToken
+	if token == "" {
+		return nil, fmt.Errorf("未配置 Opsgenie Webhook Token")
+	}
+	if !hmac.Equal([]byte(r.Signature), []byte(token)) {
+		return nil, fmt.Errorf("Opsgenie Webhook Token 校验失败")
+	}
+
+	var callback models.OpsgenieCallback
+	if err := sonic.Unmarshal(r.Body, &callback); err != nil {
+		return nil, err
+	}
+	if callback.Action != "Acknowledge" {
+		return nil, nil
+	}
+
+	// Opsgenie 的用户名为邮箱
+	username := callback.Alert.Username
+	if member, ok, _ := c.ctx.DB.User().Get("", "", username, ""); ok {
+		username = member.UserName
+	}
+
+	return nil, c.ackIncident("Opsgenie", callback.Alert.Alias, username)
+}
+
+// ackIncident 根据事件指纹查找告警中的事件并认领, 事件不存在时忽略
+func (c chatOpsService) ackIncident(platform, fingerprint, username string) error {
+	if fingerprint == "" {
+		return nil
+	}
+
+	faultCenters, err := c.ctx.DB.FaultCenter().List("", "")
+	if err != nil {
+		return err
+	}
+
+	for _, faultCenter := range faultCenters {
+		event, err := c.ctx.Redis.Alert().GetEventFromCache(faultCenter.TenantId, faultCenter.ID, fingerprint)
+		if err != nil || event.ConfirmState.IsOk {
+			continue
+		}
+
+		_, _ = EventService.ProcessAlertEvent(&types.RequestProcessAlertEvent{
+			TenantId:      faultCenter.TenantId,
+			FaultCenterId: faultCenter.ID,
+			Fingerprints:  []string{fingerprint},
+			Time:          time.Now().Unix(),
+			Username:      username,
+		})
+		logc.Infof(c.ctx.Ctx, "%s 认领回调, 用户: %s, 故障中心: %s, 指纹: %s", platform, username, faultCenter.ID, fingerprint)
+	}
+
+	return nil
+}
@@ -3,6 +3,8 @@ package services
 import (
 	"fmt"
 	"time"
+	"watchAlert/alert/health"
+	"watchAlert/alert/process"
 	"watchAlert/internal/ctx"
 	"watchAlert/internal/models"
 	"watchAlert/internal/types"
@@ -50,6 +52,7 @@ func (ds datasourceService) Create(req interface{}) (interface{}, interface{}) {
 		UpdateBy:         dataSource.UpdateBy,
 		UpdateAt:         time.Now().Unix(),
 		Enabled:          dataSource.Enabled,
+		HealthAlert:      dataSource.HealthAlert,
 	}
 
 	err := ds.ctx.DB.Datasource().Create(data)
@@ -85,6 +88,7 @@ func (ds datasourceService) Update(req interface{}) (interface{}, interface{}) {
 		UpdateBy:         dataSource.UpdateBy,
 		UpdateAt:         time.Now().Unix(),
 		Enabled:          dataSource.Enabled,
+		HealthAlert:      dataSource.HealthAlert,
 	}
 
 	err := ds.ctx.DB.Datasource().Update(data)
@@ -109,6 +113,13 @@ func (ds datasourceService) Delete(req interface{}) (interface{}, interface{}) {
 
 	ds.WithRemoveClientForProviderPools(dataSource.ID)
 
+	// 恢复已推送的健康告警事件
+	status := ds.ctx.Redis.DatasourceHealth().Get(dataSource.ID)
+	if status.AlertFaultCenterId != "" {
+		process.ResolveExternalEvent(ds.ctx, dataSource.TenantId, status.AlertFaultCenterId, health.DatasourceEventFingerprint(dataSource.ID))
+	}
+	ds.ctx.Redis.DatasourceHealth().Delete(dataSource.ID)
+
 	return nil, nil
 }
 
@@ -130,6 +141,9 @@ func (ds datasourceService) List(req interface{}) (interface{}, interface{}) {
 		return nil, err
 	}
 	newData = data
+	for i := range newData {
+		newData[i].Health = ds.ctx.Redis.DatasourceHealth().Get(newData[i].ID)
+	}
 
 	return newData, nil
 }
@@ -140,6 +154,11 @@ func (ds datasourceService) WithAddClientToProviderPools(datasource models.Alert
 		err error
 	)
 	pools := ds.ctx.Redis.ProviderPools()
+	version := datasource.GetClientVersion()
+	if pools.HasClientVersion(datasource.ID, version) {
+		return nil
+	}
+
 	switch datasource.Type {
 	case provider.PrometheusDsProvider:
 		cli, err = provider.NewPrometheusClient(datasource)
@@ -165,7 +184,19 @@ func (ds datasourceService) WithAddClientToProviderPools(datasource models.Alert
 		return fmt.Errorf("New %s client failed, err: %s", datasource.Type, err.Error())
 	}
 
-	pools.SetClient(datasource.ID, cli)
+	pools.SetClientWithVersion(datasource.ID, version, cli)
+
+	// 配置变更后旧的检查结果不再适用, 结束熔断冷却并视为未知, 由健康检查任务在下一周期重新检查
+	status := ds.ctx.Redis.DatasourceHealth().Get(datasource.ID)
+	if status.Status == models.DatasourceUnhealthy || status.Breaker == models.BreakerOpen {
+		status.Status = models.DatasourceUnknown
+		if status.Breaker == models.BreakerOpen {
+			status.Breaker = models.BreakerHalfOpen
+		}
+		status.OpenUntil = 0
+		ds.ctx.Redis.DatasourceHealth().Set(datasource.ID, status)
+	}
+
 	return nil
 }
 
@@ -0,0 +1,309 @@
+package services
+
+import (
+	"crypto/hmac"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+	"watchAlert/internal/models"
+	"watchAlert/internal/types"
+	"watchAlert/pkg/tools"
+)
+
+const (
+	// icsFeedPath 日历订阅地址, 通过签名 token 鉴权
+	icsFeedPath = "/api/w8t/calendar/ics"
+	// icsFeedLookBack 订阅日历中保留的历史班次
+	icsFeedLookBack = 30 * 24 * time.Hour
+	// icsFeedLookAhead 订阅日历中的未来班次
+	icsFeedLookAhead = 365 * 24 * time.Hour
+)
+
+// IcsToken 获取值班表的日历订阅地址
+func (dms dutyCalendarService) IcsToken(req interface{}) (interface{}, interface{}) {
+	r := req.(*types.RequestDutyIcsQuery)
+	info, err := dms.ctx.DB.DutyCalendar().GetCalendarInfo(r.DutyId)
+	if err != nil || info.TenantId != r.TenantId {
+		return nil, fmt.Errorf("值班表 %s 未发布", r.DutyId)
+	}
+
+	if info.IcsKey == "" {
+		return dms.resetIcsKey(info)
+	}
+
+	return newIcsTokenResponse(info), nil
+}
+
+// IcsTokenReset 重置日历订阅地址, 旧地址立即失效
+func (dms dutyCalendarService) IcsTokenReset(req interface{}) (interface{}, interface{}) {
+	r := req.(*types.RequestDutyIcsQuery)
+	info, err := dms.ctx.DB.DutyCalendar().GetCalendarInfo(r.DutyId)
+	if err != nil || info.TenantId != r.TenantId {
+		return nil, fmt.Errorf("值班表 %s 未发布", r.DutyId)
+	}
+
+	return dms.resetIcsKey(info)
+}
+
+func (dms dutyCalendarService) resetIcsKey(info models.DutyCalendarInfo) (interface{}, interface{}) {
+	key, err := generateApiKey()
+	if err != nil {
+		return nil, err
+	}
+
+	info.IcsKey = key
+	err = dms.ctx.DB.DutyCalendar().UpdateCalendarInfo(models.DutyCalendarInfo{
+		DutyId: info.DutyId,
+		IcsKey: info.IcsKey,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return newIcsTokenResponse(info), nil
+}
+
+func newIcsTokenResponse(info models.DutyCalendarInfo) types.ResponseDutyIcsToken {
+	token := info.DutyId + "." + signIcsToken(info)
+	return types.ResponseDutyIcsToken{
+		Token: token,
+		Path:  icsFeedPath + "?token=" + token,
+	}
+}
+
+// signIcsToken 使用值班表的随机密钥签名, 重置密钥后旧地址失效
+func signIcsToken(info models.DutyCalendarInfo) string {
+	return tools.HmacSha256(info.IcsKey, info.DutyId)
+}
+
+// IcsFeed 生成值班表的 iCalendar 订阅内容
+func (dms dutyCalendarService) IcsFeed(req interface{}) (interface{}, interface{}) {
+	r := req.(*types.RequestDutyIcsQuery)
+
+	idx := strings.LastIndex(r.Token, ".")
+	if idx <= 0 {
+		return nil, fmt.Errorf("无效的订阅地址")
+	}
+	info, err := dms.ctx.DB.DutyCalendar().GetCalendarInfo(r.Token[:idx])
+	if err != nil || info.IcsKey == "" || !hmac.Equal([]byte(r.Token[idx+1:]), []byte(signIcsToken(info))) {
+		return nil, fmt.Errorf("无效的订阅地址")
+	}
+
+	var (
+		now      = time.Now()
+		start    = now.Add(-icsFeedLookBack).Unix()
+		end      = now.Add(icsFeedLookAhead).Unix()
+		calName  = info.DutyId
+		events   []tools.IcsEvent
+		layers   = info.GetLayers()
+		duty, _  = dms.ctx.DB.Duty().Get(info.TenantId, info.DutyId)
+		matchUid = func(users []models.DutyUser) bool {
+			return r.UserId == "" || slices.ContainsFunc(users, func(u models.DutyUser) bool { return u.UserId == r.UserId })
+		}
+	)
+	if duty.Name != "" {
+		calName = duty.Name
+	}
+
+	shifts, err := dms.ctx.DB.DutyCalendar().ListShifts(info.TenantId, info.DutyId, start, end)
+	if err != nil {
+		return nil, err
+	}
+	overrides, err := dms.ctx.DB.DutyCalendar().ListOverrides(info.TenantId, info.DutyId, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	for layer := range layers {
+		for _, segment := range buildDutySegments(shifts, overrides, layer, start, end) {
+			if !matchUid(segment.Users) {
+				continue
+			}
+			segment.DutyId = info.DutyId
+			events = append(events, newDutyIcsEvent(calName, layers[layer].Name, segment))
+		}
+	}
+
+	// 未生成班次的历史值班表按天导出
+	if len(shifts) == 0 {
+		schedules, _ := dms.ctx.DB.DutyCalendar().Search(info.TenantId, info.DutyId, "")
+		for _, schedule := range schedules {
+			day, err := time.ParseInLocation("2006-1-2", schedule.Time, info.GetLocation())
+			if err != nil || day.Unix() < start || day.Unix() > end || !matchUid(schedule.Users) {
+				continue
+			}
+			event := newDutyIcsEvent(calName, layers[0].Name, models.DutyShift{
+				DutyId:    schedule.DutyId,
+				StartTime: day.Unix(),
+				EndTime:   day.AddDate(0, 0, 1).Unix(),
+				Users:     schedule.Users,
+			})
+			event.Start, event.End, event.AllDay = day, day.AddDate(0, 0, 1), true
+			events = append(events, event)
+		}
+	}
+
+	return tools.EncodeIcs(calName, events), nil
+}
+
+// buildDutySegments 合并班次与替班记录, 生成层级实际的值班时间段
+func buildDutySegments(shifts []models.DutyShift, overrides []models.DutyOverride, layer int, start, end int64) []models.DutyShift {
+	points := []int64{start, end}
+	addPoint := func(ts int64) {
+		if ts > start && ts < end {
+			points = append(points, ts)
+		}
+	}
+	for _, shift := range shifts {
+		if shift.Layer == layer {
+			addPoint(shift.StartTime)
+			addPoint(shift.EndTime)
+		}
+	}
+	for _, override := range overrides {
+		if override.Layer == layer {
+			addPoint(override.StartTime)
+			addPoint(override.EndTime)
+		}
+	}
+	sort.Slice(points, func(i, j int) bool { return points[i] < points[j] })
+	points = slices.Compact(points)
+
+	var segments []models.DutyShift
+	for i := 0; i < len(points)-1; i++ {
+		users, status := resolveDutyUsers(shifts, overrides, layer, points[i])
+		if users == nil {
+			continue
+		}
+
+		if n := len(segments); n > 0 && segments[n-1].EndTime == points[i] &&
+			tools.JsonMarshalToString(segments[n-1].Users) == tools.JsonMarshalToString(users) {
+			segments[n-1].EndTime = points[i+1]
+			continue
+		}
+		segments = append(segments, models.DutyShift{
+			Layer:     layer,
+			StartTime: points[i],
+			EndTime:   points[i+1],
+			Users:     users,
+			Status:    status,
+		})
+	}
+
+	return segments
+}
+
+func newDutyIcsEvent(calName, layerName string, shift models.DutyShift) tools.IcsEvent {
+	var names, emails []string
+	for _, user := range shift.Users {
+		names = append(names, user.Username)
+		if user.Email != "" {
+			emails = append(emails, user.Email)
+		}
+	}
+
+	return tools.IcsEvent{
+		UID:         fmt.Sprintf("%s-%d-%d@watchalert", shift.DutyId, shift.Layer, shift.StartTime),
+		Summary:     fmt.Sprintf("[%s] %s 值班: %s", calName, layerName, strings.Join(names, ", ")),
+		Description: fmt.Sprintf("值班表: %s\n值班层级: %s\n值班人员: %s", calName, layerName, strings.Join(names, ", ")),
+		Start:       time.Unix(shift.StartTime, 0),
+		End:         time.Unix(shift.EndTime, 0),
+		Attendees:   emails,
+	}
+}
+
+// IcsImport 导入 ICS 日历中的班次, 值班人员优先按参与人邮箱匹配, 其次按标题中的用户名匹配
+func (dms dutyCalendarService) IcsImport(req interface{}) (interface{}, interface{}) {
+	r := req.(*types.RequestDutyIcsImport)
+	info, err := dms.ctx.DB.DutyCalendar().GetCalendarInfo(r.DutyId)
+	if err != nil || info.TenantId != r.TenantId {
+		return nil, fmt.Errorf("值班表 %s 未发布", r.DutyId)
+	}
+	if r.Status == "" {
+		r.Status = models.CalendarFormalStatus
+	}
+
+	events, err := tools.DecodeIcs(r.Content, info.GetLocation())
+	if err != nil {
+		return nil, fmt.Errorf("解析 ICS 文件失败: %w", err)
+	}
+
+	var (
+		shifts []models.DutyShift
+		result types.ResponseDutyIcsImport
+	)
+	for _, event := range events {
+		if !event.End.After(event.Start) {
+			result.Skipped = append(result.Skipped, event.Summary)
+			continue
+		}
+
+		users := dms.matchIcsUsers(r.TenantId, event)
+		if len(users) == 0 {
+			result.Skipped = append(result.Skipped, event.Summary)
+			continue
+		}
+
+		shift := models.DutyShift{
+			TenantId:  r.TenantId,
+			DutyId:    r.DutyId,
+			Layer:     r.Layer,
+			StartTime: event.Start.Unix(),
+			EndTime:   event.End.Unix(),
+			Users:     users,
+			Status:    r.Status,
+		}
+		shifts = append(shifts, shift)
+	}
+
+	if len(shifts) == 0 {
+		return result, nil
+	}
+
+	// 与导入班次重叠的时间以 ICS 文件为准
+	if err := dms.ctx.DB.DutyCalendar().ReplaceShifts(r.TenantId, r.DutyId, r.Layer, shifts); err != nil {
+		return nil, err
+	}
+
+	result.Imported = len(shifts)
+	return result, nil
+}
+
+// matchIcsUsers 匹配 ICS 事件中的值班人员, 仅匹配租户内的用户
+func (dms dutyCalendarService) matchIcsUsers(tenantId string, event tools.IcsEvent) []models.DutyUser {
+	var users []models.DutyUser
+	for _, email := range event.Attendees {
+		if member, ok, _ := dms.ctx.DB.User().Get("", "", email, ""); ok && slices.Contains(member.Tenants, tenantId) {
+			users = append(users, newDutyUser(member))
+		}
+	}
+	if len(users) > 0 {
+		return users
+	}
+
+	// 标题格式如: 值班: alice, bob
+	summary := strings.ReplaceAll(event.Summary, "：", ":")
+	if idx := strings.LastIndex(summary, ":"); idx >= 0 {
+		summary = summary[idx+1:]
+	}
+	for _, name := range strings.FieldsFunc(summary, func(r rune) bool {
+		return r == ',' || r == '，' || r == '、' || r == '/'
+	}) {
+		if member, ok, _ := dms.ctx.DB.User().Get("", strings.TrimSpace(name), "", ""); ok && slices.Contains(member.Tenants, tenantId) {
+			users = append(users, newDutyUser(member))
+		}
+	}
+
+	return users
+}
+
+func newDutyUser(member models.Member) models.DutyUser {
+	return models.DutyUser{
+		UserId:   member.UserId,
+		Username: member.UserName,
+		Email:    member.Email,
+		Mobile:   member.Phone,
+	}
+}
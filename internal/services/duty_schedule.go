@@ -3,7 +3,6 @@ package services
 import (
 	"context"
 	"fmt"
-	"sync"
 	"time"
 	"watchAlert/internal/ctx"
 	"watchAlert/internal/models"
@@ -24,6 +23,14 @@ type InterDutyCalendarService interface {
 	Update(req interface{}) (interface{}, interface{})
 	Search(req interface{}) (interface{}, interface{})
 	GetCalendarUsers(req interface{}) (interface{}, interface{})
+	ShiftSearch(req interface{}) (interface{}, interface{})
+	OnCall(req interface{}) (interface{}, interface{})
+	OverrideCreate(req interface{}) (interface{}, interface{})
+	OverrideDelete(req interface{}) (interface{}, interface{})
+	IcsToken(req interface{}) (interface{}, interface{})
+	IcsTokenReset(req interface{}) (interface{}, interface{})
+	IcsFeed(req interface{}) (interface{}, interface{})
+	IcsImport(req interface{}) (interface{}, interface{})
 	GenerateNextYearScheduleCronjob(ctx context.Context)
 }
 
@@ -37,14 +44,25 @@ func newInterDutyCalendarService(ctx *ctx.Context) InterDutyCalendarService {
 func (dms dutyCalendarService) CreateAndUpdate(req interface{}) (interface{}, interface{}) {
 	r := req.(*types.RequestDutyCalendarCreate)
 	curYear, curMonth, _ := tools.ParseTime(r.Month)
+	if curYear == 0 {
+		return nil, fmt.Errorf("值班月份格式错误: %s", r.Month)
+	}
+	if r.Timezone != "" {
+		if _, err := time.LoadLocation(r.Timezone); err != nil {
+			return nil, fmt.Errorf("无效的时区: %s", r.Timezone)
+		}
+	}
 
 	var data = models.DutyCalendarInfo{
-		TenantId:   r.TenantId,
-		DutyId:     r.DutyId,
-		DutyPeriod: r.DutyPeriod,
-		Month:      r.Month,
-		UserGroup:  r.UserGroup,
-		DateType:   r.DateType,
+		TenantId:     r.TenantId,
+		DutyId:       r.DutyId,
+		DutyPeriod:   r.DutyPeriod,
+		Month:        r.Month,
+		UserGroup:    r.UserGroup,
+		DateType:     r.DateType,
+		Timezone:     r.Timezone,
+		HandoverTime: r.HandoverTime,
+		Layers:       r.Layers,
 	}
 
 	_, err := dms.ctx.DB.DutyCalendar().GetCalendarInfo(r.DutyId)
@@ -62,42 +80,89 @@ func (dms dutyCalendarService) CreateAndUpdate(req interface{}) (interface{}, in
 		}
 	}
 
-	dutyScheduleList, err := dms.generateDutySchedule(*r, curYear, curMonth)
-	if err != nil {
-		return nil, fmt.Errorf("生成值班表数据失败: %w", err)
+	// 从所选月份的第一个交接班时间生成至年底
+	start := handoverAt(data, curYear, curMonth, 1)
+	end := handoverAt(data, curYear+1, time.January, 1)
+
+	var shifts []models.DutyShift
+	for i, layer := range data.GetLayers() {
+		shifts = append(shifts, generateDutyShifts(data, i, layer, start, end, 0, r.Status)...)
 	}
 
-	if err := dms.updateDutyScheduleInDB(dutyScheduleList, r.TenantId); err != nil {
-		logc.Errorf(dms.ctx.Ctx, "值班表数据入库失败: %w", err)
+	if err := dms.ctx.DB.DutyCalendar().DeleteShifts(r.TenantId, r.DutyId, start.Unix()); err != nil {
+		return nil, fmt.Errorf("清理值班班次失败: %w", err)
+	}
+	if err := dms.ctx.DB.DutyCalendar().CreateShifts(shifts); err != nil {
+		logc.Errorf(dms.ctx.Ctx, "值班班次入库失败: %v", err)
+		return nil, err
 	}
+
 	return nil, nil
 }
 
-// Update 更新值班表
+// Update 调整某一天的值班人员, 以替班记录的方式覆盖当天的班次
 func (dms dutyCalendarService) Update(req interface{}) (interface{}, interface{}) {
 	r := req.(*types.RequestDutyCalendarUpdate)
-	err := dms.ctx.DB.DutyCalendar().Update(models.DutySchedule{
-		TenantId: r.TenantId,
-		DutyId:   r.DutyId,
-		Time:     r.Time,
-		Status:   r.Status,
-		Users:    r.Users,
-	})
+	info, _ := dms.ctx.DB.DutyCalendar().GetCalendarInfo(r.DutyId)
+
+	date, err := time.ParseInLocation("2006-1-2", r.Time, info.GetLocation())
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("值班日期格式错误: %s", r.Time)
 	}
-
-	return nil, nil
+	start := handoverAt(info, date.Year(), date.Month(), date.Day())
+
+	return dms.OverrideCreate(&types.RequestDutyOverrideCreate{
+		TenantId:  r.TenantId,
+		DutyId:    r.DutyId,
+		Layer:     r.Layer,
+		StartTime: start.Unix(),
+		EndTime:   start.AddDate(0, 0, 1).Unix(),
+		Users:     r.Users,
+		Reason:    "值班表调整",
+		CreateBy:  r.UpdateBy,
+	})
 }
 
-// Search 查询值班表
+// Search 查询值班表, 按天展示每天交接班时的值班人员
 func (dms dutyCalendarService) Search(req interface{}) (interface{}, interface{}) {
 	r := req.(*types.RequestDutyCalendarQuery)
-	data, err := dms.ctx.DB.DutyCalendar().Search(r.TenantId, r.DutyId, r.Time)
+	info, _ := dms.ctx.DB.DutyCalendar().GetCalendarInfo(r.DutyId)
+
+	month, err := time.ParseInLocation("2006-1", r.Time, info.GetLocation())
+	if err != nil {
+		return dms.ctx.DB.DutyCalendar().Search(r.TenantId, r.DutyId, r.Time)
+	}
+
+	start := handoverAt(info, month.Year(), month.Month(), 1)
+	end := start.AddDate(0, 1, 0)
+	shifts, err := dms.ctx.DB.DutyCalendar().ListShifts(r.TenantId, r.DutyId, start.Unix(), end.Unix())
+	if err != nil {
+		return nil, err
+	}
+	// 未生成班次的历史值班表
+	if len(shifts) == 0 {
+		return dms.ctx.DB.DutyCalendar().Search(r.TenantId, r.DutyId, r.Time)
+	}
+	overrides, err := dms.ctx.DB.DutyCalendar().ListOverrides(r.TenantId, r.DutyId, start.Unix(), end.Unix())
 	if err != nil {
 		return nil, err
 	}
 
+	var data []models.DutySchedule
+	for day := start; day.Before(end); day = day.AddDate(0, 0, 1) {
+		users, status := resolveDutyUsers(shifts, overrides, r.Layer, day.Unix())
+		if users == nil {
+			continue
+		}
+		data = append(data, models.DutySchedule{
+			TenantId: r.TenantId,
+			DutyId:   r.DutyId,
+			Time:     day.Format("2006-1-2"),
+			Status:   status,
+			Users:    users,
+		})
+	}
+
 	return data, nil
 }
 
@@ -111,114 +176,171 @@ func (dms dutyCalendarService) GetCalendarUsers(req interface{}) (interface{}, i
 	return data, nil
 }
 
-func (dms dutyCalendarService) generateDutySchedule(dutyInfo types.RequestDutyCalendarCreate, year int, month time.Month) ([]models.DutySchedule, error) {
-	dutyDays := dms.calculateDutyDays(dutyInfo.DateType, dutyInfo.DutyPeriod)
-	timeC := dms.generateDutyDates(year, month)
-	dutyScheduleList := dms.createDutyScheduleList(dutyInfo, timeC, dutyDays)
+// ShiftSearch 查询时间范围内的班次及替班记录
+func (dms dutyCalendarService) ShiftSearch(req interface{}) (interface{}, interface{}) {
+	r := req.(*types.RequestDutyCalendarQuery)
+	if r.StartTime == 0 || r.EndTime == 0 {
+		return nil, fmt.Errorf("查询时间范围不能为空")
+	}
+
+	shifts, err := dms.ctx.DB.DutyCalendar().ListShifts(r.TenantId, r.DutyId, r.StartTime, r.EndTime)
+	if err != nil {
+		return nil, err
+	}
+	overrides, err := dms.ctx.DB.DutyCalendar().ListOverrides(r.TenantId, r.DutyId, r.StartTime, r.EndTime)
+	if err != nil {
+		return nil, err
+	}
 
-	return dutyScheduleList, nil
+	return types.ResponseDutyShiftList{
+		Shifts:    shifts,
+		Overrides: overrides,
+	}, nil
 }
 
-// 计算值班天数
-func (dms dutyCalendarService) calculateDutyDays(dateType string, dutyPeriod int) int {
-	switch dateType {
-	case "day":
-		return dutyPeriod
-	case "week":
-		return 7 * dutyPeriod
-	case "month":
-		return 31 * dutyPeriod
-	case "year":
-		return 365 * dutyPeriod
-	default:
-		return 0
+// OnCall 查询指定时间点的值班人员, 未指定时为当前时间
+func (dms dutyCalendarService) OnCall(req interface{}) (interface{}, interface{}) {
+	r := req.(*types.RequestDutyCalendarQuery)
+	at := time.Now()
+	if r.At != 0 {
+		at = time.Unix(r.At, 0)
 	}
+
+	users, _ := dms.ctx.DB.DutyCalendar().GetDutyUserData(r.DutyId, at)
+	return users, nil
 }
 
-// 生成值班日期
-func (dms dutyCalendarService) generateDutyDates(year int, startMonth time.Month) <-chan string {
-	timeC := make(chan string, 370)
-	var wg sync.WaitGroup
-	wg.Add(1)
-	go func() {
-		defer close(timeC)
-		defer wg.Done()
-		for month := startMonth; month <= 12; month++ {
-			daysInMonth := time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
-			for day := 1; day <= daysInMonth; day++ {
-				date := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
-				if date.Month() != month {
-					break
-				}
-				timeC <- date.Format("2006-1-2")
-			}
-		}
-	}()
+// OverrideCreate 创建替班/换班记录
+func (dms dutyCalendarService) OverrideCreate(req interface{}) (interface{}, interface{}) {
+	r := req.(*types.RequestDutyOverrideCreate)
+	if r.StartTime >= r.EndTime {
+		return nil, fmt.Errorf("替班结束时间需晚于开始时间")
+	}
+	if len(r.Users) == 0 {
+		return nil, fmt.Errorf("替班人员不能为空")
+	}
 
-	// 等待所有日期生产完成
-	wg.Wait()
-	return timeC
+	err := dms.ctx.DB.DutyCalendar().CreateOverride(models.DutyOverride{
+		TenantId:  r.TenantId,
+		ID:        "do-" + tools.RandId(),
+		DutyId:    r.DutyId,
+		Layer:     r.Layer,
+		StartTime: r.StartTime,
+		EndTime:   r.EndTime,
+		Users:     r.Users,
+		Reason:    r.Reason,
+		CreateBy:  r.CreateBy,
+		CreateAt:  time.Now().Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, nil
 }
 
-// 创建值班表
-func (dms dutyCalendarService) createDutyScheduleList(dutyInfo types.RequestDutyCalendarCreate, timeC <-chan string, dutyDays int) []models.DutySchedule {
-	var dutyScheduleList []models.DutySchedule
+// OverrideDelete 删除替班/换班记录
+func (dms dutyCalendarService) OverrideDelete(req interface{}) (interface{}, interface{}) {
+	r := req.(*types.RequestDutyOverrideDelete)
+	err := dms.ctx.DB.DutyCalendar().DeleteOverride(r.TenantId, r.ID)
+	if err != nil {
+		return nil, err
+	}
 
-	for {
-		// 数据消费完成后退出
-		if len(timeC) == 0 {
-			break
-		}
+	return nil, nil
+}
 
-		// 每个用户组独立计数，循环开始时重置
-		var count int
+// handoverAt 值班表时区下指定日期的交接班时间
+func handoverAt(info models.DutyCalendarInfo, year int, month time.Month, day int) time.Time {
+	hour, minute := info.GetHandover()
+	return time.Date(year, month, day, hour, minute, 0, 0, info.GetLocation())
+}
 
-		for _, users := range dutyInfo.UserGroup {
-			for day := 1; day <= dutyDays; day++ {
-				date, ok := <-timeC
-				if !ok {
-					return dutyScheduleList
-				}
+// generateDutyShifts 生成层级在 [start, end) 内的班次, firstGroup 为第一个班次的值班组
+func generateDutyShifts(info models.DutyCalendarInfo, layerIndex int, layer models.DutyLayer, start, end time.Time, firstGroup int, status string) []models.DutyShift {
+	if len(layer.UserGroup) == 0 || layer.DutyPeriod <= 0 {
+		return nil
+	}
 
-				dutyScheduleList = append(dutyScheduleList, models.DutySchedule{
-					DutyId: dutyInfo.DutyId,
-					Time:   date,
-					Users:  users,
-					Status: dutyInfo.Status,
-				})
-
-				if dutyInfo.DateType == "week" && tools.IsEndOfWeek(date) {
-					count++
-					if count == dutyInfo.DutyPeriod {
-						count = 0
-						break
-					}
-				}
-			}
+	var (
+		shifts []models.DutyShift
+		group  = firstGroup % len(layer.UserGroup)
+	)
+	for cur := start; cur.Before(end); {
+		next := nextHandover(layer, cur)
+		if !next.After(cur) {
+			break
 		}
+
+		shifts = append(shifts, models.DutyShift{
+			TenantId:  info.TenantId,
+			DutyId:    info.DutyId,
+			Layer:     layerIndex,
+			StartTime: cur.Unix(),
+			EndTime:   next.Unix(),
+			Users:     layer.UserGroup[group],
+			Status:    status,
+		})
+
+		group = (group + 1) % len(layer.UserGroup)
+		cur = next
 	}
 
-	return dutyScheduleList
+	return shifts
 }
 
-// 更新库表
-func (dms dutyCalendarService) updateDutyScheduleInDB(dutyScheduleList []models.DutySchedule, tenantId string) error {
-	for _, schedule := range dutyScheduleList {
-		schedule.TenantId = tenantId
-		dutyScheduleInfo := dms.ctx.DB.DutyCalendar().GetCalendarData(schedule.DutyId, schedule.Time)
-
-		var err error
-		if dutyScheduleInfo.Time != "" {
-			err = dms.ctx.DB.DutyCalendar().Update(schedule)
-		} else {
-			err = dms.ctx.DB.DutyCalendar().Create(schedule)
+// nextHandover 计算班次的结束时间
+// 按天及以上轮换时使用日历时间, 夏令时切换时仍保持交接班时间不变; 按周轮换时每周一交接班
+func nextHandover(layer models.DutyLayer, cur time.Time) time.Time {
+	switch layer.DateType {
+	case "hour":
+		return cur.Add(time.Duration(layer.DutyPeriod) * time.Hour)
+	case "day":
+		return cur.AddDate(0, 0, layer.DutyPeriod)
+	case "week":
+		days := (8 - int(cur.Weekday())) % 7
+		if days == 0 {
+			days = 7
 		}
+		return cur.AddDate(0, 0, days+7*(layer.DutyPeriod-1))
+	case "month":
+		return cur.AddDate(0, layer.DutyPeriod, 0)
+	case "year":
+		return cur.AddDate(layer.DutyPeriod, 0, 0)
+	default:
+		return cur
+	}
+}
 
-		if err != nil {
-			return fmt.Errorf("更新/创建值班系统失败: %w", err)
+// resolveDutyUsers 获取层级在指定时间点的值班人员, 替班记录优先于班次
+func resolveDutyUsers(shifts []models.DutyShift, overrides []models.DutyOverride, layer int, ts int64) ([]models.DutyUser, string) {
+	var (
+		latest *models.DutyOverride
+		status string
+		users  []models.DutyUser
+	)
+	for _, shift := range shifts {
+		if shift.Layer == layer && shift.StartTime <= ts && ts < shift.EndTime {
+			users, status = shift.Users, shift.Status
+			break
+		}
+	}
+	for i, override := range overrides {
+		if override.Layer != layer || override.StartTime > ts || ts >= override.EndTime {
+			continue
+		}
+		if latest == nil || override.CreateAt >= latest.CreateAt {
+			latest = &overrides[i]
 		}
 	}
-	return nil
+	if latest != nil {
+		users = latest.Users
+		if status == "" {
+			status = models.CalendarFormalStatus
+		}
+	}
+
+	return users, status
 }
 
 // GenerateNextYearScheduleCronjob 每年12月1日生成下一年的值班表
@@ -250,7 +372,7 @@ func (dms dutyCalendarService) GenerateNextYearScheduleCronjob(ctx context.Conte
 	c.Stop()
 }
 
-// generateNextYearSchedule 根据历史数据生成下一年的值班表
+// generateNextYearSchedule 根据历史数据生成下一年的值班班次
 func (dms dutyCalendarService) generateNextYearSchedule(ctx context.Context) {
 	now := time.Now().UTC()
 	currentYear := now.Year()
@@ -277,21 +399,19 @@ func (dms dutyCalendarService) generateNextYearSchedule(ctx context.Context) {
 			}
 
 			for _, info := range infos {
-				dutyScheduleList, err := dms.generateDutySchedule(types.RequestDutyCalendarCreate{
-					TenantId:   info.TenantId,
-					DutyId:     info.DutyId,
-					DutyPeriod: info.DutyPeriod,
-					Month:      info.Month,
-					UserGroup:  info.UserGroup,
-					DateType:   info.DateType,
-				}, nextYear, time.January)
-				if err != nil {
-					logc.Errorf(subCtx, "生成值班表数据失败, TenantId: %s, DutyId: %s, Err: %w", info.TenantId, info.DutyId, err)
-					continue
+				end := handoverAt(info, nextYear+1, time.January, 1)
+
+				var shifts []models.DutyShift
+				for i, layer := range info.GetLayers() {
+					start, firstGroup, status := dms.getNextShiftStart(info, i, layer, nextYear)
+					if !start.Before(end) {
+						continue
+					}
+					shifts = append(shifts, generateDutyShifts(info, i, layer, start, end, firstGroup, status)...)
 				}
 
-				if err := dms.updateDutyScheduleInDB(dutyScheduleList, info.TenantId); err != nil {
-					logc.Errorf(subCtx, "值班表数据入库失败, TenantId: %s, DutyId: %s, Err: %w", info.TenantId, info.DutyId, err)
+				if err := dms.ctx.DB.DutyCalendar().CreateShifts(shifts); err != nil {
+					logc.Errorf(subCtx, "值班班次入库失败, TenantId: %s, DutyId: %s, Err: %s", info.TenantId, info.DutyId, err.Error())
 				}
 			}
 			return nil
@@ -302,3 +422,20 @@ func (dms dutyCalendarService) generateNextYearSchedule(ctx context.Context) {
 
 	logc.Infof(ctx, "年度值班表生成任务完成")
 }
+
+// getNextShiftStart 从层级最后一个班次结束时继续轮换, 保证跨年值班顺序连续
+func (dms dutyCalendarService) getNextShiftStart(info models.DutyCalendarInfo, layerIndex int, layer models.DutyLayer, year int) (time.Time, int, string) {
+	last, err := dms.ctx.DB.DutyCalendar().GetLastShift(info.TenantId, info.DutyId, layerIndex)
+	if err != nil || last.EndTime == 0 {
+		return handoverAt(info, year, time.January, 1), 0, models.CalendarFormalStatus
+	}
+
+	lastUsers := tools.JsonMarshalToString(last.Users)
+	for i, users := range layer.UserGroup {
+		if tools.JsonMarshalToString(users) == lastUsers {
+			return time.Unix(last.EndTime, 0).In(info.GetLocation()), i + 1, last.Status
+		}
+	}
+
+	return time.Unix(last.EndTime, 0).In(info.GetLocation()), 0, last.Status
+}
@@ -39,6 +39,8 @@ var (
 	ApiKeyService             InterApiKeyService
 	RecordingRuleService      InterRecordingRuleService
 	RecordingRuleGroupService InterRecordingRuleGroupService
+	AlertReceiverService      InterAlertReceiverService
+	ChatOpsService            InterChatOpsService
 )
 
 func NewServices(ctx *ctx.Context) {
@@ -74,4 +76,6 @@ func NewServices(ctx *ctx.Context) {
 	ApiKeyService = newInterApiKeyService(ctx)
 	RecordingRuleService = newInterRecordingRuleService(ctx)
 	RecordingRuleGroupService = newInterRecordingRuleGroupService(ctx)
+	AlertReceiverService = newInterAlertReceiverService(ctx)
+	ChatOpsService = newInterChatOpsService(ctx)
 }
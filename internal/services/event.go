@@ -1,6 +1,7 @@
 package services
 
 import (
+	"errors"
 	"fmt"
 	"sort"
 	"strings"
@@ -20,6 +21,8 @@ type InterEventService interface {
 	ListCurrentEvent(req interface{}) (interface{}, interface{})
 	ListHistoryEvent(req interface{}) (interface{}, interface{})
 	ProcessAlertEvent(req interface{}) (interface{}, interface{})
+	AckLinkInfo(req interface{}) (interface{}, interface{})
+	AckByLink(req interface{}) (interface{}, interface{})
 	DeleteAlertEvent(req interface{}) (interface{}, interface{})
 
 	ListComments(req interface{}) (interface{}, interface{})
@@ -36,13 +39,20 @@ func newInterEventService(ctx *ctx.Context) InterEventService {
 func (e eventService) ProcessAlertEvent(req interface{}) (interface{}, interface{}) {
 	r := req.(*types.RequestProcessAlertEvent)
 
-	var wg sync.WaitGroup
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
 	wg.Add(len(r.Fingerprints))
 	for _, fingerprint := range r.Fingerprints {
 		go func(fingerprint string) {
 			defer wg.Done()
 			cache, err := e.ctx.Redis.Alert().GetEventFromCache(r.TenantId, r.FaultCenterId, fingerprint)
 			if err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("认领告警事件失败, fingerprint: %s, err: %v", fingerprint, err))
+				mu.Unlock()
 				return
 			}
 
@@ -59,6 +69,9 @@ func (e eventService) ProcessAlertEvent(req interface{}) (interface{}, interface
 	}
 
 	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
 	return nil, nil
 }
 
@@ -105,6 +118,7 @@ func (e eventService) ListCurrentEvent(req interface{}) (interface{}, interface{
 		form = curTime.Add(-time.Duration(r.Scope) * 24 * time.Hour).Unix()
 	}
 
+	faultCenter := e.ctx.Redis.FaultCenter().GetFaultCenterInfo(models.BuildFaultCenterInfoCacheKey(r.TenantId, r.FaultCenterId))
 	for _, event := range allEvents {
 		if r.DatasourceType != "" && event.DatasourceType != r.DatasourceType {
 			continue
@@ -126,7 +140,8 @@ func (e eventService) ListCurrentEvent(req interface{}) (interface{}, interface{
 			continue
 		}
 
-		if !matchStatus(&event, r.Status, mute.MuteParams{TenantId: r.TenantId, FaultCenterId: event.FaultCenterId, Labels: event.Labels}) {
+		_, inhibited := mute.IsInhibited(faultCenter.InhibitRules, &event, center)
+		if !matchStatus(&event, r.Status, mute.MuteParams{TenantId: r.TenantId, FaultCenterId: event.FaultCenterId, Labels: event.Labels}, inhibited) {
 			continue
 		}
 
@@ -187,11 +202,14 @@ func matchQuery(event models.AlertCurEvent, query string) bool {
 	return false
 }
 
-func matchStatus(event *models.AlertCurEvent, status string, muteParams mute.MuteParams) bool {
+func matchStatus(event *models.AlertCurEvent, status string, muteParams mute.MuteParams, inhibited bool) bool {
 	if status == "" {
 		if event.ConfirmState.IsOk {
 			event.Status = "processing"
 		}
+		if inhibited {
+			event.Status = "inhibited"
+		}
 		if mute.IsSilence(muteParams) {
 			event.Status = "muting"
 		}
@@ -203,6 +221,9 @@ func matchStatus(event *models.AlertCurEvent, status string, muteParams mute.Mut
 		if event.ConfirmState.IsOk {
 			event.Status = "processing"
 		}
+		if inhibited {
+			event.Status = "inhibited"
+		}
 		if mute.IsSilence(muteParams) {
 			event.Status = "muting"
 		}
@@ -219,6 +240,12 @@ func matchStatus(event *models.AlertCurEvent, status string, muteParams mute.Mut
 			return true
 		}
 		return false
+	case "inhibited":
+		if inhibited {
+			event.Status = "inhibited"
+			return true
+		}
+		return false
 	default:
 		return true
 	}
@@ -280,6 +307,7 @@ func (e eventService) AddComment(req interface{}) (interface{}, interface{}) {
 	if err != nil {
 		return nil, fmt.Errorf("评论失败, %s", err.Error())
 	}
+	e.postTicketComment(r)
 
 	return "评论成功", nil
 }
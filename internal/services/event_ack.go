@@ -0,0 +1,115 @@
+package services
+
+import (
+	"fmt"
+	"time"
+	"watchAlert/internal/models"
+	"watchAlert/internal/types"
+	"watchAlert/pkg/tools"
+)
+
+const (
+	// ackLinkPath 一键认领链接地址
+	ackLinkPath = "/api/w8t/event/ack"
+	// ackLinkUsedKey 已使用的一键认领链接, 过期时间与链接有效期一致
+	ackLinkUsedKey = "w8t:ackLink:"
+)
+
+// AckLinkInfo 获取一键认领链接对应的事件, 用于认领前的确认页面, 不会使链接失效
+func (e eventService) AckLinkInfo(req interface{}) (interface{}, interface{}) {
+	r := req.(*types.RequestEventAckLink)
+	claims, event, err := e.parseAckLink(r.Token)
+	if err != nil {
+		return nil, err
+	}
+
+	return newAckLinkResponse(claims, event), nil
+}
+
+// AckByLink 通过一键认领链接认领事件, 认领人为链接绑定的接收人
+func (e eventService) AckByLink(req interface{}) (interface{}, interface{}) {
+	r := req.(*types.RequestEventAckLink)
+	claims, event, err := e.parseAckLink(r.Token)
+	if err != nil {
+		return nil, err
+	}
+
+	// 链接仅可使用一次
+	ttl := time.Until(time.Unix(claims.ExpiresAt, 0))
+	ok, err := e.ctx.Redis.Redis().SetNX(ackLinkUsedKey+claims.Id, claims.Username, ttl).Result()
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("链接已使用")
+	}
+
+	// 已被他人认领时不重复认领
+	if event.ConfirmState.IsOk {
+		return newAckLinkResponse(claims, event), nil
+	}
+
+	_, ackErr := e.ProcessAlertEvent(&types.RequestProcessAlertEvent{
+		TenantId:      claims.TenantId,
+		FaultCenterId: claims.FaultCenterId,
+		Fingerprints:  []string{claims.Fingerprint},
+		Time:          time.Now().Unix(),
+		Username:      claims.Username,
+	})
+	if ackErr != nil {
+		// 认领失败时链接仍可再次使用
+		e.ctx.Redis.Redis().Del(ackLinkUsedKey + claims.Id)
+		return nil, ackErr
+	}
+	event.ConfirmState.IsOk = true
+	event.ConfirmState.ConfirmUsername = claims.Username
+
+	err = e.ctx.DB.AuditLog().Create(models.AuditLog{
+		TenantId:   claims.TenantId,
+		ID:         tools.RandId(),
+		Username:   claims.Username,
+		IPAddress:  r.IPAddress,
+		Method:     "POST",
+		Path:       ackLinkPath,
+		CreatedAt:  time.Now().Unix(),
+		StatusCode: 200,
+		Body:       tools.JsonMarshalToString(map[string]string{"faultCenterId": claims.FaultCenterId, "fingerprint": claims.Fingerprint, "ruleName": event.RuleName}),
+		AuditType:  models.AuditEventMap[ackLinkPath],
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return newAckLinkResponse(claims, event), nil
+}
+
+// parseAckLink 校验链接签名及有效期, 并获取对应的事件
+func (e eventService) parseAckLink(token string) (tools.AckLinkClaims, models.AlertCurEvent, error) {
+	key, err := tools.AckSignKey(e.ctx.Redis.Redis())
+	if err != nil {
+		return tools.AckLinkClaims{}, models.AlertCurEvent{}, err
+	}
+
+	claims, err := tools.ParseAckToken(key, token)
+	if err != nil {
+		return claims, models.AlertCurEvent{}, fmt.Errorf("链接无效或已过期")
+	}
+
+	event, err := e.ctx.Redis.Alert().GetEventFromCache(claims.TenantId, claims.FaultCenterId, claims.Fingerprint)
+	if err != nil {
+		return claims, models.AlertCurEvent{}, fmt.Errorf("告警事件不存在或已恢复")
+	}
+
+	return claims, event, nil
+}
+
+func newAckLinkResponse(claims tools.AckLinkClaims, event models.AlertCurEvent) types.ResponseEventAckLink {
+	return types.ResponseEventAckLink{
+		RuleName:        event.RuleName,
+		Severity:        event.Severity,
+		Annotations:     event.Annotations,
+		Username:        claims.Username,
+		ConfirmUsername: event.ConfirmState.ConfirmUsername,
+		Acked:           event.ConfirmState.IsOk,
+	}
+}
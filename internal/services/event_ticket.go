@@ -0,0 +1,33 @@
+package services
+
+import (
+	"fmt"
+	"watchAlert/internal/types"
+	"watchAlert/pkg/ticket"
+
+	"github.com/zeromicro/go-zero/core/logc"
+)
+
+// postTicketComment 将事件评论同步至事件对应的工单
+func (e eventService) postTicketComment(r *types.RequestAddEventComment) {
+	event, err := e.ctx.Redis.Alert().GetEventFromCache(r.TenantId, r.FaultCenterId, r.Fingerprint)
+	if err != nil || event.TicketKey == "" {
+		return
+	}
+
+	faultCenter, err := e.ctx.DB.FaultCenter().Get(r.TenantId, r.FaultCenterId, "")
+	if err != nil || !faultCenter.TicketConfig.GetEnabled() {
+		return
+	}
+
+	ticketer, err := ticket.NewTicketer(faultCenter.TicketConfig)
+	if err != nil {
+		return
+	}
+
+	go func() {
+		if err := ticketer.Comment(event.TicketKey, event, r.Username, r.Content); err != nil {
+			logc.Error(e.ctx.Ctx, fmt.Sprintf("同步工单评论失败, ticket: %s, err: %v", event.TicketKey, err))
+		}
+	}()
+}
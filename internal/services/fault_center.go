@@ -1,12 +1,15 @@
 package services
 
 import (
+	"fmt"
 	"time"
 	"watchAlert/alert"
+	"watchAlert/alert/mute"
 	"watchAlert/internal/ctx"
 	"watchAlert/internal/models"
 	"watchAlert/internal/types"
 	"watchAlert/pkg/client"
+	"watchAlert/pkg/ticket"
 	"watchAlert/pkg/tools"
 )
 
@@ -34,6 +37,16 @@ func newInterFaultCenterService(ctx *ctx.Context) InterFaultCenterService {
 
 func (f faultCenterService) Create(req interface{}) (data interface{}, err interface{}) {
 	r := req.(*types.RequestFaultCenterCreate)
+	if err := validateInhibitRules(r.InhibitRules); err != nil {
+		return nil, err
+	}
+	if err := validateUpgradeStrategy(r.UpgradeStrategy); err != nil {
+		return nil, err
+	}
+	if err := validateTicketConfig(r.TicketConfig); err != nil {
+		return nil, err
+	}
+
 	fc := models.FaultCenter{
 		TenantId:             r.TenantId,
 		ID:                   "fc-" + tools.RandId(),
@@ -49,6 +62,9 @@ func (f faultCenterService) Create(req interface{}) (data interface{}, err inter
 		IsUpgradeEnabled:     r.IsUpgradeEnabled,
 		UpgradableSeverity:   r.UpgradableSeverity,
 		UpgradeStrategy:      r.UpgradeStrategy,
+		GroupStrategy:        r.GroupStrategy,
+		InhibitRules:         r.InhibitRules,
+		TicketConfig:         r.TicketConfig,
 	}
 
 	err = f.ctx.DB.FaultCenter().Create(fc)
@@ -58,12 +74,12 @@ func (f faultCenterService) Create(req interface{}) (data interface{}, err inter
 
 	f.ctx.Redis.FaultCenter().PushFaultCenterInfo(fc)
 
-	// 判断当前节点角色
-	if alert.IsLeader() {
-		// Leader: 直接启动消费协程
+	// 判断当前节点是否负责该任务
+	if alert.IsOwner(fc.ID) {
+		// 负责节点: 直接启动消费协程
 		alert.ConsumerWork.Submit(fc)
 	} else {
-		// Follower: 发布消息通知 Leader
+		// 其他节点: 发布消息通知负责节点
 		tools.PublishReloadMessage(f.ctx.Ctx, client.Redis, tools.ChannelFaultCenterReload, tools.ReloadMessage{
 			Action:   tools.ActionCreate,
 			ID:       fc.ID,
@@ -77,6 +93,16 @@ func (f faultCenterService) Create(req interface{}) (data interface{}, err inter
 
 func (f faultCenterService) Update(req interface{}) (data interface{}, err interface{}) {
 	r := req.(*types.RequestFaultCenterUpdate)
+	if err := validateInhibitRules(r.InhibitRules); err != nil {
+		return nil, err
+	}
+	if err := validateUpgradeStrategy(r.UpgradeStrategy); err != nil {
+		return nil, err
+	}
+	if err := validateTicketConfig(r.TicketConfig); err != nil {
+		return nil, err
+	}
+
 	fc := models.FaultCenter{
 		TenantId:             r.TenantId,
 		ID:                   r.ID,
@@ -92,6 +118,9 @@ func (f faultCenterService) Update(req interface{}) (data interface{}, err inter
 		IsUpgradeEnabled:     r.IsUpgradeEnabled,
 		UpgradableSeverity:   r.UpgradableSeverity,
 		UpgradeStrategy:      r.UpgradeStrategy,
+		GroupStrategy:        r.GroupStrategy,
+		InhibitRules:         r.InhibitRules,
+		TicketConfig:         r.TicketConfig,
 	}
 
 	err = f.ctx.DB.FaultCenter().Update(fc)
@@ -101,13 +130,13 @@ func (f faultCenterService) Update(req interface{}) (data interface{}, err inter
 
 	f.ctx.Redis.FaultCenter().PushFaultCenterInfo(fc)
 
-	// 判断当前节点角色
-	if alert.IsLeader() {
-		// Leader: 直接重启消费协程
+	// 判断当前节点是否负责该任务
+	if alert.IsOwner(fc.ID) {
+		// 负责节点: 直接重启消费协程
 		alert.ConsumerWork.Stop(r.ID)
 		alert.ConsumerWork.Submit(fc)
 	} else {
-		// Follower: 发布消息通知 Leader
+		// 其他节点: 发布消息通知负责节点
 		tools.PublishReloadMessage(f.ctx.Ctx, client.Redis, tools.ChannelFaultCenterReload, tools.ReloadMessage{
 			Action:   tools.ActionUpdate,
 			ID:       fc.ID,
@@ -128,12 +157,12 @@ func (f faultCenterService) Delete(req interface{}) (data interface{}, err inter
 
 	f.ctx.Redis.FaultCenter().RemoveFaultCenterInfo(models.BuildFaultCenterInfoCacheKey(r.TenantId, r.ID))
 
-	// 判断当前节点角色
-	if alert.IsLeader() {
-		// Leader: 直接停止消费协程
+	// 判断当前节点是否负责该任务
+	if alert.IsOwner(r.ID) {
+		// 负责节点: 直接停止消费协程
 		alert.ConsumerWork.Stop(r.ID)
 	} else {
-		// Follower: 发布消息通知 Leader
+		// 其他节点: 发布消息通知负责节点
 		tools.PublishReloadMessage(f.ctx.Ctx, client.Redis, tools.ChannelFaultCenterReload, tools.ReloadMessage{
 			Action:   tools.ActionDelete,
 			ID:       r.ID,
@@ -199,13 +228,13 @@ func (f faultCenterService) Reset(req interface{}) (data interface{}, err interf
 	}
 	f.ctx.Redis.FaultCenter().PushFaultCenterInfo(data.(models.FaultCenter))
 
-	// 判断当前节点角色
-	if alert.IsLeader() {
-		// Leader: 直接重启消费协程
+	// 判断当前节点是否负责该任务
+	if alert.IsOwner(r.ID) {
+		// 负责节点: 直接重启消费协程
 		alert.ConsumerWork.Stop(r.ID)
 		alert.ConsumerWork.Submit(data.(models.FaultCenter))
 	} else {
-		// Follower: 发布消息通知 Leader
+		// 其他节点: 发布消息通知负责节点
 		tools.PublishReloadMessage(f.ctx.Ctx, client.Redis, tools.ChannelFaultCenterReload, tools.ReloadMessage{
 			Action:   tools.ActionUpdate,
 			ID:       r.ID,
@@ -302,3 +331,51 @@ func (f faultCenterService) calculateMTTARange(eventsList []models.AlertHisEvent
 	}
 	return totalRespTime / float64(ackCount), nil
 }
+
+// validateInhibitRules 校验抑制规则
+func validateInhibitRules(rules []models.InhibitRule) error {
+	for i, rule := range rules {
+		if len(rule.SourceMatchers) == 0 || len(rule.TargetMatchers) == 0 {
+			return fmt.Errorf("抑制规则 [%d] 的源条件和目标条件不能为空", i+1)
+		}
+		for _, matcher := range append(rule.SourceMatchers, rule.TargetMatchers...) {
+			switch matcher.Operator {
+			case "==", "=", "!=":
+			case "=~", "!~":
+				if _, err := mute.CompileMatcher(matcher.Value); err != nil {
+					return fmt.Errorf("抑制规则 [%d] 正则表达式 %s 无效: %v", i+1, matcher.Value, err)
+				}
+			default:
+				return fmt.Errorf("抑制规则 [%d] 不支持的运算符: %s", i+1, matcher.Operator)
+			}
+		}
+	}
+	return nil
+}
+
+// validateUpgradeStrategy 校验升级级别, 每个级别需指定通知对象或值班表
+func validateUpgradeStrategy(strategy models.UpgradeStrategy) error {
+	for i, level := range strategy.Levels {
+		if level.NoticeId == "" && level.DutyId == "" {
+			return fmt.Errorf("升级级别 [%d] 需指定通知对象或值班表", i+1)
+		}
+	}
+	if strategy.Acked != nil && strategy.Acked.NoticeId == "" && strategy.Acked.DutyId == "" {
+		return fmt.Errorf("已认领阶段需指定通知对象或值班表")
+	}
+	return nil
+}
+
+// validateTicketConfig 校验工单配置
+func validateTicketConfig(config models.TicketConfig) error {
+	if !config.GetEnabled() {
+		return nil
+	}
+	if config.Duration < 0 {
+		return fmt.Errorf("工单的告警持续时长不能小于 0")
+	}
+	if _, err := ticket.NewTicketer(config); err != nil {
+		return err
+	}
+	return nil
+}
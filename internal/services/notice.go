@@ -26,6 +26,9 @@ type InterNoticeService interface {
 	ListRecord(req interface{}) (interface{}, interface{})
 	GetRecordMetric(req interface{}) (interface{}, interface{})
 	DeleteRecord(req interface{}) (interface{}, interface{})
+	ListDeadLetter(req interface{}) (interface{}, interface{})
+	ReplayDeadLetter(req interface{}) (interface{}, interface{})
+	DeleteDeadLetter(req interface{}) (interface{}, interface{})
 	Test(req interface{}) (interface{}, interface{})
 }
 
@@ -52,13 +55,14 @@ func (n noticeService) Create(req interface{}) (interface{}, interface{}) {
 	}
 
 	err := n.ctx.DB.Notice().Create(models.AlertNotice{
-		TenantId: r.TenantId,
-		Uuid:     "n-" + tools.RandId(),
-		Name:     r.Name,
-		DutyId:   r.DutyId,
-		Routes:   r.Routes,
-		UpdateAt: time.Now().Unix(),
-		UpdateBy: r.UpdateBy,
+		TenantId:  r.TenantId,
+		Uuid:      "n-" + tools.RandId(),
+		Name:      r.Name,
+		DutyId:    r.DutyId,
+		Routes:    r.Routes,
+		RateLimit: r.RateLimit,
+		UpdateAt:  time.Now().Unix(),
+		UpdateBy:  r.UpdateBy,
 	})
 	if err != nil {
 		return nil, err
@@ -69,13 +73,14 @@ func (n noticeService) Create(req interface{}) (interface{}, interface{}) {
 func (n noticeService) Update(req interface{}) (interface{}, interface{}) {
 	r := req.(*types.RequestNoticeUpdate)
 	err := n.ctx.DB.Notice().Update(models.AlertNotice{
-		TenantId: r.TenantId,
-		Uuid:     r.Uuid,
-		Name:     r.Name,
-		DutyId:   r.GetDutyId(),
-		Routes:   r.Routes,
-		UpdateAt: time.Now().Unix(),
-		UpdateBy: r.UpdateBy,
+		TenantId:  r.TenantId,
+		Uuid:      r.Uuid,
+		Name:      r.Name,
+		DutyId:    r.GetDutyId(),
+		Routes:    r.Routes,
+		RateLimit: r.RateLimit,
+		UpdateAt:  time.Now().Unix(),
+		UpdateBy:  r.UpdateBy,
 	})
 	if err != nil {
 		return nil, err
@@ -121,6 +126,46 @@ func (n noticeService) DeleteRecord(req interface{}) (interface{}, interface{})
 	return nil, nil
 }
 
+// ListDeadLetter 获取超过最大重试次数的通知任务
+func (n noticeService) ListDeadLetter(req interface{}) (interface{}, interface{}) {
+	r := req.(*types.RequestNoticeDeadLetterQuery)
+	data, err := n.ctx.DB.NoticeQueue().ListDead(r.TenantId, r.NoticeType, r.Query, r.Page)
+	if err != nil {
+		return nil, err
+	}
+	for i := range data.List {
+		data.List[i].Params = mediums.RedactParams(data.List[i].Params)
+	}
+
+	return data, nil
+}
+
+// ReplayDeadLetter 重新投递死信, 由通知队列的扫描协程重新发送
+func (n noticeService) ReplayDeadLetter(req interface{}) (interface{}, interface{}) {
+	r := req.(*types.RequestNoticeDeadLetterReplay)
+	count, err := n.ctx.DB.NoticeQueue().Replay(r.TenantId, r.Ids)
+	if err != nil {
+		return nil, err
+	}
+
+	return count, nil
+}
+
+// DeleteDeadLetter 删除指定的死信, 超过保留时长的死信由通知队列自动清理
+func (n noticeService) DeleteDeadLetter(req interface{}) (interface{}, interface{}) {
+	r := req.(*types.RequestNoticeDeadLetterDelete)
+	if len(r.Ids) == 0 {
+		return nil, fmt.Errorf("请选择需要删除的死信")
+	}
+
+	count, err := n.ctx.DB.NoticeQueue().DeleteDead(r.TenantId, r.Ids)
+	if err != nil {
+		return nil, err
+	}
+
+	return count, nil
+}
+
 type ResponseRecordMetric struct {
 	Date   []string `json:"date"`
 	Series series   `json:"series"`
@@ -130,6 +175,8 @@ type series struct {
 	P0 []int64 `json:"p0"`
 	P1 []int64 `json:"p1"`
 	P2 []int64 `json:"p2"`
+	// 限流抑制的通知数
+	Suppressed []int64 `json:"suppressed"`
 }
 
 func (n noticeService) GetRecordMetric(req interface{}) (interface{}, interface{}) {
@@ -147,8 +194,18 @@ func (n noticeService) GetRecordMetric(req interface{}) (interface{}, interface{
 	}
 
 	var severitys = []string{"P0", "P1", "P2"}
-	var P0, P1, P2 []int64
+	var P0, P1, P2, suppressed []int64
 	for _, t := range timeList {
+		count, err := n.ctx.DB.Notice().CountRecord(models.CountRecord{
+			Date:       t,
+			TenantId:   r.TenantId,
+			Suppressed: true,
+		})
+		if err != nil {
+			logc.Error(n.ctx.Ctx, err.Error())
+		}
+		suppressed = append(suppressed, count)
+
 		for _, s := range severitys {
 			count, err := n.ctx.DB.Notice().CountRecord(models.CountRecord{
 				Date:     t,
@@ -173,9 +230,10 @@ func (n noticeService) GetRecordMetric(req interface{}) (interface{}, interface{
 	return ResponseRecordMetric{
 		Date: timeList,
 		Series: series{
-			P0: P0,
-			P1: P1,
-			P2: P2,
+			P0:         P0,
+			P1:         P1,
+			P2:         P2,
+			Suppressed: suppressed,
 		},
 	}, nil
 }
@@ -193,6 +251,8 @@ func (n noticeService) Test(req interface{}) (interface{}, interface{}) {
 		Email:      r.Email,
 		Phone:      r.Phone,
 		SMS:        r.SMS,
+		Telegram:   r.Telegram,
+		WebHook:    r.WebHook,
 		Sign:       r.Sign,
 	})
 	if err != nil {
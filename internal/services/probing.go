@@ -57,15 +57,15 @@ func (m probingService) Create(req interface{}) (interface{}, interface{}) {
 		return nil, err
 	}
 
-	// 判断当前节点角色
+	// 判断当前节点是否负责该任务
 	if *r.GetEnabled() {
-		if alert.IsLeader() {
-			// Leader: 直接启动拨测协程
+		if alert.IsOwner(data.RuleId) {
+			// 负责节点: 直接启动拨测协程
 			if err := alert.Probe.Add(data); err != nil {
 				logc.Errorf(m.ctx.Ctx, "启动拨测任务失败: %v", err)
 			}
 		} else {
-			// Follower: 发布消息通知 Leader
+			// 其他节点: 发布消息通知负责节点
 			tools.PublishReloadMessage(m.ctx.Ctx, client.Redis, tools.ChannelProbingReload, tools.ReloadMessage{
 				Action:   tools.ActionCreate,
 				ID:       data.RuleId,
@@ -103,9 +103,9 @@ func (m probingService) Update(req interface{}) (interface{}, interface{}) {
 		return nil, err
 	}
 
-	// 判断当前节点角色
-	if alert.LeaderElector != nil && alert.LeaderElector.IsLeader() {
-		// Leader: 直接重启拨测协程
+	// 判断当前节点是否负责该任务
+	if alert.IsOwner(r.RuleId) {
+		// 负责节点: 直接重启拨测协程
 		if err := alert.Probe.Stop(r.RuleId); err != nil {
 			logc.Errorf(m.ctx.Ctx, "停止拨测任务失败: %v", err)
 		}
@@ -115,7 +115,7 @@ func (m probingService) Update(req interface{}) (interface{}, interface{}) {
 			}
 		}
 	} else {
-		// Follower: 发布消息通知 Leader
+		// 其他节点: 发布消息通知负责节点
 		tools.PublishReloadMessage(m.ctx.Ctx, client.Redis, tools.ChannelProbingReload, tools.ReloadMessage{
 			Action:   tools.ActionUpdate,
 			ID:       r.RuleId,
@@ -139,14 +139,14 @@ func (m probingService) Delete(req interface{}) (interface{}, interface{}) {
 		return nil, err
 	}
 
-	// 判断当前节点角色
-	if alert.LeaderElector != nil && alert.LeaderElector.IsLeader() {
-		// Leader: 直接停止拨测协程
+	// 判断当前节点是否负责该任务
+	if alert.IsOwner(r.RuleId) {
+		// 负责节点: 直接停止拨测协程
 		if err := alert.Probe.Stop(r.RuleId); err != nil {
 			logc.Errorf(m.ctx.Ctx, "停止拨测任务失败: %v", err)
 		}
 	} else {
-		// Follower: 发布消息通知 Leader
+		// 其他节点: 发布消息通知负责节点
 		tools.PublishReloadMessage(m.ctx.Ctx, client.Redis, tools.ChannelProbingReload, tools.ReloadMessage{
 			Action:   tools.ActionDelete,
 			ID:       r.RuleId,
@@ -263,10 +263,10 @@ func (m probingService) ChangeState(req interface{}) (interface{}, interface{})
 		return nil, err
 	}
 
-	// 判断当前节点角色
+	// 判断当前节点是否负责该任务
 	rule, _ := m.ctx.DB.Probing().Search(r.TenantId, r.RuleId)
-	if alert.LeaderElector != nil && alert.LeaderElector.IsLeader() {
-		// Leader: 直接操作协程
+	if alert.IsOwner(r.RuleId) {
+		// 负责节点: 直接操作协程
 		switch *r.GetEnabled() {
 		case true:
 			if err := alert.Probe.Add(rule); err != nil {
@@ -278,7 +278,7 @@ func (m probingService) ChangeState(req interface{}) (interface{}, interface{})
 			}
 		}
 	} else {
-		// Follower: 发布消息通知 Leader
+		// 其他节点: 发布消息通知负责节点
 		tools.PublishReloadMessage(m.ctx.Ctx, client.Redis, tools.ChannelProbingReload, tools.ReloadMessage{
 			Action:   action,
 			ID:       r.RuleId,
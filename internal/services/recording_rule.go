@@ -72,13 +72,13 @@ func (rs recordingRuleService) Create(req interface{}) (interface{}, interface{}
 		return nil, err
 	}
 
-	// 判断当前节点角色
+	// 判断当前节点是否负责该任务
 	if *r.GetEnabled() {
-		if alert.IsLeader() {
-			// Leader: 直接启动评估协程
+		if alert.IsOwner(data.RuleId) {
+			// 负责节点: 直接启动评估协程
 			alert.RecordingRule.Submit(data)
 		} else {
-			// Follower: 发布 Redis 消息通知 Leader
+			// 其他节点: 发布 Redis 消息通知负责节点
 			tools.PublishReloadMessage(rs.ctx.Ctx, client.Redis, tools.ChannelRecordingRuleReload, tools.ReloadMessage{
 				Action:   tools.ActionCreate,
 				ID:       data.RuleId,
@@ -148,10 +148,10 @@ func (rs recordingRuleService) Update(req interface{}) (interface{}, interface{}
 		return nil, err
 	}
 
-	// 判断当前节点角色并处理
+	// 判断当前节点是否负责该任务并处理
 	if action != "" {
-		if alert.IsLeader() {
-			// Leader: 直接操作协程
+		if alert.IsOwner(r.RuleId) {
+			// 负责节点: 直接操作协程
 			if action == tools.ActionDisable || action == tools.ActionUpdate {
 				alert.RecordingRule.Stop(r.RuleId)
 			}
@@ -159,7 +159,7 @@ func (rs recordingRuleService) Update(req interface{}) (interface{}, interface{}
 				alert.RecordingRule.Submit(data)
 			}
 		} else {
-			// Follower: 发布消息通知 Leader
+			// 其他节点: 发布消息通知负责节点
 			tools.PublishReloadMessage(rs.ctx.Ctx, client.Redis, tools.ChannelRecordingRuleReload, tools.ReloadMessage{
 				Action:   action,
 				ID:       r.RuleId,
@@ -188,13 +188,13 @@ func (rs recordingRuleService) Delete(req interface{}) (interface{}, interface{}
 		return nil, err
 	}
 
-	// 判断当前节点角色
+	// 判断当前节点是否负责该任务
 	if *info.GetEnabled() {
-		if alert.IsLeader() {
-			// Leader: 直接停止协程
+		if alert.IsOwner(r.RuleId) {
+			// 负责节点: 直接停止协程
 			alert.RecordingRule.Stop(r.RuleId)
 		} else {
-			// Follower: 发布消息通知 Leader
+			// 其他节点: 发布消息通知负责节点
 			tools.PublishReloadMessage(rs.ctx.Ctx, client.Redis, tools.ChannelRecordingRuleReload, tools.ReloadMessage{
 				Action:   tools.ActionDelete,
 				ID:       r.RuleId,
@@ -264,10 +264,10 @@ func (rs recordingRuleService) ChangeStatus(req interface{}) (interface{}, inter
 		return nil, err
 	}
 
-	// 判断当前节点角色
+	// 判断当前节点是否负责该任务
 	rule := rs.ctx.DB.RecordingRule().GetRuleObject(r.RuleId)
-	if alert.LeaderElector != nil && alert.LeaderElector.IsLeader() {
-		// Leader: 直接操作协程
+	if alert.IsOwner(r.RuleId) {
+		// 负责节点: 直接操作协程
 		switch *r.GetEnabled() {
 		case true:
 			var enable = true
@@ -278,7 +278,7 @@ func (rs recordingRuleService) ChangeStatus(req interface{}) (interface{}, inter
 			alert.RecordingRule.Stop(r.RuleId)
 		}
 	} else {
-		// Follower: 发布消息通知 Leader
+		// 其他节点: 发布消息通知负责节点
 		tools.PublishReloadMessage(rs.ctx.Ctx, client.Redis, tools.ChannelRecordingRuleReload, tools.ReloadMessage{
 			Action:   action,
 			ID:       r.RuleId,
@@ -8,6 +8,7 @@ import (
 	"watchAlert/internal/models"
 	"watchAlert/internal/types"
 	"watchAlert/pkg/client"
+	"watchAlert/pkg/metrics"
 	"watchAlert/pkg/tools"
 
 	"github.com/bytedance/sonic"
@@ -28,6 +29,8 @@ type InterRuleService interface {
 	ChangeStatus(req interface{}) (interface{}, interface{})
 	Import(req interface{}) (interface{}, interface{})
 	Change(req interface{}) (interface{}, interface{})
+	Backtest(req interface{}) (interface{}, interface{})
+	Test(req interface{}) (interface{}, interface{})
 }
 
 func newInterRuleService(ctx *ctx.Context) InterRuleService {
@@ -66,24 +69,30 @@ func (rs ruleService) Create(req interface{}) (interface{}, interface{}) {
 		KubernetesConfig:     r.KubernetesConfig,
 		ElasticSearchConfig:  r.ElasticSearchConfig,
 		LogEvalCondition:     r.LogEvalCondition,
+		NoDataConfig:         r.NoDataConfig,
 		FaultCenterId:        r.FaultCenterId,
 		UpdateAt:             time.Now().Unix(),
 		UpdateBy:             r.UpdateBy,
 		Enabled:              r.Enabled,
 	}
 
-	err := rs.ctx.DB.Rule().Create(data)
+	err := data.Validate()
 	if err != nil {
 		return nil, err
 	}
 
-	// 判断当前节点角色
+	err = rs.ctx.DB.Rule().Create(data)
+	if err != nil {
+		return nil, err
+	}
+
+	// 判断当前节点是否负责该任务
 	if *r.GetEnabled() {
-		if alert.IsLeader() {
-			// Leader: 直接启动评估协程
+		if alert.IsOwner(data.RuleId) {
+			// 负责节点: 直接启动评估协程
 			alert.AlertRule.Submit(data)
 		} else {
-			// Follower: 发布 Redis 消息通知 Leader
+			// 其他节点: 发布 Redis 消息通知负责节点
 			tools.PublishReloadMessage(rs.ctx.Ctx, client.Redis, tools.ChannelRuleReload, tools.ReloadMessage{
 				Action:   tools.ActionCreate,
 				ID:       data.RuleId,
@@ -146,22 +155,28 @@ func (rs ruleService) Update(req interface{}) (interface{}, interface{}) {
 		KubernetesConfig:     r.KubernetesConfig,
 		ElasticSearchConfig:  r.ElasticSearchConfig,
 		LogEvalCondition:     r.LogEvalCondition,
+		NoDataConfig:         r.NoDataConfig,
 		FaultCenterId:        r.FaultCenterId,
 		UpdateAt:             time.Now().Unix(),
 		UpdateBy:             r.UpdateBy,
 		Enabled:              r.Enabled,
 	}
 
+	err := data.Validate()
+	if err != nil {
+		return nil, err
+	}
+
 	// 更新数据
-	err := rs.ctx.DB.Rule().Update(data)
+	err = rs.ctx.DB.Rule().Update(data)
 	if err != nil {
 		return nil, err
 	}
 
-	// 判断当前节点角色并处理
+	// 判断当前节点是否负责该任务并处理
 	if action != "" {
-		if alert.IsLeader() {
-			// Leader: 直接操作协程
+		if alert.IsOwner(r.RuleId) {
+			// 负责节点: 直接操作协程
 			if action == tools.ActionDisable || action == tools.ActionUpdate {
 				alert.AlertRule.Stop(r.RuleId)
 			}
@@ -169,7 +184,7 @@ func (rs ruleService) Update(req interface{}) (interface{}, interface{}) {
 				alert.AlertRule.Submit(data)
 			}
 		} else {
-			// Follower: 发布消息通知 Leader
+			// 其他节点: 发布消息通知负责节点
 			tools.PublishReloadMessage(rs.ctx.Ctx, client.Redis, tools.ChannelRuleReload, tools.ReloadMessage{
 				Action:   action,
 				ID:       r.RuleId,
@@ -201,14 +216,15 @@ func (rs ruleService) Delete(req interface{}) (interface{}, interface{}) {
 	if err != nil {
 		return nil, err
 	}
+	metrics.DeleteRule(r.RuleId)
 
-	// 判断当前节点角色
+	// 判断当前节点是否负责该任务
 	if *info.GetEnabled() {
-		if alert.IsLeader() {
-			// Leader: 直接停止协程
+		if alert.IsOwner(r.RuleId) {
+			// 负责节点: 直接停止协程
 			alert.AlertRule.Stop(r.RuleId)
 		} else {
-			// Follower: 发布消息通知 Leader
+			// 其他节点: 发布消息通知负责节点
 			tools.PublishReloadMessage(rs.ctx.Ctx, client.Redis, tools.ChannelRuleReload, tools.ReloadMessage{
 				Action:   tools.ActionDelete,
 				ID:       r.RuleId,
@@ -275,10 +291,10 @@ func (rs ruleService) ChangeStatus(req interface{}) (interface{}, interface{}) {
 		return nil, err
 	}
 
-	// 判断当前节点角色
+	// 判断当前节点是否负责该任务
 	rule := rs.ctx.DB.Rule().GetRuleObject(r.RuleId)
-	if alert.LeaderElector != nil && alert.LeaderElector.IsLeader() {
-		// Leader: 直接操作协程
+	if alert.IsOwner(r.RuleId) {
+		// 负责节点: 直接操作协程
 		switch *r.GetEnabled() {
 		case true:
 			var enable = true
@@ -289,7 +305,7 @@ func (rs ruleService) ChangeStatus(req interface{}) (interface{}, interface{}) {
 			alert.AlertRule.Stop(r.RuleId)
 		}
 	} else {
-		// Follower: 发布消息通知 Leader
+		// 其他节点: 发布消息通知负责节点
 		tools.PublishReloadMessage(rs.ctx.Ctx, client.Redis, tools.ChannelRuleReload, tools.ReloadMessage{
 			Action:   action,
 			ID:       r.RuleId,
@@ -367,7 +383,13 @@ func (rs ruleService) Import(req interface{}) (interface{}, interface{}) {
 			continue
 		}
 
-		err := rs.ctx.DB.Rule().Create(models.AlertRule{
+		err := rule.NoDataConfig.Validate()
+		if err != nil {
+			logc.Errorf(rs.ctx.Ctx, "导入规则 %s 失败: %v", rule.RuleName, err)
+			continue
+		}
+
+		err = rs.ctx.DB.Rule().Create(models.AlertRule{
 			TenantId:             r.TenantId,
 			RuleId:               "a-" + tools.RandId(),
 			RuleGroupId:          r.RuleGroupId,
@@ -390,6 +412,7 @@ func (rs ruleService) Import(req interface{}) (interface{}, interface{}) {
 			KubernetesConfig:     rule.KubernetesConfig,
 			ElasticSearchConfig:  rule.ElasticSearchConfig,
 			LogEvalCondition:     rule.LogEvalCondition,
+			NoDataConfig:         rule.NoDataConfig,
 			FaultCenterId:        rule.FaultCenterId,
 			Enabled:              &disable,
 		})
@@ -487,7 +510,7 @@ func (rs ruleService) Change(req interface{}) (interface{}, interface{}) {
 
 		// 규칙 활성화 상태에 따라 적절한 처리
 		if *rule.Enabled {
-			if alert.IsLeader() {
+			if alert.IsOwner(rule.RuleId) {
 				// 리더: 기존 평가 고루틴을 중지하고 새로운 것을 시작합니다
 				alert.AlertRule.Stop(ruleId)
 				alert.AlertRule.Submit(rule)
@@ -506,7 +529,7 @@ func (rs ruleService) Change(req interface{}) (interface{}, interface{}) {
 				rs.ctx.Redis.Alert().RemoveAlertEvent(r.TenantId, rule.FaultCenterId, fingerprint)
 			}
 
-			if alert.IsLeader() {
+			if alert.IsOwner(rule.RuleId) {
 				// 리더: 평가 고루틴 중지
 				alert.AlertRule.Stop(ruleId)
 			} else {
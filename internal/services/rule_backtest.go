@@ -0,0 +1,91 @@
+package services
+
+import (
+	"fmt"
+	"time"
+	"watchAlert/alert/eval"
+	"watchAlert/internal/models"
+	"watchAlert/internal/types"
+	"watchAlert/pkg/provider"
+)
+
+// Backtest 在指定时间范围内回放 Prometheus 规则, 返回模拟的告警触发及恢复区间
+func (rs ruleService) Backtest(req interface{}) (interface{}, interface{}) {
+	r := req.(*types.RequestRuleBacktest)
+	if r.DatasourceType != provider.PrometheusDsProvider {
+		return nil, fmt.Errorf("仅支持 Prometheus 类型的规则回测")
+	}
+	if len(r.DatasourceIdList) == 0 {
+		return nil, fmt.Errorf("数据源不能为空")
+	}
+	if r.PrometheusConfig.PromQL == "" || len(r.PrometheusConfig.Rules) == 0 {
+		return nil, fmt.Errorf("PromQL 及告警条件不能为空")
+	}
+	if r.EvalInterval < 5 {
+		return nil, fmt.Errorf("EvalInterval must be greater than 5")
+	}
+
+	if r.EndTime == 0 {
+		r.EndTime = time.Now().Unix()
+	}
+	if r.StartTime <= 0 || r.StartTime >= r.EndTime {
+		return nil, fmt.Errorf("回测时间范围无效")
+	}
+	if (r.EndTime-r.StartTime)/r.EvalInterval > eval.BacktestMaxPoints {
+		return nil, fmt.Errorf("回测时间范围过大, 评估次数不能超过 %d, 请缩小时间范围或增大评估周期", eval.BacktestMaxPoints)
+	}
+
+	rule := models.AlertRule{
+		TenantId:         r.TenantId,
+		RuleId:           r.RuleId,
+		RuleName:         r.RuleName,
+		DatasourceType:   r.DatasourceType,
+		DatasourceIdList: r.DatasourceIdList,
+		EvalInterval:     r.EvalInterval,
+		PrometheusConfig: r.PrometheusConfig,
+	}
+	start, end := time.Unix(r.StartTime, 0), time.Unix(r.EndTime, 0)
+
+	summary := models.RuleBacktestSummary{
+		StartTime:    r.StartTime,
+		EndTime:      r.EndTime,
+		EvalInterval: r.EvalInterval,
+		Severitys:    make(map[string]int),
+		Series:       []models.RuleBacktestSeries{},
+	}
+	for _, datasourceId := range r.DatasourceIdList {
+		series, err := rs.backtestDatasource(datasourceId, rule, start, end)
+		if err != nil {
+			if summary.Errors == nil {
+				summary.Errors = make(map[string]string)
+			}
+			summary.Errors[datasourceId] = err.Error()
+			continue
+		}
+
+		for _, s := range series {
+			summary.Total += len(s.Intervals)
+			summary.Severitys[s.Severity] += len(s.Intervals)
+		}
+		summary.Series = append(summary.Series, series...)
+	}
+
+	return summary, nil
+}
+
+func (rs ruleService) backtestDatasource(datasourceId string, rule models.AlertRule, start, end time.Time) ([]models.RuleBacktestSeries, error) {
+	instance, err := rs.ctx.DB.Datasource().GetInstance(datasourceId)
+	if err != nil {
+		return nil, err
+	}
+	if !instance.GetEnabled() {
+		return nil, fmt.Errorf("数据源「%s」已被禁用!", instance.Name)
+	}
+
+	cli, err := provider.NewPrometheusClient(instance)
+	if err != nil {
+		return nil, err
+	}
+
+	return eval.Backtest(cli, datasourceId, rule, start, end)
+}
@@ -0,0 +1,17 @@
+package services
+
+import (
+	"watchAlert/alert/eval"
+	"watchAlert/internal/types"
+)
+
+// Test 使用内存序列执行告警规则单元测试
+func (rs ruleService) Test(req interface{}) (interface{}, interface{}) {
+	r := req.(*types.RequestRuleTest)
+	file, err := eval.ParseRuleTestFile(r.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	return eval.RunRuleTests(file), nil
+}
@@ -5,6 +5,7 @@ import (
 	"watchAlert/internal/ctx"
 	models "watchAlert/internal/models"
 	"watchAlert/internal/types"
+	"watchAlert/pkg/metrics"
 	"watchAlert/pkg/tools"
 )
 
@@ -94,6 +95,7 @@ func (ass alertSilenceService) Delete(req interface{}) (interface{}, interface{}
 	if err != nil {
 		return nil, err
 	}
+	metrics.DeleteSilence(r.FaultCenterId, r.ID)
 
 	return nil, nil
 }
@@ -2,6 +2,7 @@ package services
 
 import (
 	"fmt"
+	"regexp"
 	"time"
 	"watchAlert/internal/ctx"
 	"watchAlert/internal/models"
@@ -60,6 +61,18 @@ func (s alertSubscribeService) Create(req interface{}) (interface{}, interface{}
 		return nil, fmt.Errorf("用户已订阅该规则, 请勿重复创建!")
 	}
 
+	for _, filter := range r.SFilter {
+		label, ok := models.ParseSubscribeFilter(filter)
+		if !ok {
+			continue
+		}
+		if label.Operator == "=~" || label.Operator == "!~" {
+			if _, err := regexp.Compile(label.Value); err != nil {
+				return nil, fmt.Errorf("过滤条件 %s 正则表达式无效: %v", filter, err)
+			}
+		}
+	}
+
 	subscribe := models.AlertSubscribe{
 		SId:               "as-" + tools.RandId(),
 		STenantId:         r.STenantId,
@@ -71,6 +84,7 @@ func (s alertSubscribeService) Create(req interface{}) (interface{}, interface{}
 		SRuleSeverity:     r.SRuleSeverity,
 		SNoticeSubject:    r.SNoticeSubject,
 		SNoticeTemplateId: r.SNoticeTemplateId,
+		SNoticeRoutes:     r.SNoticeRoutes,
 		SFilter:           r.SFilter,
 		SCreateAt:         time.Now().Unix(),
 	}
@@ -27,6 +27,7 @@ type InterUserService interface {
 	Register(req interface{}) (interface{}, interface{})
 	Delete(req interface{}) (interface{}, interface{})
 	ChangePass(req interface{}) (interface{}, interface{})
+	UpdatePreference(req interface{}) (interface{}, interface{})
 }
 
 func newInterUserService(ctx *ctx.Context) InterUserService {
@@ -171,6 +172,10 @@ func (us userService) Register(req interface{}) (interface{}, interface{}) {
 		JoinDuty:   r.JoinDuty,
 		DutyUserId: r.DutyUserId,
 		Tenants:    r.Tenants,
+
+		ContactMethods:    r.ContactMethods,
+		NotifyPreferences: r.NotifyPreferences,
+		QuietHours:        r.QuietHours,
 	})
 	if err != nil {
 		return nil, err
@@ -215,6 +220,10 @@ func (us userService) Update(req interface{}) (interface{}, interface{}) {
 		JoinDuty:   r.JoinDuty,
 		DutyUserId: r.DutyUserId,
 		Tenants:    r.Tenants,
+
+		ContactMethods:    r.ContactMethods,
+		NotifyPreferences: r.NotifyPreferences,
+		QuietHours:        r.QuietHours,
 	})
 	if err != nil {
 		return nil, err
@@ -256,3 +265,27 @@ func (us userService) ChangePass(req interface{}) (interface{}, interface{}) {
 
 	return nil, nil
 }
+
+// UpdatePreference 更新当前用户的通知偏好
+func (us userService) UpdatePreference(req interface{}) (interface{}, interface{}) {
+	r := req.(*types.RequestUserPreferenceUpdate)
+	if r.QuietHours.Timezone != "" {
+		if _, err := time.LoadLocation(r.QuietHours.Timezone); err != nil {
+			return nil, fmt.Errorf("无效的时区: %s", r.QuietHours.Timezone)
+		}
+	}
+
+	err := us.ctx.DB.User().UpdatePreference(models.Member{
+		UserId:            r.UserId,
+		ContactMethods:    r.ContactMethods,
+		NotifyPreferences: r.NotifyPreferences,
+		QuietHours:        r.QuietHours,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	us.ctx.DB.User().ChangeCache(r.UserId)
+
+	return nil, nil
+}
@@ -0,0 +1,68 @@
+package types
+
+import "time"
+
+// AlertmanagerReceiverFaultCenterLabel 告警接收时用于选择故障中心的路由标签
+const AlertmanagerReceiverFaultCenterLabel = "w8t_fault_center"
+
+// RequestAlertmanagerReceive Alertmanager 兼容接口推送的告警
+type RequestAlertmanagerReceive struct {
+	UserId string
+	ApiKey string
+	Alerts []AlertmanagerAlert
+}
+
+// AlertmanagerAlert Alertmanager v2 PostableAlert
+type AlertmanagerAlert struct {
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations"`
+	StartsAt     time.Time         `json:"startsAt"`
+	EndsAt       time.Time         `json:"endsAt"`
+	GeneratorURL string            `json:"generatorURL"`
+}
+
+// IsResolved endsAt 已过期的告警视为恢复
+func (a AlertmanagerAlert) IsResolved() bool {
+	return !a.EndsAt.IsZero() && !a.EndsAt.After(time.Now())
+}
+
+type ResponseAlertmanagerReceive struct {
+	Received int      `json:"received"`
+	Firing   int      `json:"firing"`
+	Resolved int      `json:"resolved"`
+	Errors   []string `json:"errors"`
+}
+
+// 通用事件接口支持的动作
+const (
+	EventsActionTrigger     = "trigger"
+	EventsActionAcknowledge = "acknowledge"
+	EventsActionResolve     = "resolve"
+)
+
+// RequestEventsReceive 通用事件接口, 兼容 PagerDuty Events v2 格式
+type RequestEventsReceive struct {
+	UserId      string        `json:"-"`
+	ApiKey      string        `json:"-"`
+	RoutingKey  string        `json:"routing_key"` // 故障中心 ID, 为空时使用 API Key 绑定的故障中心
+	EventAction string        `json:"event_action"`
+	DedupKey    string        `json:"dedup_key"`
+	Payload     EventsPayload `json:"payload"`
+}
+
+type EventsPayload struct {
+	Summary       string                 `json:"summary"`
+	Source        string                 `json:"source"`
+	Severity      string                 `json:"severity"`
+	Timestamp     time.Time              `json:"timestamp"`
+	Component     string                 `json:"component"`
+	Group         string                 `json:"group"`
+	Class         string                 `json:"class"`
+	CustomDetails map[string]interface{} `json:"custom_details"`
+}
+
+type ResponseEventsReceive struct {
+	Status   string `json:"status"`
+	Message  string `json:"message"`
+	DedupKey string `json:"dedup_key"`
+}
@@ -1,16 +1,20 @@
 package types
 
 type RequestApiKeyCreate struct {
-	UserId      string `json:"userId" form:"userId"`
-	Name        string `json:"name" form:"name" binding:"required"`
-	Description string `json:"description" form:"description"`
+	UserId        string `json:"userId" form:"userId"`
+	TenantId      string `json:"tenantId" form:"tenantId"`
+	Name          string `json:"name" form:"name" binding:"required"`
+	Description   string `json:"description" form:"description"`
+	FaultCenterId string `json:"faultCenterId" form:"faultCenterId"`
 }
 
 type RequestApiKeyUpdate struct {
-	ID          int    `json:"id" form:"id" binding:"required"`
-	UserId      string `json:"userId" form:"userId"`
-	Name        string `json:"name" form:"name"`
-	Description string `json:"description" form:"description"`
+	ID            int    `json:"id" form:"id" binding:"required"`
+	UserId        string `json:"userId" form:"userId"`
+	TenantId      string `json:"tenantId" form:"tenantId"`
+	Name          string `json:"name" form:"name"`
+	Description   string `json:"description" form:"description"`
+	FaultCenterId string `json:"faultCenterId" form:"faultCenterId"`
 }
 
 type RequestApiKeyQuery struct {
@@ -21,10 +25,12 @@ type RequestApiKeyQuery struct {
 }
 
 type ResponseApiKeyInfo struct {
-	ID          int    `json:"id"`
-	UserId      string `json:"userId"`
-	Name        string `json:"name"`
-	Description string `json:"description"`
-	Key         string `json:"key"`
-	CreatedAt   int64  `json:"createdAt"`
+	ID            int    `json:"id"`
+	UserId        string `json:"userId"`
+	Name          string `json:"name"`
+	Description   string `json:"description"`
+	Key           string `json:"key"`
+	TenantId      string `json:"tenantId"`
+	FaultCenterId string `json:"faultCenterId"`
+	CreatedAt     int64  `json:"createdAt"`
 }
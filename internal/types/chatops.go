@@ -0,0 +1,9 @@
+package types
+
+// RequestChatOpsCallback IM 卡片回传请求, 签名校验需要原始请求体
+type RequestChatOpsCallback struct {
+	Timestamp string
+	Nonce     string
+	Signature string
+	Body      []byte
+}
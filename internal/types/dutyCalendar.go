@@ -10,6 +10,10 @@ type RequestDutyCalendarCreate struct {
 	UserGroup  [][]models.DutyUser `json:"userGroup"`
 	DateType   string              `json:"dateType"`
 	Status     string              `json:"status" `
+	// 时区与交接班时间, 班次按该时区的交接班时间切换
+	Timezone     string             `json:"timezone"`
+	HandoverTime string             `json:"handoverTime"`
+	Layers       []models.DutyLayer `json:"layers"`
 }
 
 type RequestDutyCalendarUpdate struct {
@@ -18,10 +22,65 @@ type RequestDutyCalendarUpdate struct {
 	Time     string            `json:"time"`
 	Users    []models.DutyUser `json:"users"`
 	Status   string            `json:"status" `
+	Layer    int               `json:"layer"`
+	UpdateBy string            `json:"updateBy"`
 }
 
 type RequestDutyCalendarQuery struct {
 	TenantId string `json:"tenantId" form:"tenantId"`
 	DutyId   string `json:"dutyId" form:"dutyId"`
 	Time     string `json:"time" form:"time"`
+	Layer    int    `json:"layer" form:"layer"`
+	// 时间范围与时间点, 单位（秒）
+	StartTime int64 `json:"startTime" form:"startTime"`
+	EndTime   int64 `json:"endTime" form:"endTime"`
+	At        int64 `json:"at" form:"at"`
+}
+
+type RequestDutyOverrideCreate struct {
+	TenantId  string            `json:"tenantId"`
+	DutyId    string            `json:"dutyId"`
+	Layer     int               `json:"layer"`
+	StartTime int64             `json:"startTime"`
+	EndTime   int64             `json:"endTime"`
+	Users     []models.DutyUser `json:"users"`
+	Reason    string            `json:"reason"`
+	CreateBy  string            `json:"createBy"`
+}
+
+type RequestDutyOverrideDelete struct {
+	TenantId string `json:"tenantId"`
+	ID       string `json:"id"`
+}
+
+type ResponseDutyShiftList struct {
+	Shifts    []models.DutyShift    `json:"shifts"`
+	Overrides []models.DutyOverride `json:"overrides"`
+}
+
+type RequestDutyIcsQuery struct {
+	TenantId string `json:"tenantId" form:"tenantId"`
+	DutyId   string `json:"dutyId" form:"dutyId"`
+	Token    string `json:"token" form:"token"`
+	// 仅导出指定用户的值班
+	UserId string `json:"userId" form:"userId"`
+}
+
+type ResponseDutyIcsToken struct {
+	Token string `json:"token"`
+	Path  string `json:"path"`
+}
+
+type RequestDutyIcsImport struct {
+	TenantId string `json:"tenantId"`
+	DutyId   string `json:"dutyId"`
+	Layer    int    `json:"layer"`
+	Status   string `json:"status"`
+	// ICS 文件内容
+	Content string `json:"content"`
+}
+
+type ResponseDutyIcsImport struct {
+	Imported int      `json:"imported"`
+	Skipped  []string `json:"skipped"`
 }
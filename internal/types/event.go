@@ -88,3 +88,19 @@ type RequestListEventComments struct {
 	// 告警指纹
 	Fingerprint string `json:"fingerprint" form:"fingerprint"`
 }
+
+// RequestEventAckLink 请求一键认领链接
+type RequestEventAckLink struct {
+	Token     string `json:"token" form:"token"`
+	IPAddress string `json:"-" form:"-"`
+}
+
+// ResponseEventAckLink 一键认领链接对应的事件
+type ResponseEventAckLink struct {
+	RuleName        string `json:"ruleName"`
+	Severity        string `json:"severity"`
+	Annotations     string `json:"annotations"`
+	Username        string `json:"username"`
+	ConfirmUsername string `json:"confirmUsername"`
+	Acked           bool   `json:"acked"`
+}
@@ -20,6 +20,9 @@ type RequestFaultCenterCreate struct {
 	IsUpgradeEnabled      *bool                  `json:"isUpgradeEnabled" gorm:"column:isUpgradeEnabled"`
 	UpgradableSeverity    []string               `json:"upgradableSeverity" gorm:"column:upgradableSeverity;serializer:json"`
 	UpgradeStrategy       models.UpgradeStrategy `json:"upgradeStrategy" gorm:"column:upgradeStrategy;serializer:json"`
+	GroupStrategy         models.GroupStrategy   `json:"groupStrategy" gorm:"column:groupStrategy;serializer:json"`
+	InhibitRules          []models.InhibitRule   `json:"inhibitRules" gorm:"column:inhibitRules;serializer:json"`
+	TicketConfig          models.TicketConfig    `json:"ticketConfig" gorm:"column:ticketConfig;serializer:json"`
 }
 
 // RequestFaultCenterUpdate 请求更新故障中心
@@ -41,6 +44,9 @@ type RequestFaultCenterUpdate struct {
 	IsUpgradeEnabled      *bool                  `json:"isUpgradeEnabled" gorm:"column:isUpgradeEnabled"`
 	UpgradableSeverity    []string               `json:"upgradableSeverity" gorm:"column:upgradableSeverity;serializer:json"`
 	UpgradeStrategy       models.UpgradeStrategy `json:"upgradeStrategy" gorm:"column:upgradeStrategy;serializer:json"`
+	GroupStrategy         models.GroupStrategy   `json:"groupStrategy" gorm:"column:groupStrategy;serializer:json"`
+	InhibitRules          []models.InhibitRule   `json:"inhibitRules" gorm:"column:inhibitRules;serializer:json"`
+	TicketConfig          models.TicketConfig    `json:"ticketConfig" gorm:"column:ticketConfig;serializer:json"`
 }
 
 // RequestFaultCenterQuery 请求查询故障中心
@@ -3,20 +3,22 @@ package types
 import "watchAlert/internal/models"
 
 type RequestNoticeCreate struct {
-	TenantId string         `json:"tenantId"`
-	Name     string         `json:"name"`
-	DutyId   *string        `json:"dutyId"`
-	Routes   []models.Route `json:"routes" gorm:"column:routes;serializer:json"`
-	UpdateBy string         `json:"updateBy"`
+	TenantId  string                 `json:"tenantId"`
+	Name      string                 `json:"name"`
+	DutyId    *string                `json:"dutyId"`
+	Routes    []models.Route         `json:"routes" gorm:"column:routes;serializer:json"`
+	RateLimit models.NoticeRateLimit `json:"rateLimit"`
+	UpdateBy  string                 `json:"updateBy"`
 }
 
 type RequestNoticeUpdate struct {
-	TenantId string         `json:"tenantId"`
-	Uuid     string         `json:"uuid"`
-	Name     string         `json:"name"`
-	DutyId   *string        `json:"dutyId"`
-	Routes   []models.Route `json:"routes" gorm:"column:routes;serializer:json"`
-	UpdateBy string         `json:"updateBy"`
+	TenantId  string                 `json:"tenantId"`
+	Uuid      string                 `json:"uuid"`
+	Name      string                 `json:"name"`
+	DutyId    *string                `json:"dutyId"`
+	Routes    []models.Route         `json:"routes" gorm:"column:routes;serializer:json"`
+	RateLimit models.NoticeRateLimit `json:"rateLimit"`
+	UpdateBy  string                 `json:"updateBy"`
 }
 
 func (requestNoticeUpdate *RequestNoticeUpdate) GetDutyId() *string {
@@ -39,10 +41,32 @@ type RequestNoticeQuery struct {
 }
 
 type RequestNoticeTest struct {
-	NoticeType string       `json:"noticeType"`
-	Hook       string       `json:"hook"`
-	Sign       string       `json:"sign"`
-	Email      models.Email `json:"email"`
-	Phone      models.Phone `json:"phone"`
-	SMS        models.SMS   `json:"sms"`
+	NoticeType string               `json:"noticeType"`
+	Hook       string               `json:"hook"`
+	Sign       string               `json:"sign"`
+	Email      models.Email         `json:"email"`
+	Phone      models.Phone         `json:"phone"`
+	SMS        models.SMS           `json:"sms"`
+	Telegram   models.Telegram      `json:"telegram"`
+	WebHook    models.WebHookConfig `json:"webhook"`
+}
+
+// RequestNoticeDeadLetterQuery 请求查询通知死信
+type RequestNoticeDeadLetterQuery struct {
+	TenantId   string `json:"tenantId" form:"tenantId"`
+	NoticeType string `json:"noticeType" form:"noticeType"`
+	Query      string `json:"query" form:"query"`
+	models.Page
+}
+
+// RequestNoticeDeadLetterReplay 请求重新投递通知死信, ids 为空时投递全部
+type RequestNoticeDeadLetterReplay struct {
+	TenantId string   `json:"tenantId"`
+	Ids      []string `json:"ids"`
+}
+
+// RequestNoticeDeadLetterDelete 请求删除通知死信
+type RequestNoticeDeadLetterDelete struct {
+	TenantId string   `json:"tenantId"`
+	Ids      []string `json:"ids"`
 }
@@ -24,6 +24,7 @@ type RequestRuleCreate struct {
 	KubernetesConfig     models.KubernetesConfig    `json:"kubernetesConfig"`
 	ElasticSearchConfig  models.ElasticSearchConfig `json:"elasticSearchConfig"`
 	LogEvalCondition     string                     `json:"logEvalCondition"`
+	NoDataConfig         models.NoDataConfig        `json:"noDataConfig"`
 	FaultCenterId        string                     `json:"faultCenterId"`
 	UpdateBy             string                     `json:"updateBy"`
 	Enabled              *bool                      `json:"enabled"`
@@ -60,6 +61,7 @@ type RequestRuleUpdate struct {
 	KubernetesConfig     models.KubernetesConfig    `json:"kubernetesConfig"`
 	ElasticSearchConfig  models.ElasticSearchConfig `json:"elasticSearchConfig"`
 	LogEvalCondition     string                     `json:"logEvalCondition"`
+	NoDataConfig         models.NoDataConfig        `json:"noDataConfig"`
 	FaultCenterId        string                     `json:"faultCenterId"`
 	UpdateBy             string                     `json:"updateBy"`
 	Enabled              *bool                      `json:"enabled"`
@@ -107,6 +109,25 @@ func (r *RequestRuleChangeStatus) GetEnabled() *bool {
 	return r.Enabled
 }
 
+// RequestRuleBacktest 规则回测, 时间为秒级时间戳, 回测已保存的规则时需传入 RuleId 以生成与实时事件一致的指纹
+type RequestRuleBacktest struct {
+	TenantId         string                  `json:"tenantId"`
+	RuleId           string                  `json:"ruleId"`
+	DatasourceType   string                  `json:"datasourceType"`
+	DatasourceIdList []string                `json:"datasourceId"`
+	RuleName         string                  `json:"ruleName"`
+	EvalInterval     int64                   `json:"evalInterval"`
+	PrometheusConfig models.PrometheusConfig `json:"prometheusConfig"`
+	StartTime        int64                   `json:"startTime"`
+	EndTime          int64                   `json:"endTime"`
+}
+
+// RequestRuleTest 规则单元测试, Content 为 YAML 或 JSON 格式的测试文件内容, 规则需内联
+type RequestRuleTest struct {
+	TenantId string `json:"tenantId"`
+	Content  string `json:"content"`
+}
+
 const (
 	WithPrometheusRuleImport int = 0
 	WithWatchAlertJsonImport int = 1
@@ -1,5 +1,7 @@
 package types
 
+import "watchAlert/internal/models"
+
 type RequestUserLogin struct {
 	Identifier string `json:"identifier"`
 	Password   string `json:"password"`
@@ -17,6 +19,10 @@ type RequestUserCreate struct {
 	JoinDuty   string   `json:"joinDuty" `
 	DutyUserId string   `json:"dutyUserId"`
 	Tenants    []string `json:"tenants" gorm:"tenants;serializer:json"`
+	// 通知偏好
+	ContactMethods    models.ContactMethods     `json:"contactMethods"`
+	NotifyPreferences []models.NotifyPreference `json:"notifyPreferences"`
+	QuietHours        models.QuietHours         `json:"quietHours"`
 }
 
 type RequestUserUpdate struct {
@@ -31,6 +37,10 @@ type RequestUserUpdate struct {
 	JoinDuty   string   `json:"joinDuty" `
 	DutyUserId string   `json:"dutyUserId"`
 	Tenants    []string `json:"tenants" gorm:"tenants;serializer:json"`
+	// 通知偏好
+	ContactMethods    models.ContactMethods     `json:"contactMethods"`
+	NotifyPreferences []models.NotifyPreference `json:"notifyPreferences"`
+	QuietHours        models.QuietHours         `json:"quietHours"`
 }
 
 type RequestUserQuery struct {
@@ -46,3 +56,10 @@ type RequestUserChangePassword struct {
 	UserId   string `json:"userid"`
 	Password string `json:"password"`
 }
+
+type RequestUserPreferenceUpdate struct {
+	UserId            string                    `json:"userid"`
+	ContactMethods    models.ContactMethods     `json:"contactMethods"`
+	NotifyPreferences []models.NotifyPreference `json:"notifyPreferences"`
+	QuietHours        models.QuietHours         `json:"quietHours"`
+}
@@ -55,6 +55,8 @@ func NewDBClient(dbcfg DBConfig) *gorm.DB {
 		&models.DutySchedule{},
 		&models.DutyManagement{},
 		&models.DutyCalendarInfo{},
+		&models.DutyShift{},
+		&models.DutyOverride{},
 		&models.AlertNotice{},
 		&models.AlertDataSource{},
 		&models.AlertRule{},
@@ -76,6 +78,7 @@ func NewDBClient(dbcfg DBConfig) *gorm.DB {
 		&models.DashboardFolders{},
 		&models.AlertSubscribe{},
 		&models.NoticeRecord{},
+		&models.NoticeTask{},
 		&models.ProbeRule{},
 		&models.FaultCenter{},
 		&models.AiContentRecord{},
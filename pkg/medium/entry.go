@@ -1,7 +1,9 @@
 package medium
 
 import (
+	"errors"
 	"fmt"
+	"html"
 	"strconv"
 	"time"
 	"watchAlert/internal/ctx"
@@ -9,6 +11,8 @@ import (
 	"github.com/bytedance/sonic"
 
 	"watchAlert/internal/models"
+	"watchAlert/pkg/metrics"
+	"watchAlert/pkg/tools"
 
 	"github.com/zeromicro/go-zero/core/logc"
 )
@@ -29,12 +33,16 @@ type (
 		IsRecovered bool
 		// hook 地址
 		Hook string
+		// 自定义 WebHook
+		WebHook models.WebHookConfig
 		// 邮件
 		Email models.Email
 		// 短信
 		SMS models.SMS
 		// 电话
 		Phone models.Phone
+		// Telegram
+		Telegram models.Telegram
 		// 消息
 		Content string
 		// 签名
@@ -50,26 +58,51 @@ type (
 
 const RobotTestContent = "这是一条来自 WatchAlert 的测试消息"
 
-// Sender 发送通知的主函数
+// Sender 发送通知的主函数, 通知进入持久化队列后异步发送, 未启动队列时直接发送
 func Sender(ctx *ctx.Context, sendParams SendParams) error {
-	// 根据通知类型获取对应的发送器
-	sender, err := senderFactory(sendParams.NoticeType)
-	if err != nil {
-		return fmt.Errorf("Send alarm failed, %s", err.Error())
+	// 每个 Telegram 会话作为独立任务发送及重试, 避免部分会话失败时重复发送至已成功的会话
+	if sendParams.NoticeType == "Telegram" && len(sendParams.Telegram.To) > 1 {
+		var errs []error
+		for _, chatId := range sendParams.Telegram.To {
+			params := sendParams
+			params.Telegram.To = []string{chatId}
+			if err := Sender(ctx, params); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		return errors.Join(errs...)
+	}
+
+	if queue != nil {
+		err := queue.enqueue(sendParams)
+		if err == nil {
+			return nil
+		}
+		logc.Errorf(ctx.Ctx, "通知任务入队失败, 直接发送, err: %s", err.Error())
 	}
 
 	// 发送通知
-	if err := sender.Send(sendParams); err != nil {
-		addRecord(ctx, sendParams, 1, sendParams.Content, err.Error())
+	if err := send(sendParams); err != nil {
+		addRecord(ctx, sendParams, 1, sendParams.Content, err.Error(), 0)
 		return fmt.Errorf("Send alarm failed to %s, err: %s", sendParams.NoticeType, err.Error())
 	}
 
 	// 记录成功发送的日志
-	addRecord(ctx, sendParams, 0, sendParams.Content, "success")
+	addRecord(ctx, sendParams, 0, sendParams.Content, "success", 0)
 	logc.Info(ctx.Ctx, fmt.Sprintf("Send alarm ok, msg: %s", sendParams.Content))
 	return nil
 }
 
+// send 根据通知类型获取对应的发送器并发送
+func send(sendParams SendParams) error {
+	sender, err := senderFactory(sendParams.NoticeType)
+	if err != nil {
+		return err
+	}
+
+	return sender.Send(sendParams)
+}
+
 // Tester 发送测试消息
 func Tester(ctx *ctx.Context, sendParams SendParams) error {
 	sender, err := senderFactory(sendParams.NoticeType)
@@ -85,6 +118,34 @@ func Tester(ctx *ctx.Context, sendParams SendParams) error {
 	return nil
 }
 
+// NewTextContent 生成纯文本消息的发送内容, 用于摘要、告警风暴等系统消息, 电话及短信不支持纯文本消息
+func NewTextContent(noticeType, text string) (string, bool) {
+	switch noticeType {
+	case "FeiShu":
+		return tools.JsonMarshalToString(map[string]any{"msg_type": "text", "content": map[string]any{"text": text}}), true
+	case "DingDing", "WeChat":
+		return tools.JsonMarshalToString(map[string]any{"msgtype": "text", "text": map[string]any{"content": text}}), true
+	case "Slack", "WebHook", "Telegram":
+		return tools.JsonMarshalToString(map[string]any{"text": text}), true
+	case "Teams":
+		return tools.JsonMarshalToString(models.TeamsMsgTemplate{
+			Type: "message",
+			Attachments: []models.TeamsAttachment{{
+				ContentType: "application/vnd.microsoft.card.adaptive",
+				Content: models.TeamsAdaptiveCard{
+					Schema:  "http://adaptivecards.io/schemas/adaptive-card.json",
+					Type:    "AdaptiveCard",
+					Version: "1.4",
+					Body:    []map[string]interface{}{{"type": "TextBlock", "text": text, "wrap": true}},
+				},
+			}},
+		}), true
+	case "Email":
+		return "<pre>" + html.EscapeString(text) + "</pre>", true
+	}
+	return "", false
+}
+
 // senderFactory 创建发送器的工厂函数
 func senderFactory(noticeType string) (SendInter, error) {
 	switch noticeType {
@@ -100,6 +161,14 @@ func senderFactory(noticeType string) (SendInter, error) {
 		return NewWebHookSender(), nil
 	case "Slack":
 		return NewSlackSender(), nil
+	case "Teams":
+		return NewTeamsSender(), nil
+	case "Telegram":
+		return NewTelegramSender(), nil
+	case "PagerDuty":
+		return NewPagerDutySender(), nil
+	case "Opsgenie":
+		return NewOpsgenieSender(), nil
 	case "SMS":
 		return getSMSSender()
 	case "Phone":
@@ -110,7 +179,13 @@ func senderFactory(noticeType string) (SendInter, error) {
 }
 
 // addRecord 记录通知发送结果
-func addRecord(ctx *ctx.Context, sendParams SendParams, status int, msg, errMsg string) {
+func addRecord(ctx *ctx.Context, sendParams SendParams, status int, msg, errMsg string, retries int) {
+	result := "success"
+	if status != 0 {
+		result = "failed"
+	}
+	metrics.Notifications.WithLabelValues(sendParams.NoticeType, result).Inc()
+
 	err := ctx.DB.Notice().AddRecord(models.NoticeRecord{
 		EventId:  sendParams.EventId,
 		Date:     time.Now().Format("2006-01-02"),
@@ -123,6 +198,7 @@ func addRecord(ctx *ctx.Context, sendParams SendParams, status int, msg, errMsg
 		Status:   status,
 		AlarmMsg: msg,
 		ErrMsg:   errMsg,
+		Retries:  retries,
 	})
 	if err != nil {
 		logc.Errorf(ctx.Ctx, "Add notice record failed, err: %s", err.Error())
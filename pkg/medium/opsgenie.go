@@ -0,0 +1,74 @@
+package medium
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"watchAlert/internal/models"
+	"watchAlert/pkg/tools"
+
+	"github.com/bytedance/sonic"
+)
+
+type (
+	// OpsgenieSender Opsgenie Alert API 发送策略, Sign 为 API Key, Hook 为 API 地址, 如欧洲区 https://api.eu.opsgenie.com
+	OpsgenieSender struct{}
+)
+
+const opsgenieApiUrl = "https://api.opsgenie.com"
+
+func NewOpsgenieSender() SendInter { return &OpsgenieSender{} }
+
+func (o *OpsgenieSender) Send(params SendParams) error {
+	var alert models.OpsgenieAlert
+	if err := sonic.UnmarshalString(params.Content, &alert); err != nil {
+		return fmt.Errorf("发送的内容解析失败, err: %s", err.Error())
+	}
+
+	if params.IsRecovered {
+		return o.close(params, alert)
+	}
+	return o.post(params, "/v2/alerts", alert)
+}
+
+// Test 创建一条测试告警并立即关闭
+func (o *OpsgenieSender) Test(params SendParams) error {
+	alert := models.OpsgenieAlert{
+		Message:  RobotTestContent,
+		Alias:    "watchalert-test",
+		Source:   "WatchAlert",
+		Priority: "P5",
+	}
+	if err := o.post(params, "/v2/alerts", alert); err != nil {
+		return err
+	}
+
+	return o.close(params, models.OpsgenieAlert{Alias: alert.Alias, Source: alert.Source})
+}
+
+// close 按 alias 关闭告警
+func (o *OpsgenieSender) close(params SendParams, alert models.OpsgenieAlert) error {
+	path := "/v2/alerts/" + url.PathEscape(alert.Alias) + "/close?identifierType=alias"
+	return o.post(params, path, models.OpsgenieAlert{Source: alert.Source, Note: alert.Note})
+}
+
+func (o *OpsgenieSender) post(params SendParams, path string, alert models.OpsgenieAlert) error {
+	if params.Sign == "" {
+		return errors.New("未配置 Opsgenie API Key")
+	}
+
+	api := strings.TrimRight(params.Hook, "/")
+	if api == "" {
+		api = opsgenieApiUrl
+	}
+
+	headers := map[string]string{"Authorization": "GenieKey " + params.Sign}
+	res, err := tools.Post(headers, api+path, bytes.NewReader(tools.JsonMarshalToByte(alert)), 10)
+	if err != nil {
+		return err
+	}
+
+	return checkIncidentResponse(res.StatusCode, res.Body)
+}
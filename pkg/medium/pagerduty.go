@@ -0,0 +1,81 @@
+package medium
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"watchAlert/internal/models"
+	"watchAlert/pkg/tools"
+
+	"github.com/bytedance/sonic"
+)
+
+type (
+	// PagerDutySender PagerDuty Events API v2 发送策略, Sign 为 Routing Key, Hook 为空时使用默认地址
+	PagerDutySender struct{}
+)
+
+const pagerDutyEventsUrl = "https://events.pagerduty.com/v2/enqueue"
+
+func NewPagerDutySender() SendInter { return &PagerDutySender{} }
+
+func (p *PagerDutySender) Send(params SendParams) error {
+	var event models.PagerDutyEvent
+	if err := sonic.UnmarshalString(params.Content, &event); err != nil {
+		return fmt.Errorf("发送的内容解析失败, err: %s", err.Error())
+	}
+
+	return p.post(params, event)
+}
+
+// Test 触发一条测试事件并立即解决
+func (p *PagerDutySender) Test(params SendParams) error {
+	event := models.PagerDutyEvent{
+		EventAction: "trigger",
+		DedupKey:    "watchalert-test",
+		Payload: &models.PagerDutyPayload{
+			Summary:  RobotTestContent,
+			Source:   "WatchAlert",
+			Severity: "info",
+		},
+	}
+	if err := p.post(params, event); err != nil {
+		return err
+	}
+
+	return p.post(params, models.PagerDutyEvent{EventAction: "resolve", DedupKey: event.DedupKey})
+}
+
+func (p *PagerDutySender) post(params SendParams, event models.PagerDutyEvent) error {
+	if params.Sign == "" {
+		return errors.New("未配置 PagerDuty Routing Key")
+	}
+	event.RoutingKey = params.Sign
+
+	url := params.Hook
+	if url == "" {
+		url = pagerDutyEventsUrl
+	}
+
+	res, err := tools.Post(nil, url, bytes.NewReader(tools.JsonMarshalToByte(event)), 10)
+	if err != nil {
+		return err
+	}
+
+	return checkIncidentResponse(res.StatusCode, res.Body)
+}
+
+// checkIncidentResponse PagerDuty、Opsgenie 接收成功时返回 202
+func checkIncidentResponse(statusCode int, body io.ReadCloser) error {
+	defer body.Close()
+	if statusCode >= 200 && statusCode < 300 {
+		return nil
+	}
+
+	bodyByte, err := io.ReadAll(body)
+	if err != nil {
+		return fmt.Errorf("读取 Body 失败, err: %s", err.Error())
+	}
+	return fmt.Errorf("status code %d, %s", statusCode, string(bodyByte))
+}
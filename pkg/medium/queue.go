@@ -0,0 +1,269 @@
+package medium
+
+import (
+	"fmt"
+	"math/rand"
+	"net/url"
+	"slices"
+	"sync"
+	"time"
+	"watchAlert/internal/ctx"
+	"watchAlert/internal/models"
+	"watchAlert/pkg/tools"
+
+	"github.com/bytedance/sonic"
+	"github.com/zeromicro/go-zero/core/logc"
+)
+
+// IncidentNoticeTypes 转发至外部事件平台的通知类型, 恢复时需解决对应事件
+var IncidentNoticeTypes = []string{"PagerDuty", "Opsgenie"}
+
+// retryPolicy 通知重试策略
+type retryPolicy struct {
+	MaxRetries int
+	BaseDelay  time.Duration
+	MaxDelay   time.Duration
+}
+
+var (
+	defaultRetryPolicy = retryPolicy{MaxRetries: 5, BaseDelay: 10 * time.Second, MaxDelay: 10 * time.Minute}
+	// 电话、短信存在发送频率限制且成本较高, 重试次数较少
+	retryPolicies = map[string]retryPolicy{
+		"Phone": {MaxRetries: 2, BaseDelay: time.Minute, MaxDelay: 5 * time.Minute},
+		"SMS":   {MaxRetries: 3, BaseDelay: 30 * time.Second, MaxDelay: 5 * time.Minute},
+		"Email": {MaxRetries: 5, BaseDelay: 30 * time.Second, MaxDelay: 30 * time.Minute},
+	}
+)
+
+func getRetryPolicy(noticeType string) retryPolicy {
+	if policy, ok := retryPolicies[noticeType]; ok {
+		return policy
+	}
+	return defaultRetryPolicy
+}
+
+// backoff 第 retries 次重试的等待时间, 指数退避并加入随机抖动, 避免大量任务同时重试
+func (p retryPolicy) backoff(retries int) time.Duration {
+	delay := p.MaxDelay
+	if retries <= 16 {
+		if d := p.BaseDelay << (retries - 1); d > 0 && d < p.MaxDelay {
+			delay = d
+		}
+	}
+
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+const (
+	// queueWorkers 每种通知类型的发送协程数, 各通知类型互不阻塞
+	queueWorkers = 4
+	// queueBufferSize 每种通知类型的待发送缓冲
+	queueBufferSize = 1000
+	// queueLease 任务的抢占时长, 超时未完成的任务将被重新发送
+	queueLease = 2 * time.Minute
+	// queueScanInterval 扫描待重试任务的间隔
+	queueScanInterval = 5 * time.Second
+	queueScanLimit    = 200
+	// deadTaskRetention 死信的保留时长, 超过后由扫描协程清理
+	deadTaskRetention = 7 * 24 * time.Hour
+	// deadTaskPurgeInterval 清理过期死信的间隔
+	deadTaskPurgeInterval = time.Hour
+)
+
+// noticeQueue 持久化的通知发送队列, 任务保存在数据库中, 实例重启后未完成的任务由扫描协程重新发送
+type noticeQueue struct {
+	ctx    *ctx.Context
+	mu     sync.Mutex
+	queues map[string]chan models.NoticeTask
+}
+
+var queue *noticeQueue
+
+// StartNoticeQueue 启动通知发送队列
+func StartNoticeQueue(ctx *ctx.Context) {
+	queue = &noticeQueue{
+		ctx:    ctx,
+		queues: make(map[string]chan models.NoticeTask),
+	}
+	go queue.scan()
+}
+
+// enqueue 保存任务并立即投递, 任务在抢占时长内由当前实例发送
+func (q *noticeQueue) enqueue(params SendParams) error {
+	now := time.Now()
+	task := models.NoticeTask{
+		TenantId:    params.TenantId,
+		ID:          "nt-" + tools.RandId(),
+		EventId:     params.EventId,
+		RuleName:    params.RuleName,
+		Severity:    params.Severity,
+		NoticeType:  params.NoticeType,
+		NoticeId:    params.NoticeId,
+		NoticeName:  params.NoticeName,
+		IsRecovered: params.IsRecovered,
+		Params:      tools.JsonMarshalToString(params),
+		Status:      models.NoticeTaskPending,
+		NextRetryAt: now.Add(queueLease).Unix(),
+		CreateAt:    now.Unix(),
+		UpdateAt:    now.Unix(),
+	}
+	// 外部事件平台的触发与恢复为独立任务, 触发重试晚于恢复发送时将产生无法恢复的事件, 恢复时丢弃未发送成功的触发
+	if params.IsRecovered && params.EventId != "" && slices.Contains(IncidentNoticeTypes, params.NoticeType) {
+		dropped, err := q.ctx.DB.NoticeQueue().DeleteTriggers(params.EventId, params.NoticeType, params.NoticeId)
+		if err != nil {
+			logc.Errorf(q.ctx.Ctx, "删除未发送的触发通知失败, eventId: %s, err: %s", params.EventId, err.Error())
+		} else if dropped > 0 {
+			logc.Infof(q.ctx.Ctx, "事件已恢复, 丢弃 %d 条未发送的触发通知, noticeType: %s, eventId: %s", dropped, params.NoticeType, params.EventId)
+		}
+	}
+
+	if err := q.ctx.DB.NoticeQueue().Create(task); err != nil {
+		return err
+	}
+
+	q.dispatch(task)
+	return nil
+}
+
+// dispatch 投递到通知类型对应的发送协程, 缓冲已满时不阻塞, 由扫描协程在抢占超时后重新投递
+func (q *noticeQueue) dispatch(task models.NoticeTask) {
+	select {
+	case q.getQueue(task.NoticeType) <- task:
+	default:
+		logc.Errorf(q.ctx.Ctx, "通知队列已满, 任务将稍后重试, noticeType: %s, id: %s", task.NoticeType, task.ID)
+	}
+}
+
+func (q *noticeQueue) getQueue(noticeType string) chan models.NoticeTask {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	ch, ok := q.queues[noticeType]
+	if !ok {
+		ch = make(chan models.NoticeTask, queueBufferSize)
+		q.queues[noticeType] = ch
+		for i := 0; i < queueWorkers; i++ {
+			go q.work(ch)
+		}
+	}
+	return ch
+}
+
+func (q *noticeQueue) work(ch chan models.NoticeTask) {
+	for {
+		select {
+		case <-q.ctx.Ctx.Done():
+			return
+		case task := <-ch:
+			q.deliver(task)
+		}
+	}
+}
+
+// deliver 发送任务, 成功后删除任务, 失败后按重试策略退避重试, 超过最大重试次数后进入死信
+func (q *noticeQueue) deliver(task models.NoticeTask) {
+	var params SendParams
+	err := sonic.UnmarshalString(task.Params, &params)
+	if err == nil {
+		err = send(params)
+	}
+
+	if err == nil {
+		addRecord(q.ctx, params, 0, params.Content, "success", task.Retries)
+		logc.Info(q.ctx.Ctx, fmt.Sprintf("Send alarm ok, msg: %s", params.Content))
+		if err := q.ctx.DB.NoticeQueue().Delete(task.ID); err != nil {
+			logc.Errorf(q.ctx.Ctx, "删除通知任务失败, id: %s, err: %s", task.ID, err.Error())
+		}
+		return
+	}
+
+	now := time.Now()
+	policy := getRetryPolicy(task.NoticeType)
+	task.Retries++
+	task.LastError = err.Error()
+	task.UpdateAt = now.Unix()
+	if task.Retries > policy.MaxRetries {
+		task.Status = models.NoticeTaskDead
+		addRecord(q.ctx, params, 1, params.Content, err.Error(), policy.MaxRetries)
+		logc.Errorf(q.ctx.Ctx, "Send alarm failed to %s after %d retries, err: %s", task.NoticeType, policy.MaxRetries, err.Error())
+	} else {
+		task.NextRetryAt = now.Add(policy.backoff(task.Retries)).Unix()
+		logc.Errorf(q.ctx.Ctx, "Send alarm failed to %s, retry %d at %s, err: %s",
+			task.NoticeType, task.Retries, time.Unix(task.NextRetryAt, 0).Format(time.DateTime), err.Error())
+	}
+
+	if err := q.ctx.DB.NoticeQueue().Update(task); err != nil {
+		logc.Errorf(q.ctx.Ctx, "更新通知任务失败, id: %s, err: %s", task.ID, err.Error())
+	}
+}
+
+// scan 定期扫描到达重试时间的任务, 以及抢占超时未完成的任务, 并清理过期的死信
+func (q *noticeQueue) scan() {
+	ticker := time.NewTicker(queueScanInterval)
+	defer ticker.Stop()
+	purgeTicker := time.NewTicker(deadTaskPurgeInterval)
+	defer purgeTicker.Stop()
+
+	for {
+		select {
+		case <-q.ctx.Ctx.Done():
+			return
+		case <-purgeTicker.C:
+			purged, err := q.ctx.DB.NoticeQueue().PurgeDead(time.Now().Add(-deadTaskRetention).Unix())
+			if err != nil {
+				logc.Errorf(q.ctx.Ctx, "清理过期的通知死信失败, err: %s", err.Error())
+			} else if purged > 0 {
+				logc.Infof(q.ctx.Ctx, "已清理 %d 条过期的通知死信", purged)
+			}
+		case <-ticker.C:
+			now := time.Now()
+			tasks, err := q.ctx.DB.NoticeQueue().ListDue(now.Unix(), queueScanLimit)
+			if err != nil {
+				logc.Errorf(q.ctx.Ctx, "获取待重试的通知任务失败, err: %s", err.Error())
+				continue
+			}
+
+			leaseUntil := now.Add(queueLease).Unix()
+			for _, task := range tasks {
+				if !q.ctx.DB.NoticeQueue().Claim(task.ID, task.NextRetryAt, leaseUntil) {
+					continue
+				}
+				task.NextRetryAt = leaseUntil
+				q.dispatch(task)
+			}
+		}
+	}
+}
+
+// redactedValue 隐藏后的敏感信息
+const redactedValue = "******"
+
+// RedactParams 隐藏通知任务发送参数中的认证信息, 用于接口返回
+func RedactParams(params string) string {
+	var p SendParams
+	if err := sonic.UnmarshalString(params, &p); err != nil {
+		return ""
+	}
+
+	for k := range p.WebHook.Headers {
+		p.WebHook.Headers[k] = redactedValue
+	}
+	if p.WebHook.Password != "" {
+		p.WebHook.Password = redactedValue
+	}
+	if p.WebHook.Token != "" {
+		p.WebHook.Token = redactedValue
+	}
+	if p.Sign != "" {
+		p.Sign = redactedValue
+	}
+	// 机器人地址的路径或参数中携带 Token, 仅保留域名; Telegram 的 Hook 可为 Bot Token
+	if u, err := url.Parse(p.Hook); err == nil && u.Host != "" {
+		p.Hook = u.Scheme + "://" + u.Host + "/" + redactedValue
+	} else if p.Hook != "" {
+		p.Hook = redactedValue
+	}
+
+	return tools.JsonMarshalToString(p)
+}
@@ -0,0 +1,57 @@
+package medium
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"watchAlert/pkg/tools"
+)
+
+type (
+	// TeamsSender Microsoft Teams 发送策略, 支持传入 Webhook 及工作流 (Workflows) 的 Webhook 地址
+	TeamsSender struct{}
+)
+
+var TeamsTestContent = fmt.Sprintf(`{
+  "type": "message",
+  "attachments": [{
+    "contentType": "application/vnd.microsoft.card.adaptive",
+    "contentUrl": null,
+    "content": {
+      "$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+      "type": "AdaptiveCard",
+      "version": "1.4",
+      "body": [{"type": "TextBlock", "text": "%s", "wrap": true}]
+    }
+  }]
+}`, RobotTestContent)
+
+func NewTeamsSender() SendInter { return &TeamsSender{} }
+
+func (t *TeamsSender) Send(params SendParams) error {
+	return t.post(params.Hook, params.Content)
+}
+
+func (t *TeamsSender) Test(params SendParams) error {
+	return t.post(params.Hook, TeamsTestContent)
+}
+
+func (t *TeamsSender) post(hook, content string) error {
+	res, err := tools.Post(nil, hook, bytes.NewReader([]byte(content)), 10)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	// 传入 Webhook 返回 200, 工作流返回 202
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		bodyByte, err := io.ReadAll(res.Body)
+		if err != nil {
+			return fmt.Errorf("读取 Body 失败, err: %s", err.Error())
+		}
+		return errors.New(string(bodyByte))
+	}
+
+	return nil
+}
@@ -0,0 +1,97 @@
+package medium
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+	"watchAlert/internal/models"
+	"watchAlert/pkg/tools"
+
+	"github.com/bytedance/sonic"
+)
+
+type (
+	// TelegramSender Telegram Bot 发送策略, Hook 为 Bot Token, 按 Chat ID 逐个发送
+	TelegramSender struct{}
+
+	TelegramResponse struct {
+		Ok          bool   `json:"ok"`
+		Description string `json:"description"`
+	}
+)
+
+const telegramApiUrl = "https://api.telegram.org"
+
+func NewTelegramSender() SendInter { return &TelegramSender{} }
+
+func (t *TelegramSender) Send(params SendParams) error {
+	var msg models.TelegramMsgTemplate
+	if err := sonic.UnmarshalString(params.Content, &msg); err != nil {
+		return fmt.Errorf("发送的内容解析失败, err: %s", err.Error())
+	}
+
+	return t.sendToChats(params, msg)
+}
+
+func (t *TelegramSender) Test(params SendParams) error {
+	return t.sendToChats(params, models.TelegramMsgTemplate{Text: RobotTestContent})
+}
+
+func (t *TelegramSender) sendToChats(params SendParams, msg models.TelegramMsgTemplate) error {
+	if len(params.Telegram.To) == 0 {
+		return errors.New("未配置 Telegram Chat ID")
+	}
+
+	var errs []error
+	for _, chatId := range params.Telegram.To {
+		msg.ChatId = chatId
+		err := t.post(params.Hook, msg)
+		// 模版中的标签、注解未转义时 Telegram 无法解析 HTML, 改为纯文本重新发送
+		if err != nil && msg.ParseMode != "" && strings.Contains(err.Error(), "can't parse entities") {
+			err = t.post(params.Hook, toTelegramPlainText(msg))
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("chat %s: %s", chatId, err.Error()))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// telegramHtmlTags Telegram HTML 消息中模版生成的格式标签
+var telegramHtmlTags = regexp.MustCompile(`</?(b|strong|i|em|u|ins|s|strike|del|code|pre|a|tg-spoiler|blockquote)(\s[^<>]*)?>`)
+
+// toTelegramPlainText 去除格式标签并还原转义字符
+func toTelegramPlainText(msg models.TelegramMsgTemplate) models.TelegramMsgTemplate {
+	msg.Text = html.UnescapeString(telegramHtmlTags.ReplaceAllString(msg.Text, ""))
+	msg.ParseMode = ""
+	return msg
+}
+
+// getTelegramSendUrl 获取 sendMessage 地址, Hook 为完整地址时可通过代理访问 Bot API
+func getTelegramSendUrl(hook string) string {
+	if strings.HasPrefix(hook, "http://") || strings.HasPrefix(hook, "https://") {
+		return strings.TrimSuffix(strings.TrimRight(hook, "/"), "/sendMessage") + "/sendMessage"
+	}
+	return telegramApiUrl + "/bot" + hook + "/sendMessage"
+}
+
+func (t *TelegramSender) post(hook string, msg models.TelegramMsgTemplate) error {
+	res, err := tools.Post(nil, getTelegramSendUrl(hook), bytes.NewReader(tools.JsonMarshalToByte(msg)), 10)
+	if err != nil {
+		return err
+	}
+
+	var response TelegramResponse
+	if err := tools.ParseReaderBody(res.Body, &response); err != nil {
+		return fmt.Errorf("Error unmarshalling Telegram response: %s", err.Error())
+	}
+	if !response.Ok {
+		return errors.New(response.Description)
+	}
+
+	return nil
+}
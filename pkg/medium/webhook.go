@@ -2,9 +2,10 @@ package medium
 
 import (
 	"bytes"
-	"errors"
 	"fmt"
 	"io"
+	"strconv"
+	"time"
 	"watchAlert/pkg/tools"
 )
 
@@ -15,32 +16,59 @@ type (
 
 var WebhookTestContent = fmt.Sprintf(`{
   "text": "%s"
-  }
 }`, RobotTestContent)
 
 func NewWebHookSender() SendInter { return &WebHookSender{} }
 
 func (w *WebHookSender) Send(params SendParams) error {
-	return w.post(params.Hook, params.Content)
+	return w.post(params, params.Content)
 }
 
 func (w *WebHookSender) Test(params SendParams) error {
-	return w.post(params.Hook, WebhookTestContent)
+	return w.post(params, WebhookTestContent)
 }
 
-func (w *WebHookSender) post(hook, content string) error {
-	res, err := tools.Post(nil, hook, bytes.NewReader([]byte(content)), 10)
+func (w *WebHookSender) post(params SendParams, content string) error {
+	res, err := tools.Post(newWebhookHeaders(params, content), params.Hook, bytes.NewReader([]byte(content)), 10)
 	if err != nil {
 		return err
 	}
+	defer res.Body.Close()
 
-	if res.StatusCode != 200 {
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
 		bodyByte, err := io.ReadAll(res.Body)
 		if err != nil {
 			return fmt.Errorf("读取 Body 失败, err: %s", err.Error())
 		}
-		return errors.New(string(bodyByte))
+		return fmt.Errorf("status code %d, %s", res.StatusCode, string(bodyByte))
 	}
 
 	return nil
 }
+
+// newWebhookHeaders 生成自定义请求头、认证及签名
+// 签名为 "sha256=" + hex(HmacSHA256(签名密钥, 时间戳 + "." + 请求体)), 时间戳通过 X-W8t-Timestamp 请求头携带
+func newWebhookHeaders(params SendParams, content string) map[string]string {
+	config := params.WebHook
+	headers := make(map[string]string)
+	for k, v := range config.Headers {
+		headers[k] = v
+	}
+
+	switch config.AuthType {
+	case "basic":
+		headers = tools.MergeHeaders(headers, tools.CreateBasicAuthHeader(config.Username, config.Password))
+	case "bearer":
+		if config.Token != "" {
+			headers["Authorization"] = "Bearer " + config.Token
+		}
+	}
+
+	if params.Sign != "" {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		headers["X-W8t-Timestamp"] = timestamp
+		headers["X-W8t-Signature"] = "sha256=" + tools.HmacSha256(params.Sign, timestamp+"."+content)
+	}
+
+	return headers
+}
@@ -0,0 +1,102 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// namespace WatchAlert 自身指标的前缀
+const namespace = "watchalert"
+
+var (
+	// RuleEvalDuration 告警规则单次评估耗时
+	RuleEvalDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "rule_eval_duration_seconds",
+		Help:      "Duration of alert rule evaluations.",
+		Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60},
+	}, []string{"rule_id", "datasource_type"})
+
+	// RuleEvalFailures 告警规则评估失败次数, 包括获取数据源、数据源不可用及查询失败
+	RuleEvalFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rule_eval_failures_total",
+		Help:      "Total number of failed alert rule evaluations.",
+	}, []string{"rule_id", "datasource_type"})
+
+	// RuleEvalMissed 告警规则错过的评估次数, reason 为 delay(调度延迟) 或 overrun(上次评估未完成)
+	RuleEvalMissed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rule_eval_missed_total",
+		Help:      "Total number of missed alert rule evaluations.",
+	}, []string{"rule_id", "reason"})
+
+	// Notifications 通知发送结果, status 为 success 或 failed
+	Notifications = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "notifications_total",
+		Help:      "Total number of notifications sent per medium.",
+	}, []string{"medium", "status"})
+
+	// SilenceHits 静默规则命中次数
+	SilenceHits = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "silence_hits_total",
+		Help:      "Total number of events suppressed by silences.",
+	}, []string{"fault_center_id", "silence_id"})
+
+	// ProbeExecutions 拨测执行次数, status 为 success 或 failed
+	ProbeExecutions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "probe_executions_total",
+		Help:      "Total number of probe executions.",
+	}, []string{"rule_type", "status"})
+
+	// ReloadMessages 重载消息数量, direction 为 published 或 received
+	ReloadMessages = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "reload_messages_total",
+		Help:      "Total number of reload messages published or received.",
+	}, []string{"channel", "action", "direction"})
+)
+
+func init() {
+	prometheus.MustRegister(
+		RuleEvalDuration,
+		RuleEvalFailures,
+		RuleEvalMissed,
+		Notifications,
+		SilenceHits,
+		ProbeExecutions,
+		ReloadMessages,
+	)
+}
+
+// DeleteRule 删除规则的评估指标, 规则删除后调用
+func DeleteRule(ruleId string) {
+	labels := prometheus.Labels{"rule_id": ruleId}
+	RuleEvalDuration.DeletePartialMatch(labels)
+	RuleEvalFailures.DeletePartialMatch(labels)
+	RuleEvalMissed.DeletePartialMatch(labels)
+}
+
+// DeleteSilence 删除静默规则的命中次数, 静默规则删除后调用
+func DeleteSilence(faultCenterId, silenceId string) {
+	SilenceHits.DeleteLabelValues(faultCenterId, silenceId)
+}
+
+// NewGauge 创建指标, 值在采集时计算
+func NewGauge(name, help string, value func() float64) prometheus.GaugeFunc {
+	return prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      name,
+		Help:      help,
+	}, value)
+}
+
+// Status 根据错误返回结果标签
+func Status(err error) string {
+	if err != nil {
+		return "failed"
+	}
+	return "success"
+}
@@ -0,0 +1,260 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+	"watchAlert/internal/models"
+
+	"github.com/prometheus/prometheus/model/histogram"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/promql/parser"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"github.com/prometheus/prometheus/tsdb/chunks"
+	"github.com/prometheus/prometheus/util/annotations"
+)
+
+// MemoryProvider 基于内存序列的 Prometheus 数据源, 使用 PromQL 引擎查询, 用于告警规则单元测试
+type MemoryProvider struct {
+	engine         *promql.Engine
+	queryable      memoryQueryable
+	externalLabels map[string]interface{}
+	// EvalTime 即时查询的评估时间
+	EvalTime time.Time
+}
+
+// NewMemoryProvider 加载 promtool input_series 语法的序列, 第 i 个样本的时间为 start + i*interval
+func NewMemoryProvider(inputs []models.RuleTestSeries, start time.Time, interval time.Duration, externalLabels map[string]interface{}) (*MemoryProvider, error) {
+	var series []storage.Series
+	for _, input := range inputs {
+		lset, values, err := parser.ParseSeriesDesc(input.Series + " " + input.Values)
+		if err != nil {
+			return nil, fmt.Errorf("解析序列 %s 失败: %w", input.Series, err)
+		}
+
+		var samples []chunks.Sample
+		for i, v := range values {
+			if v.Omitted {
+				continue
+			}
+			samples = append(samples, memorySample{
+				t:  start.Add(time.Duration(i) * interval).UnixMilli(),
+				f:  v.Value,
+				fh: v.Histogram,
+			})
+		}
+		series = append(series, storage.NewListSeries(lset, samples))
+	}
+	sort.Slice(series, func(i, j int) bool {
+		return labels.Compare(series[i].Labels(), series[j].Labels()) < 0
+	})
+
+	return &MemoryProvider{
+		engine: promql.NewEngine(promql.EngineOpts{
+			MaxSamples:           50000000,
+			Timeout:              time.Minute,
+			EnableAtModifier:     true,
+			EnableNegativeOffset: true,
+		}),
+		queryable:      memoryQueryable{series: series},
+		externalLabels: externalLabels,
+		EvalTime:       start,
+	}, nil
+}
+
+func (m *MemoryProvider) Query(promQL string) ([]Metrics, error) {
+	query, err := m.engine.NewInstantQuery(context.Background(), m.queryable, nil, promQL, m.EvalTime)
+	if err != nil {
+		return nil, err
+	}
+	defer query.Close()
+
+	res := query.Exec(context.Background())
+	if res.Err != nil {
+		return nil, res.Err
+	}
+
+	switch value := res.Value.(type) {
+	case promql.Vector:
+		var result []Metrics
+		for _, sample := range value {
+			if math.IsNaN(sample.F) {
+				continue
+			}
+			result = append(result, Metrics{
+				Labels:    memoryLabels(sample.Metric),
+				Value:     sample.F,
+				Timestamp: sample.T,
+			})
+		}
+		return result, nil
+	case promql.Scalar:
+		return []Metrics{{Labels: map[string]interface{}{}, Value: value.V, Timestamp: value.T}}, nil
+	default:
+		return nil, fmt.Errorf("不支持的查询结果类型: %s", res.Value.Type())
+	}
+}
+
+func (m *MemoryProvider) QueryRange(promQL string, start, end time.Time, step time.Duration) ([]Metrics, error) {
+	query, err := m.engine.NewRangeQuery(context.Background(), m.queryable, nil, promQL, start, end, step)
+	if err != nil {
+		return nil, err
+	}
+	defer query.Close()
+
+	res := query.Exec(context.Background())
+	if res.Err != nil {
+		return nil, res.Err
+	}
+
+	matrix, err := res.Matrix()
+	if err != nil {
+		return nil, err
+	}
+
+	var result []Metrics
+	for _, series := range matrix {
+		metric := memoryLabels(series.Metric)
+		for _, point := range series.Floats {
+			if math.IsNaN(point.F) {
+				continue
+			}
+			result = append(result, Metrics{
+				Labels:    metric,
+				Value:     point.F,
+				Timestamp: point.T,
+			})
+		}
+	}
+
+	return result, nil
+}
+
+func (m *MemoryProvider) Check() (bool, error) {
+	return true, nil
+}
+
+func (m *MemoryProvider) GetExternalLabels() map[string]interface{} {
+	return m.externalLabels
+}
+
+func (m *MemoryProvider) Write(ctx context.Context, result []Metrics, labels map[string]string) error {
+	return fmt.Errorf("内存数据源不支持写入")
+}
+
+func memoryLabels(lset labels.Labels) map[string]interface{} {
+	metric := make(map[string]interface{}, lset.Len())
+	lset.Range(func(l labels.Label) {
+		metric[l.Name] = l.Value
+	})
+	return metric
+}
+
+type memorySample struct {
+	t  int64
+	f  float64
+	fh *histogram.FloatHistogram
+}
+
+func (s memorySample) T() int64                      { return s.t }
+func (s memorySample) F() float64                    { return s.f }
+func (s memorySample) H() *histogram.Histogram       { return nil }
+func (s memorySample) FH() *histogram.FloatHistogram { return s.fh }
+
+func (s memorySample) Type() chunkenc.ValueType {
+	if s.fh != nil {
+		return chunkenc.ValFloatHistogram
+	}
+	return chunkenc.ValFloat
+}
+
+func (s memorySample) Copy() chunks.Sample {
+	c := memorySample{t: s.t, f: s.f}
+	if s.fh != nil {
+		c.fh = s.fh.Copy()
+	}
+	return c
+}
+
+// memoryQueryable 按标签匹配内存中的序列, 序列已按标签排序
+type memoryQueryable struct {
+	series []storage.Series
+}
+
+func (q memoryQueryable) Querier(mint, maxt int64) (storage.Querier, error) {
+	return q, nil
+}
+
+func (q memoryQueryable) Select(ctx context.Context, sortSeries bool, hints *storage.SelectHints, matchers ...*labels.Matcher) storage.SeriesSet {
+	var matched []storage.Series
+	for _, series := range q.series {
+		if matchLabels(series.Labels(), matchers) {
+			matched = append(matched, series)
+		}
+	}
+	return &memorySeriesSet{series: matched, index: -1}
+}
+
+func (q memoryQueryable) LabelValues(ctx context.Context, name string, hints *storage.LabelHints, matchers ...*labels.Matcher) ([]string, annotations.Annotations, error) {
+	seen := make(map[string]struct{})
+	var values []string
+	for _, series := range q.series {
+		value := series.Labels().Get(name)
+		if _, ok := seen[value]; ok || value == "" || !matchLabels(series.Labels(), matchers) {
+			continue
+		}
+		seen[value] = struct{}{}
+		values = append(values, value)
+	}
+	sort.Strings(values)
+	return values, nil, nil
+}
+
+func (q memoryQueryable) LabelNames(ctx context.Context, hints *storage.LabelHints, matchers ...*labels.Matcher) ([]string, annotations.Annotations, error) {
+	seen := make(map[string]struct{})
+	var names []string
+	for _, series := range q.series {
+		if !matchLabels(series.Labels(), matchers) {
+			continue
+		}
+		series.Labels().Range(func(l labels.Label) {
+			if _, ok := seen[l.Name]; !ok {
+				seen[l.Name] = struct{}{}
+				names = append(names, l.Name)
+			}
+		})
+	}
+	sort.Strings(names)
+	return names, nil, nil
+}
+
+func (q memoryQueryable) Close() error {
+	return nil
+}
+
+func matchLabels(lset labels.Labels, matchers []*labels.Matcher) bool {
+	for _, matcher := range matchers {
+		if !matcher.Matches(lset.Get(matcher.Name)) {
+			return false
+		}
+	}
+	return true
+}
+
+type memorySeriesSet struct {
+	series []storage.Series
+	index  int
+}
+
+func (s *memorySeriesSet) Next() bool {
+	s.index++
+	return s.index < len(s.series)
+}
+
+func (s *memorySeriesSet) At() storage.Series                { return s.series[s.index] }
+func (s *memorySeriesSet) Err() error                        { return nil }
+func (s *memorySeriesSet) Warnings() annotations.Annotations { return nil }
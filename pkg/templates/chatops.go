@@ -0,0 +1,53 @@
+package templates
+
+import (
+	"fmt"
+	"watchAlert/internal/models"
+)
+
+// cardAction 卡片交互按钮及处理结果
+type cardAction struct {
+	config models.ChatOpsConfig
+	// handled 处理结果, 如 "alice 已静默 1 小时"
+	handled string
+}
+
+// cardButton 卡片按钮, Url 不为空时为跳转按钮
+type cardButton struct {
+	Text   string
+	Action string
+	Style  string // primary, danger, default
+	Url    string
+}
+
+// getHandled 获取卡片中展示的处理结果, 已认领的事件展示认领人
+func (c cardAction) getHandled(alert models.AlertCurEvent) string {
+	if c.handled != "" {
+		return c.handled
+	}
+	if alert.ConfirmState.IsOk && alert.ConfirmState.ConfirmUsername != "" {
+		return fmt.Sprintf("%s 已认领", alert.ConfirmState.ConfirmUsername)
+	}
+	return ""
+}
+
+// getButtons 获取告警卡片的操作按钮, 恢复通知不展示按钮, 已处理的事件仅保留查看详情
+func (c cardAction) getButtons(alert models.AlertCurEvent) []cardButton {
+	if alert.IsRecovered {
+		return nil
+	}
+
+	var buttons []cardButton
+	if c.config.GetEnable() && c.getHandled(alert) == "" {
+		buttons = append(buttons,
+			cardButton{Text: "认领", Action: models.ChatOpsActionAck, Style: "primary"},
+			cardButton{Text: "静默 1 小时", Action: models.ChatOpsActionSilence1h, Style: "default"},
+			cardButton{Text: "静默 4 小时", Action: models.ChatOpsActionSilence4h, Style: "danger"},
+		)
+	}
+	if url := c.config.GetEventDetailUrl(alert); url != "" {
+		buttons = append(buttons, cardButton{Text: "查看详情", Action: models.ChatOpsActionViewDetail, Style: "default", Url: url})
+	}
+
+	return buttons
+}
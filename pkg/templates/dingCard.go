@@ -6,7 +6,7 @@ import (
 	"watchAlert/pkg/tools"
 )
 
-func dingdingTemplate(alert models2.AlertCurEvent, noticeTmpl models2.NoticeTemplateExample) string {
+func dingdingTemplate(alert models2.AlertCurEvent, noticeTmpl models2.NoticeTemplateExample, action cardAction) string {
 	Title := ParserTemplate("Title", alert, noticeTmpl.Template)
 	Footer := ParserTemplate("Footer", alert, noticeTmpl.Template)
 
@@ -34,6 +34,14 @@ func dingdingTemplate(alert models2.AlertCurEvent, noticeTmpl models2.NoticeTemp
 		},
 	}
 
+	// 群机器人的 Markdown 消息不支持回传交互, 仅展示处理结果及详情链接
+	if handled := action.getHandled(alert); handled != "" {
+		t.Markdown.Text += "\n\n**处理结果:** " + handled
+	}
+	if url := action.config.GetEventDetailUrl(alert); url != "" && !alert.IsRecovered {
+		t.Markdown.Text += "\n\n[查看详情](" + url + ")"
+	}
+
 	if strings.Trim(alert.DutyUser, " ") == "all" {
 		t.At = models2.At{
 			AtUserIds: []string{},
@@ -9,7 +9,7 @@ import (
 )
 
 // Template 飞书消息卡片模版
-func feishuTemplate(alert models.AlertCurEvent, noticeTmpl models.NoticeTemplateExample) string {
+func feishuTemplate(alert models.AlertCurEvent, noticeTmpl models.NoticeTemplateExample, action cardAction) string {
 
 	var cardContentString string
 	if *noticeTmpl.EnableFeiShuJsonCard {
@@ -68,6 +68,9 @@ func feishuTemplate(alert models.AlertCurEvent, noticeTmpl models.NoticeTemplate
 					},
 				},
 			},
+		}
+		cardElements = append(cardElements, feishuActionElements(alert, noticeTmpl, action)...)
+		cardElements = append(cardElements, []models.Elements{
 			{
 				Tag: "hr",
 			},
@@ -80,7 +83,7 @@ func feishuTemplate(alert models.AlertCurEvent, noticeTmpl models.NoticeTemplate
 					},
 				},
 			},
-		}
+		}...)
 
 		defaultTemplate.Card.Elements = tools.ConvertSliceToMapList(cardElements)
 		defaultTemplate.Card.Header = tools.ConvertStructToMap(cardHeader)
@@ -94,3 +97,38 @@ func feishuTemplate(alert models.AlertCurEvent, noticeTmpl models.NoticeTemplate
 	return cardContentString
 
 }
+
+// feishuActionElements 卡片的处理结果及操作按钮
+func feishuActionElements(alert models.AlertCurEvent, noticeTmpl models.NoticeTemplateExample, action cardAction) []models.Elements {
+	var elements []models.Elements
+	if handled := action.getHandled(alert); handled != "" {
+		elements = append(elements, models.Elements{
+			Tag: "div",
+			Text: models.Texts{
+				Content: "**处理结果:** " + handled,
+				Tag:     "lark_md",
+			},
+		})
+	}
+
+	buttons := action.getButtons(alert)
+	if len(buttons) == 0 {
+		return elements
+	}
+
+	element := models.Elements{Tag: "action"}
+	for _, button := range buttons {
+		b := models.Buttons{
+			Tag:  "button",
+			Text: models.ActionsText{Content: button.Text, Tag: "plain_text"},
+			Type: button.Style,
+			URL:  button.Url,
+		}
+		if button.Url == "" {
+			b.Value = models.NewChatOpsActionValue(button.Action, alert, noticeTmpl.ID)
+		}
+		element.Actions = append(element.Actions, b)
+	}
+
+	return append(elements, element)
+}
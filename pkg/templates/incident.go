@@ -0,0 +1,109 @@
+package templates
+
+import (
+	"fmt"
+	"time"
+	"unicode/utf8"
+	"watchAlert/internal/models"
+	"watchAlert/pkg/tools"
+)
+
+const (
+	// PagerDuty 摘要最长 1024 字符, Opsgenie 消息最长 130 字符
+	pagerDutySummaryMaxLen = 1024
+	opsgenieMessageMaxLen  = 130
+)
+
+// incidentSummary 告警摘要, 配置通知模版时使用模版标题
+func incidentSummary(alert models.AlertCurEvent, noticeTmpl models.NoticeTemplateExample) string {
+	if noticeTmpl.Template != "" {
+		if title := ParserTemplate("Title", alert, noticeTmpl.Template); title != "" {
+			return title
+		}
+	}
+	return fmt.Sprintf("[%s] %s", alert.Severity, alert.RuleName)
+}
+
+// incidentSource 告警来源, 优先使用 instance 标签
+func incidentSource(alert models.AlertCurEvent) string {
+	if instance, ok := alert.Labels["instance"].(string); ok && instance != "" {
+		return instance
+	}
+	if alert.DatasourceType != "" {
+		return alert.DatasourceType
+	}
+	return "WatchAlert"
+}
+
+func pagerDutyTemplate(alert models.AlertCurEvent, noticeTmpl models.NoticeTemplateExample, action cardAction) string {
+	event := models.PagerDutyEvent{
+		EventAction: "trigger",
+		DedupKey:    alert.Fingerprint,
+		Client:      "WatchAlert",
+		ClientUrl:   action.config.GetEventDetailUrl(alert),
+	}
+	if alert.IsRecovered {
+		event.EventAction = "resolve"
+		return tools.JsonMarshalToString(event)
+	}
+
+	details := map[string]interface{}{
+		"annotations":   alert.Annotations,
+		"faultCenterId": alert.FaultCenterId,
+		"ruleId":        alert.RuleId,
+	}
+	for k, v := range alert.Labels {
+		details[k] = v
+	}
+	event.Payload = &models.PagerDutyPayload{
+		Summary:       truncate(incidentSummary(alert, noticeTmpl), pagerDutySummaryMaxLen),
+		Source:        incidentSource(alert),
+		Severity:      models.GetPagerDutySeverity(alert.Severity),
+		Timestamp:     time.Unix(alert.FirstTriggerTime, 0).Format(time.RFC3339),
+		Group:         alert.FaultCenterId,
+		Class:         alert.RuleName,
+		CustomDetails: details,
+	}
+
+	return tools.JsonMarshalToString(event)
+}
+
+func opsgenieTemplate(alert models.AlertCurEvent, noticeTmpl models.NoticeTemplateExample, action cardAction) string {
+	t := models.OpsgenieAlert{
+		Alias:  alert.Fingerprint,
+		Source: "WatchAlert",
+	}
+	if alert.IsRecovered {
+		t.Note = "告警已恢复"
+		return tools.JsonMarshalToString(t)
+	}
+
+	description := alert.Annotations
+	if url := action.config.GetEventDetailUrl(alert); url != "" {
+		description += "\n\n" + url
+	}
+
+	details := map[string]string{
+		"faultCenterId": alert.FaultCenterId,
+		"ruleId":        alert.RuleId,
+	}
+	for k, v := range alert.Labels {
+		details[k] = fmt.Sprint(v)
+	}
+
+	t.Message = truncate(incidentSummary(alert, noticeTmpl), opsgenieMessageMaxLen)
+	t.Description = description
+	t.Priority = models.GetOpsgeniePriority(alert.Severity)
+	t.Tags = []string{alert.Severity, alert.RuleName}
+	t.Details = details
+
+	return tools.JsonMarshalToString(t)
+}
+
+// truncate 按字符截断
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
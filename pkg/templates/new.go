@@ -11,21 +11,46 @@ type Template struct {
 
 // NewTemplate 创建模板
 func NewTemplate(ctx *ctx.Context, alert models.AlertCurEvent, route models.Route) (Template, error) {
+	return NewHandledTemplate(ctx, alert, route, "")
+}
+
+// NewHandledTemplate 创建展示处理结果的模板, 用于卡片交互后更新原卡片
+func NewHandledTemplate(ctx *ctx.Context, alert models.AlertCurEvent, route models.Route, handled string) (Template, error) {
+	action := cardAction{handled: handled}
+	if setting, err := ctx.DB.Setting().Get(); err == nil {
+		action.config = setting.ChatOpsConfig
+	}
+
+	// PagerDuty、Opsgenie 按事件字段映射, 通知模版为可选, 仅用于生成告警摘要
+	switch route.NoticeType {
+	case "PagerDuty":
+		noticeTmpl, _ := ctx.DB.NoticeTmpl().Get(route.NoticeTmplId)
+		return Template{CardContentMsg: pagerDutyTemplate(alert, noticeTmpl, action)}, nil
+	case "Opsgenie":
+		noticeTmpl, _ := ctx.DB.NoticeTmpl().Get(route.NoticeTmplId)
+		return Template{CardContentMsg: opsgenieTemplate(alert, noticeTmpl, action)}, nil
+	}
+
 	noticeTmpl, err := ctx.DB.NoticeTmpl().Get(route.NoticeTmplId)
 	if err != nil {
 		return Template{}, err
 	}
+
 	switch route.NoticeType {
 	case "FeiShu":
-		return Template{CardContentMsg: feishuTemplate(alert, noticeTmpl)}, nil
+		return Template{CardContentMsg: feishuTemplate(alert, noticeTmpl, action)}, nil
 	case "DingDing":
-		return Template{CardContentMsg: dingdingTemplate(alert, noticeTmpl)}, nil
+		return Template{CardContentMsg: dingdingTemplate(alert, noticeTmpl, action)}, nil
 	case "Email":
 		return Template{CardContentMsg: emailTemplate(alert, noticeTmpl)}, nil
 	case "WeChat":
 		return Template{CardContentMsg: wechatTemplate(alert, noticeTmpl)}, nil
 	case "Slack":
-		return Template{CardContentMsg: slackTemplate(alert, noticeTmpl)}, nil
+		return Template{CardContentMsg: slackTemplate(alert, noticeTmpl, action)}, nil
+	case "Teams":
+		return Template{CardContentMsg: teamsTemplate(alert, noticeTmpl, action)}, nil
+	case "Telegram":
+		return Template{CardContentMsg: telegramTemplate(alert, noticeTmpl, action)}, nil
 	case "Phone":
 		return Template{CardContentMsg: alert.GetJsonString()}, nil
 	case "SMS":
@@ -11,30 +11,34 @@ import (
 	"github.com/zeromicro/go-zero/core/logc"
 )
 
+// templateFuncs 消息模版中可使用的函数
+var templateFuncs = template.FuncMap{
+	// 时间戳转格式化字符串: {{ .FirstTriggerTime | formatTime }}
+	"formatTime": func(timestamp int64) string {
+		if timestamp == 0 {
+			return "-"
+		}
+		return time.Unix(timestamp, 0).Format("2006-01-02 15:04:05")
+	},
+	// 计算持续时间: {{ duration .FirstTriggerTime }}
+	"duration": func(first int64) string {
+		cur := time.Now().Unix()
+		if first == 0 || cur == 0 || cur < first {
+			return "0s"
+		}
+		d := time.Duration(cur-first) * time.Second
+		return d.String()
+	},
+	// 转换为 JSON, 用于在 JSON 请求体中安全引用文本: {{ json .Alarm.Annotations }}
+	"json": func(v interface{}) string {
+		return tools.JsonMarshalToString(v)
+	},
+}
+
 // ParserTemplate 处理告警推送的消息模版
 func ParserTemplate(defineName string, alert models.AlertCurEvent, templateStr string) string {
-	// 1. 定义模板函数
-	funcMap := template.FuncMap{
-		// 时间戳转格式化字符串: {{ .FirstTriggerTime | formatTime }}
-		"formatTime": func(timestamp int64) string {
-			if timestamp == 0 {
-				return "-"
-			}
-			return time.Unix(timestamp, 0).Format("2006-01-02 15:04:05")
-		},
-		// 计算持续时间: {{ duration .FirstTriggerTime }}
-		"duration": func(first int64) string {
-			cur := time.Now().Unix()
-			if first == 0 || cur == 0 || cur < first {
-				return "0s"
-			}
-			d := time.Duration(cur-first) * time.Second
-			return d.String()
-		},
-	}
-
-	// 2. 解析模板并注入函数
-	tmpl, err := template.New("tmpl").Funcs(funcMap).Parse(templateStr)
+	// 解析模板并注入函数
+	tmpl, err := template.New("tmpl").Funcs(templateFuncs).Parse(templateStr)
 	if err != nil {
 		logc.Errorf(context.Background(), "模板解析失败: %v, template: %s", err, templateStr)
 		return ""
@@ -43,6 +47,26 @@ func ParserTemplate(defineName string, alert models.AlertCurEvent, templateStr s
 	return renderNamedTemplate(tmpl, defineName, alert)
 }
 
+// CheckTemplate 校验模版语法
+func CheckTemplate(templateStr string) error {
+	_, err := template.New("tmpl").Funcs(templateFuncs).Parse(templateStr)
+	return err
+}
+
+// RenderTemplate 使用任意数据渲染模版, 如 WebHook 请求体
+func RenderTemplate(templateStr string, data interface{}) (string, error) {
+	tmpl, err := template.New("tmpl").Funcs(templateFuncs).Parse(templateStr)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
 // renderNamedTemplate 渲染模板
 func renderNamedTemplate(tmpl *template.Template, name string, alert models.AlertCurEvent) string {
 	var buf bytes.Buffer
@@ -5,10 +5,52 @@ import (
 	"watchAlert/pkg/tools"
 )
 
-func slackTemplate(alert models.AlertCurEvent, noticeTmpl models.NoticeTemplateExample) string {
+func slackTemplate(alert models.AlertCurEvent, noticeTmpl models.NoticeTemplateExample, action cardAction) string {
 	t := models.SlackMsgTemplate{
 		Text: ParserTemplate("Event", alert, noticeTmpl.Template),
 	}
 
+	handled := action.getHandled(alert)
+	buttons := action.getButtons(alert)
+	if handled == "" && len(buttons) == 0 {
+		return tools.JsonMarshalToString(t)
+	}
+
+	// 使用 Block Kit 展示处理结果及操作按钮, Text 作为通知摘要
+	t.Blocks = append(t.Blocks, map[string]interface{}{
+		"type": "section",
+		"text": map[string]interface{}{"type": "mrkdwn", "text": t.Text},
+	})
+	if handled != "" {
+		t.Blocks = append(t.Blocks, map[string]interface{}{
+			"type":     "context",
+			"elements": []map[string]interface{}{{"type": "mrkdwn", "text": "*处理结果:* " + handled}},
+		})
+	}
+	if len(buttons) > 0 {
+		var elements []map[string]interface{}
+		for _, button := range buttons {
+			element := map[string]interface{}{
+				"type":      "button",
+				"action_id": button.Action,
+				"text":      map[string]interface{}{"type": "plain_text", "text": button.Text},
+			}
+			if button.Url != "" {
+				element["url"] = button.Url
+			} else {
+				element["value"] = tools.JsonMarshalToString(models.NewChatOpsActionValue(button.Action, alert, noticeTmpl.ID))
+			}
+			// Slack 按钮仅支持 primary、danger 两种样式
+			if button.Style != "default" {
+				element["style"] = button.Style
+			}
+			elements = append(elements, element)
+		}
+		t.Blocks = append(t.Blocks, map[string]interface{}{
+			"type":     "actions",
+			"elements": elements,
+		})
+	}
+
 	return tools.JsonMarshalToString(t)
 }
@@ -0,0 +1,75 @@
+package templates
+
+import (
+	"regexp"
+	"watchAlert/internal/models"
+	"watchAlert/pkg/tools"
+)
+
+// teamsMentionRe 匹配模版中的 <at>UPN</at> 提及
+var teamsMentionRe = regexp.MustCompile(`<at>([^<]+)</at>`)
+
+func teamsTemplate(alert models.AlertCurEvent, noticeTmpl models.NoticeTemplateExample, action cardAction) string {
+	Title := ParserTemplate("Title", alert, noticeTmpl.Template)
+	Footer := ParserTemplate("Footer", alert, noticeTmpl.Template)
+	Event := ParserTemplate("Event", alert, noticeTmpl.Template)
+
+	color := "Attention"
+	if alert.IsRecovered {
+		color = "Good"
+	}
+
+	card := models.TeamsAdaptiveCard{
+		Schema:  "http://adaptivecards.io/schemas/adaptive-card.json",
+		Type:    "AdaptiveCard",
+		Version: "1.4",
+		Body: []map[string]interface{}{
+			{"type": "TextBlock", "text": Title, "weight": "Bolder", "size": "Medium", "color": color, "wrap": true},
+			{"type": "TextBlock", "text": Event, "wrap": true},
+		},
+		// 卡片宽度铺满消息区域
+		MsTeams: map[string]interface{}{"width": "Full"},
+	}
+	if Footer != "" {
+		card.Body = append(card.Body, map[string]interface{}{"type": "TextBlock", "text": Footer, "wrap": true, "isSubtle": true})
+	}
+	if handled := action.getHandled(alert); handled != "" {
+		card.Body = append(card.Body, map[string]interface{}{"type": "TextBlock", "text": "**处理结果:** " + handled, "wrap": true})
+	}
+
+	// 传入 Webhook 不支持回传交互, 仅保留跳转按钮
+	for _, button := range action.getButtons(alert) {
+		if button.Url == "" {
+			continue
+		}
+		card.Actions = append(card.Actions, map[string]interface{}{"type": "Action.OpenUrl", "title": button.Text, "url": button.Url})
+	}
+
+	// 卡片文本中的 <at> 标签需声明对应的提及实体才会通知到用户
+	var entities []map[string]interface{}
+	seen := make(map[string]bool)
+	for _, text := range []string{Title, Event, Footer} {
+		for _, match := range teamsMentionRe.FindAllStringSubmatch(text, -1) {
+			if seen[match[1]] {
+				continue
+			}
+			seen[match[1]] = true
+			entities = append(entities, map[string]interface{}{
+				"type":      "mention",
+				"text":      match[0],
+				"mentioned": map[string]interface{}{"id": match[1], "name": match[1]},
+			})
+		}
+	}
+	if len(entities) > 0 {
+		card.MsTeams["entities"] = entities
+	}
+
+	return tools.JsonMarshalToString(models.TeamsMsgTemplate{
+		Type: "message",
+		Attachments: []models.TeamsAttachment{{
+			ContentType: "application/vnd.microsoft.card.adaptive",
+			Content:     card,
+		}},
+	})
+}
@@ -0,0 +1,41 @@
+package templates
+
+import (
+	"html"
+	"watchAlert/internal/models"
+	"watchAlert/pkg/tools"
+)
+
+// telegramTemplate Telegram HTML 消息, 模版中的变量可通过 {{ html .Annotations }} 转义
+func telegramTemplate(alert models.AlertCurEvent, noticeTmpl models.NoticeTemplateExample, action cardAction) string {
+	Title := ParserTemplate("Title", alert, noticeTmpl.Template)
+	Footer := ParserTemplate("Footer", alert, noticeTmpl.Template)
+
+	t := models.TelegramMsgTemplate{
+		Text: "<b>" + Title + "</b>" +
+			"\n" + "\n" +
+			ParserTemplate("Event", alert, noticeTmpl.Template) +
+			"\n" +
+			Footer,
+		ParseMode:             "HTML",
+		DisableWebPagePreview: true,
+	}
+
+	if handled := action.getHandled(alert); handled != "" {
+		t.Text += "\n\n<b>处理结果:</b> " + html.EscapeString(handled)
+	}
+
+	// 群机器人未配置回调, 仅保留跳转按钮
+	var buttons []map[string]interface{}
+	for _, button := range action.getButtons(alert) {
+		if button.Url == "" {
+			continue
+		}
+		buttons = append(buttons, map[string]interface{}{"text": button.Text, "url": button.Url})
+	}
+	if len(buttons) > 0 {
+		t.ReplyMarkup = map[string]interface{}{"inline_keyboard": [][]map[string]interface{}{buttons}}
+	}
+
+	return tools.JsonMarshalToString(t)
+}
@@ -0,0 +1,122 @@
+package ticket
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"watchAlert/internal/models"
+	"watchAlert/pkg/templates"
+	"watchAlert/pkg/tools"
+)
+
+// httpTicketer 通用工单接口, 请求地址及请求体通过模版渲染
+type httpTicketer struct {
+	config models.HttpTicketConfig
+}
+
+// requestData 模版中可引用的数据
+type requestData struct {
+	Event    models.AlertCurEvent
+	Key      string
+	Comment  string
+	Username string
+}
+
+func newHttpTicketer(config models.HttpTicketConfig) (Ticketer, error) {
+	if config.Create.Url == "" {
+		return nil, fmt.Errorf("工单创建接口地址不能为空")
+	}
+	if config.KeyPath == "" {
+		return nil, fmt.Errorf("工单编号的字段路径不能为空")
+	}
+	for _, request := range []models.TicketRequest{config.Create, config.Comment, config.Resolve} {
+		for _, text := range []string{request.Url, request.Body} {
+			if err := templates.CheckTemplate(text); err != nil {
+				return nil, fmt.Errorf("工单请求模版无效: %v", err)
+			}
+		}
+	}
+
+	return &httpTicketer{config: config}, nil
+}
+
+func (h *httpTicketer) Create(event models.AlertCurEvent) (string, error) {
+	var result map[string]interface{}
+	if err := h.do(h.config.Create, requestData{Event: event}, &result); err != nil {
+		return "", err
+	}
+
+	key := getPath(result, h.config.KeyPath)
+	if key == "" {
+		return "", fmt.Errorf("响应中未找到工单编号: %s", h.config.KeyPath)
+	}
+	return key, nil
+}
+
+func (h *httpTicketer) Comment(key string, event models.AlertCurEvent, username, content string) error {
+	if h.config.Comment.Url == "" {
+		return nil
+	}
+	return h.do(h.config.Comment, requestData{Event: event, Key: key, Comment: content, Username: username}, nil)
+}
+
+func (h *httpTicketer) Resolve(key string, event models.AlertCurEvent) error {
+	if h.config.Resolve.Url == "" {
+		return nil
+	}
+	return h.do(h.config.Resolve, requestData{Event: event, Key: key}, nil)
+}
+
+func (h *httpTicketer) do(request models.TicketRequest, data requestData, result interface{}) error {
+	url, err := templates.RenderTemplate(request.Url, data)
+	if err != nil {
+		return err
+	}
+	body, err := templates.RenderTemplate(request.Body, data)
+	if err != nil {
+		return err
+	}
+
+	method := strings.ToUpper(request.Method)
+	if method == "" {
+		method = http.MethodPost
+	}
+
+	res, err := tools.Request(method, request.Headers, url, bytes.NewReader([]byte(body)), 10)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if err := checkResponse(res); err != nil {
+		return err
+	}
+
+	if result == nil {
+		return nil
+	}
+	return tools.ParseReaderBody(res.Body, result)
+}
+
+// getPath 按字段路径获取响应中的值, 如 data.id
+func getPath(data map[string]interface{}, path string) string {
+	var value interface{} = data
+	for _, field := range strings.Split(path, ".") {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return ""
+		}
+		value = m[field]
+	}
+
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case float64:
+		// JSON 数字避免使用科学计数法
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
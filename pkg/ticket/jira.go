@@ -0,0 +1,133 @@
+package ticket
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"watchAlert/internal/models"
+	"watchAlert/pkg/tools"
+)
+
+// jiraTicketer Jira REST API v2
+type jiraTicketer struct {
+	config  models.JiraTicketConfig
+	headers map[string]string
+}
+
+func newJiraTicketer(config models.JiraTicketConfig) (Ticketer, error) {
+	if config.Url == "" || config.Token == "" || config.ProjectKey == "" {
+		return nil, fmt.Errorf("Jira 地址、Token 及项目 Key 不能为空")
+	}
+	if config.IssueType == "" {
+		config.IssueType = "Task"
+	}
+	config.Url = strings.TrimRight(config.Url, "/")
+
+	// Jira Cloud 使用邮箱 + API Token, Jira Server 使用 Personal Access Token
+	headers := map[string]string{"Authorization": "Bearer " + config.Token}
+	if config.Username != "" {
+		headers = tools.CreateBasicAuthHeader(config.Username, config.Token)
+	}
+
+	return &jiraTicketer{config: config, headers: headers}, nil
+}
+
+func (j *jiraTicketer) Create(event models.AlertCurEvent) (string, error) {
+	var labels []string
+	for k, v := range event.Labels {
+		labels = append(labels, fmt.Sprintf("%s=%v", k, v))
+	}
+	sort.Strings(labels)
+
+	description := fmt.Sprintf("*告警规则:* %s\n*告警等级:* %s\n*故障中心:* %s\n*触发时间:* %s\n*事件标签:*\n%s\n\n*告警详情:*\n%s",
+		event.RuleName, event.Severity, event.FaultCenterId,
+		time.Unix(event.FirstTriggerTime, 0).Format(time.DateTime),
+		"* "+strings.Join(labels, "\n* "),
+		event.Annotations)
+
+	body := map[string]interface{}{
+		"fields": map[string]interface{}{
+			"project":     map[string]string{"key": j.config.ProjectKey},
+			"issuetype":   map[string]string{"name": j.config.IssueType},
+			"summary":     fmt.Sprintf("[%s] %s", event.Severity, event.RuleName),
+			"description": description,
+			"labels":      []string{"WatchAlert", event.Severity},
+		},
+	}
+
+	var result struct {
+		Key string `json:"key"`
+	}
+	if err := j.post("/rest/api/2/issue", body, &result); err != nil {
+		return "", err
+	}
+	return result.Key, nil
+}
+
+func (j *jiraTicketer) Comment(key string, event models.AlertCurEvent, username, content string) error {
+	body := map[string]string{"body": fmt.Sprintf("%s: %s", username, content)}
+	return j.post("/rest/api/2/issue/"+key+"/comment", body, nil)
+}
+
+// Resolve 执行名称或目标状态与配置一致的流转, 恢复评论随流转一并提交, 避免重试时重复评论
+// 未配置流转时仅添加恢复评论
+func (j *jiraTicketer) Resolve(key string, event models.AlertCurEvent) error {
+	comment := fmt.Sprintf("告警已于 %s 恢复", time.Unix(event.RecoverTime, 0).Format(time.DateTime))
+	if j.config.Transition == "" {
+		return j.post("/rest/api/2/issue/"+key+"/comment", map[string]string{"body": comment}, nil)
+	}
+
+	res, err := tools.Get(j.headers, j.config.Url+"/rest/api/2/issue/"+key+"/transitions", 10)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if err := checkResponse(res); err != nil {
+		return err
+	}
+
+	var result struct {
+		Transitions []struct {
+			Id   string `json:"id"`
+			Name string `json:"name"`
+			To   struct {
+				Name string `json:"name"`
+			} `json:"to"`
+		} `json:"transitions"`
+	}
+	if err := tools.ParseReaderBody(res.Body, &result); err != nil {
+		return err
+	}
+
+	for _, transition := range result.Transitions {
+		if strings.EqualFold(transition.Name, j.config.Transition) || strings.EqualFold(transition.To.Name, j.config.Transition) {
+			body := map[string]interface{}{
+				"transition": map[string]string{"id": transition.Id},
+				"update": map[string]interface{}{
+					"comment": []interface{}{map[string]interface{}{"add": map[string]string{"body": comment}}},
+				},
+			}
+			return j.post("/rest/api/2/issue/"+key+"/transitions", body, nil)
+		}
+	}
+
+	return fmt.Errorf("工单 %s 当前状态不支持流转: %s", key, j.config.Transition)
+}
+
+func (j *jiraTicketer) post(path string, body interface{}, result interface{}) error {
+	res, err := tools.Post(j.headers, j.config.Url+path, bytes.NewReader(tools.JsonMarshalToByte(body)), 10)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if err := checkResponse(res); err != nil {
+		return err
+	}
+
+	if result == nil {
+		return nil
+	}
+	return tools.ParseReaderBody(res.Body, result)
+}
@@ -0,0 +1,40 @@
+package ticket
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"watchAlert/internal/models"
+)
+
+// Ticketer 工单系统
+type Ticketer interface {
+	// Create 创建工单, 返回工单编号
+	Create(event models.AlertCurEvent) (string, error)
+	// Comment 添加工单评论
+	Comment(key string, event models.AlertCurEvent, username, content string) error
+	// Resolve 事件恢复时流转工单状态
+	Resolve(key string, event models.AlertCurEvent) error
+}
+
+// NewTicketer 根据工单配置创建工单系统
+func NewTicketer(config models.TicketConfig) (Ticketer, error) {
+	switch config.Type {
+	case models.TicketTypeJira:
+		return newJiraTicketer(config.Jira)
+	case models.TicketTypeHttp:
+		return newHttpTicketer(config.Http)
+	default:
+		return nil, fmt.Errorf("无效的工单类型: %s", config.Type)
+	}
+}
+
+// checkResponse 检查响应状态码, 非 2xx 时返回响应内容
+func checkResponse(res *http.Response) error {
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		return nil
+	}
+
+	body, _ := io.ReadAll(res.Body)
+	return fmt.Errorf("status code %d, %s", res.StatusCode, string(body))
+}
@@ -0,0 +1,219 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/google/uuid"
+	"github.com/zeromicro/go-zero/core/logc"
+)
+
+const (
+	// ClusterMembersKey 集群成员列表的 Redis Key, score 为最近一次心跳时间
+	ClusterMembersKey = "w8t:cluster:members"
+	// ClusterMemberTTL 成员心跳超时时间（秒）, 超时后视为离开集群
+	ClusterMemberTTL = 10
+	// ClusterHeartbeatInterval 成员心跳间隔（秒）
+	ClusterHeartbeatInterval = 2
+
+	// hashRingReplicas 每个成员在哈希环上的虚拟节点数
+	hashRingReplicas = 128
+)
+
+// ClusterMember 集群成员, 通过 Redis 维护存活成员列表, 按一致性哈希分配任务
+type ClusterMember struct {
+	client     *redis.Client
+	ctx        context.Context
+	instanceID string
+	mu         sync.RWMutex
+	members    []string
+	ring       HashRing
+	onChange   func()
+	// lastHeartbeat 最近一次成功上报心跳并刷新成员列表的时间
+	lastHeartbeat int64
+	// changedAt 最近一次成员列表变化的时间
+	changedAt int64
+}
+
+// NewClusterMember 创建集群成员, 成员列表变化时调用 onChange
+func NewClusterMember(ctx context.Context, client *redis.Client, onChange func()) *ClusterMember {
+	return &ClusterMember{
+		client:     client,
+		ctx:        ctx,
+		instanceID: uuid.New().String(),
+		onChange:   onChange,
+	}
+}
+
+// Start 加入集群并开始心跳
+func (c *ClusterMember) Start() {
+	logc.Infof(c.ctx, "实例 ID: %s", c.instanceID)
+
+	c.heartbeat()
+	go c.heartbeatLoop()
+}
+
+func (c *ClusterMember) heartbeatLoop() {
+	ticker := time.NewTicker(time.Second * ClusterHeartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			c.heartbeat()
+		case <-c.ctx.Done():
+			c.leave()
+			return
+		}
+	}
+}
+
+// heartbeat 上报心跳, 清理超时成员并刷新成员列表
+func (c *ClusterMember) heartbeat() {
+	now := time.Now().Unix()
+	if err := c.client.ZAdd(ClusterMembersKey, redis.Z{Score: float64(now), Member: c.instanceID}).Err(); err != nil {
+		logc.Errorf(c.ctx, "集群心跳上报失败: %v", err)
+		c.fence(now)
+		return
+	}
+	c.client.ZRemRangeByScore(ClusterMembersKey, "-inf", fmt.Sprintf("(%d", now-ClusterMemberTTL))
+
+	members, err := c.client.ZRange(ClusterMembersKey, 0, -1).Result()
+	if err != nil {
+		logc.Errorf(c.ctx, "获取集群成员失败: %v", err)
+		c.fence(now)
+		return
+	}
+	sort.Strings(members)
+
+	c.mu.Lock()
+	c.lastHeartbeat = now
+	if slices.Equal(c.members, members) {
+		c.mu.Unlock()
+		return
+	}
+	c.members = members
+	c.ring = NewHashRing(members)
+	c.changedAt = now
+	c.mu.Unlock()
+
+	logc.Infof(c.ctx, "集群成员变更, 当前成员数: %d, 成员: %v", len(members), members)
+	if c.onChange != nil {
+		go c.onChange()
+	}
+}
+
+// fence 超过 ClusterMemberTTL 未能上报心跳时, 其他成员已将本实例移出集群并接管任务,
+// 清空成员列表使 Owns 返回 false, 并通知停止本地任务, 心跳恢复后重新分配
+func (c *ClusterMember) fence(now int64) {
+	c.mu.Lock()
+	if len(c.members) == 0 || now-c.lastHeartbeat < ClusterMemberTTL {
+		c.mu.Unlock()
+		return
+	}
+	c.members = nil
+	c.ring = HashRing{}
+	c.mu.Unlock()
+
+	logc.Errorf(c.ctx, "超过 %d 秒未能上报心跳, 停止本实例负责的任务", ClusterMemberTTL)
+	if c.onChange != nil {
+		go c.onChange()
+	}
+}
+
+// leave 离开集群, 其他成员在下一次心跳时重新分配任务
+func (c *ClusterMember) leave() {
+	if err := c.client.ZRem(ClusterMembersKey, c.instanceID).Err(); err != nil {
+		logc.Errorf(c.ctx, "离开集群失败: %v", err)
+	}
+}
+
+// Owns 判断 key 对应的任务是否由当前实例负责
+func (c *ClusterMember) Owns(key string) bool {
+	return c.Owner(key) == c.instanceID
+}
+
+// Owner 获取 key 对应任务的负责实例
+func (c *ClusterMember) Owner(key string) string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.ring.Get(key)
+}
+
+// Members 获取当前存活的成员
+func (c *ClusterMember) Members() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return slices.Clone(c.members)
+}
+
+// ChangedAt 获取最近一次成员列表变化的时间
+func (c *ClusterMember) ChangedAt() time.Time {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return time.Unix(c.changedAt, 0)
+}
+
+// GetInstanceID 获取当前实例 ID
+func (c *ClusterMember) GetInstanceID() string {
+	return c.instanceID
+}
+
+// HashRing 一致性哈希环, 成员增减时只有相邻区间的 key 会迁移
+type HashRing struct {
+	hashes []uint64
+	nodes  map[uint64]string
+}
+
+func NewHashRing(members []string) HashRing {
+	ring := HashRing{nodes: make(map[uint64]string, len(members)*hashRingReplicas)}
+	for _, member := range members {
+		for i := 0; i < hashRingReplicas; i++ {
+			h := ringHash(fmt.Sprintf("%s#%d", member, i))
+			ring.nodes[h] = member
+			ring.hashes = append(ring.hashes, h)
+		}
+	}
+	sort.Slice(ring.hashes, func(i, j int) bool {
+		return ring.hashes[i] < ring.hashes[j]
+	})
+
+	return ring
+}
+
+// Get 获取 key 所在的成员, 环为空时返回空字符串
+func (r HashRing) Get(key string) string {
+	if len(r.hashes) == 0 {
+		return ""
+	}
+
+	h := ringHash(key)
+	i := sort.Search(len(r.hashes), func(i int) bool {
+		return r.hashes[i] >= h
+	})
+	if i == len(r.hashes) {
+		i = 0
+	}
+
+	return r.nodes[r.hashes[i]]
+}
+
+// ringHash fnv64a 对相近的字符串分布不均, 追加 murmur3 的混淆步骤
+func ringHash(s string) uint64 {
+	h := HashAdd(HashNew(), s)
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	h *= 0xc4ceb9fe1a85ec53
+	h ^= h >> 33
+
+	return h
+}
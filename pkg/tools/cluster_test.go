@@ -0,0 +1,107 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestHashRingGet(t *testing.T) {
+	tests := []struct {
+		name    string
+		members []string
+		key     string
+		want    string
+	}{
+		{name: "empty ring", key: "a-1", want: ""},
+		{name: "single member", members: []string{"node-1"}, key: "a-1", want: "node-1"},
+		{name: "single member other key", members: []string{"node-1"}, key: "fc-1", want: "node-1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewHashRing(tt.members).Get(tt.key); got != tt.want {
+				t.Errorf("Get(%q) = %q, want %q", tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHashRingRebalance(t *testing.T) {
+	keys := make([]string, 3000)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("a-%d", i)
+	}
+
+	tests := []struct {
+		name   string
+		before []string
+		after  []string
+	}{
+		{name: "member order does not matter", before: []string{"node-1", "node-2", "node-3"}, after: []string{"node-3", "node-1", "node-2"}},
+		{name: "member joins", before: []string{"node-1", "node-2"}, after: []string{"node-1", "node-2", "node-3"}},
+		{name: "member leaves", before: []string{"node-1", "node-2", "node-3"}, after: []string{"node-1", "node-3"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before, after := NewHashRing(tt.before), NewHashRing(tt.after)
+			counts := make(map[string]int)
+			for _, key := range keys {
+				from, to := before.Get(key), after.Get(key)
+				counts[to]++
+				// 只有离开成员的 key 或迁移到新成员的 key 会变化
+				if from != to && slices.Contains(tt.after, from) && slices.Contains(tt.before, to) {
+					t.Fatalf("key %s moved from %s to %s", key, from, to)
+				}
+			}
+
+			for _, member := range tt.after {
+				share := float64(counts[member]) / float64(len(keys))
+				if expect := 1 / float64(len(tt.after)); share < expect*0.6 || share > expect*1.4 {
+					t.Errorf("member %s owns %.2f of keys, want about %.2f", member, share, expect)
+				}
+			}
+		})
+	}
+}
+
+func TestClusterMemberFence(t *testing.T) {
+	now := time.Now().Unix()
+	tests := []struct {
+		name          string
+		lastHeartbeat int64
+		fenced        bool
+	}{
+		{name: "recent heartbeat", lastHeartbeat: now - ClusterHeartbeatInterval},
+		{name: "heartbeat expired", lastHeartbeat: now - ClusterMemberTTL, fenced: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changed := make(chan struct{}, 1)
+			c := &ClusterMember{
+				ctx:           context.Background(),
+				instanceID:    "node-1",
+				members:       []string{"node-1"},
+				ring:          NewHashRing([]string{"node-1"}),
+				lastHeartbeat: tt.lastHeartbeat,
+				onChange:      func() { changed <- struct{}{} },
+			}
+
+			c.fence(now)
+			if c.Owns("a-1") == tt.fenced || (len(c.Members()) == 0) != tt.fenced {
+				t.Fatalf("owns = %v, members = %v, want fenced %v", c.Owns("a-1"), c.Members(), tt.fenced)
+			}
+			if tt.fenced {
+				select {
+				case <-changed:
+				case <-time.After(time.Second):
+					t.Error("onChange not called after fencing")
+				}
+			}
+		})
+	}
+}
@@ -1,7 +1,9 @@
 package tools
 
 import (
+	"crypto/hmac"
 	"crypto/md5"
+	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
 )
@@ -50,3 +52,10 @@ func GenerateHashPassword(passwd string) string {
 	arr := md5.Sum([]byte(passwd))
 	return hex.EncodeToString(arr[:])
 }
+
+// HmacSha256 计算 HMAC-SHA256 签名
+func HmacSha256(key, data string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(data))
+	return hex.EncodeToString(mac.Sum(nil))
+}
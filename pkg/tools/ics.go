@@ -0,0 +1,462 @@
+package tools
+
+import (
+	"bufio"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// IcsEvent iCalendar 中的 VEVENT
+type IcsEvent struct {
+	UID         string
+	Summary     string
+	Description string
+	Start       time.Time
+	End         time.Time
+	AllDay      bool
+	Attendees   []string // 参与人邮箱
+
+	rrule        string
+	exDates      []time.Time
+	recurrenceId time.Time
+}
+
+const (
+	// icsMaxOccurrences 单个重复事件最多展开的次数
+	icsMaxOccurrences = 1000
+	// icsRecurrenceHorizon 未指定 UNTIL 及 COUNT 的重复事件展开到当前时间之后的时长
+	icsRecurrenceHorizon = 365 * 24 * time.Hour
+)
+
+const icsTimeLayout = "20060102T150405Z"
+
+var icsEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`)
+var icsUnescaper = strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n")
+
+// EncodeIcs 生成 iCalendar 日历, 时间统一使用 UTC
+func EncodeIcs(calName string, events []IcsEvent) string {
+	var b strings.Builder
+	writeIcsLine(&b, "BEGIN:VCALENDAR")
+	writeIcsLine(&b, "VERSION:2.0")
+	writeIcsLine(&b, "PRODID:-//WatchAlert//Duty Calendar//CN")
+	writeIcsLine(&b, "CALSCALE:GREGORIAN")
+	writeIcsLine(&b, "METHOD:PUBLISH")
+	writeIcsLine(&b, "X-WR-CALNAME:"+icsEscaper.Replace(calName))
+
+	stamp := time.Now().UTC().Format(icsTimeLayout)
+	for _, event := range events {
+		writeIcsLine(&b, "BEGIN:VEVENT")
+		writeIcsLine(&b, "UID:"+event.UID)
+		writeIcsLine(&b, "DTSTAMP:"+stamp)
+		if event.AllDay {
+			writeIcsLine(&b, "DTSTART;VALUE=DATE:"+event.Start.Format("20060102"))
+			writeIcsLine(&b, "DTEND;VALUE=DATE:"+event.End.Format("20060102"))
+		} else {
+			writeIcsLine(&b, "DTSTART:"+event.Start.UTC().Format(icsTimeLayout))
+			writeIcsLine(&b, "DTEND:"+event.End.UTC().Format(icsTimeLayout))
+		}
+		writeIcsLine(&b, "SUMMARY:"+icsEscaper.Replace(event.Summary))
+		if event.Description != "" {
+			writeIcsLine(&b, "DESCRIPTION:"+icsEscaper.Replace(event.Description))
+		}
+		for _, attendee := range event.Attendees {
+			writeIcsLine(&b, "ATTENDEE:mailto:"+attendee)
+		}
+		writeIcsLine(&b, "END:VEVENT")
+	}
+	writeIcsLine(&b, "END:VCALENDAR")
+
+	return b.String()
+}
+
+// writeIcsLine 按 RFC 5545 每行不超过 75 字节折行
+func writeIcsLine(b *strings.Builder, line string) {
+	// 折行后的行首空格同样计入长度
+	limit := 75
+	for len(line) > limit {
+		cut := limit
+		// 避免截断多字节字符
+		for cut > 0 && line[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		b.WriteString(line[:cut] + "\r\n ")
+		line = line[cut:]
+		limit = 74
+	}
+	b.WriteString(line + "\r\n")
+}
+
+// DecodeIcs 解析 iCalendar 日历中的全部 VEVENT, 未指定时区的时间使用 loc
+func DecodeIcs(data string, loc *time.Location) ([]IcsEvent, error) {
+	var (
+		lines   []string
+		scanner = bufio.NewScanner(strings.NewReader(data))
+	)
+	scanner.Buffer(make([]byte, 1024*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		// 折行以空格或制表符开头
+		if len(lines) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	var (
+		events    []IcsEvent
+		overrides []IcsEvent
+		current   *IcsEvent
+	)
+	for _, line := range lines {
+		name, params, value, ok := parseIcsLine(line)
+		if !ok {
+			continue
+		}
+
+		switch {
+		case name == "BEGIN" && value == "VEVENT":
+			current = &IcsEvent{}
+		case name == "END" && value == "VEVENT":
+			if current == nil {
+				continue
+			}
+			if current.Start.IsZero() {
+				return nil, fmt.Errorf("事件 %s 缺少 DTSTART", current.Summary)
+			}
+			if current.End.IsZero() {
+				current.End = current.Start.AddDate(0, 0, 1)
+			}
+			if current.recurrenceId.IsZero() {
+				events = append(events, *current)
+			} else {
+				overrides = append(overrides, *current)
+			}
+			current = nil
+		case current == nil:
+			continue
+		case name == "UID":
+			current.UID = value
+		case name == "SUMMARY":
+			current.Summary = icsUnescaper.Replace(value)
+		case name == "DESCRIPTION":
+			current.Description = icsUnescaper.Replace(value)
+		case name == "ATTENDEE":
+			if strings.HasPrefix(strings.ToLower(value), "mailto:") {
+				current.Attendees = append(current.Attendees, value[len("mailto:"):])
+			}
+		case name == "DTSTART", name == "DTEND", name == "RECURRENCE-ID":
+			t, allDay, err := parseIcsTime(value, params, loc)
+			if err != nil {
+				return nil, fmt.Errorf("解析 %s 失败: %w", name, err)
+			}
+			switch name {
+			case "DTSTART":
+				current.Start, current.AllDay = t, allDay
+			case "DTEND":
+				current.End = t
+			default:
+				current.recurrenceId = t
+			}
+		case name == "RRULE":
+			current.rrule = value
+		case name == "EXDATE":
+			for _, v := range strings.Split(value, ",") {
+				t, _, err := parseIcsTime(v, params, loc)
+				if err != nil {
+					return nil, fmt.Errorf("解析 EXDATE 失败: %w", err)
+				}
+				current.exDates = append(current.exDates, t)
+			}
+		}
+	}
+
+	// 展开重复事件, RECURRENCE-ID 指定的单次修改替换对应的重复实例
+	var result []IcsEvent
+	for _, event := range events {
+		if event.rrule == "" {
+			result = append(result, event)
+			continue
+		}
+
+		for _, override := range overrides {
+			if override.UID == event.UID {
+				event.exDates = append(event.exDates, override.recurrenceId)
+			}
+		}
+		occurrences, err := expandIcsEvent(event, time.Now().Add(icsRecurrenceHorizon))
+		if err != nil {
+			return nil, fmt.Errorf("事件 %s 的 RRULE 无效: %w", event.Summary, err)
+		}
+		result = append(result, occurrences...)
+	}
+	result = append(result, overrides...)
+
+	return result, nil
+}
+
+// icsRecurrence RRULE 中支持的部分: FREQ、INTERVAL、UNTIL、COUNT 及 BYDAY
+type icsRecurrence struct {
+	freq     string
+	interval int
+	until    time.Time
+	count    int
+	byDay    []icsWeekday
+}
+
+// icsWeekday BYDAY 中的星期, nth 为月内第几个, 负数表示倒数, 0 表示全部
+type icsWeekday struct {
+	nth     int
+	weekday time.Weekday
+}
+
+var icsWeekdays = map[string]time.Weekday{
+	"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+	"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+}
+
+func parseIcsRecurrence(value string, loc *time.Location) (icsRecurrence, error) {
+	rec := icsRecurrence{interval: 1}
+	for _, part := range strings.Split(value, ";") {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+
+		var err error
+		switch key, val := strings.ToUpper(kv[0]), strings.ToUpper(kv[1]); key {
+		case "FREQ":
+			rec.freq = val
+		case "INTERVAL":
+			rec.interval, err = strconv.Atoi(val)
+			if err == nil && rec.interval <= 0 {
+				err = fmt.Errorf("INTERVAL 需大于 0")
+			}
+		case "COUNT":
+			rec.count, err = strconv.Atoi(val)
+		case "UNTIL":
+			rec.until, _, err = parseIcsTime(val, nil, loc)
+		case "BYDAY":
+			for _, day := range strings.Split(val, ",") {
+				if len(day) < 2 {
+					return rec, fmt.Errorf("BYDAY %s 无效", day)
+				}
+				weekday, ok := icsWeekdays[day[len(day)-2:]]
+				if !ok {
+					return rec, fmt.Errorf("BYDAY %s 无效", day)
+				}
+				var nth int
+				if prefix := strings.TrimPrefix(day[:len(day)-2], "+"); prefix != "" {
+					if nth, err = strconv.Atoi(prefix); err != nil {
+						return rec, fmt.Errorf("BYDAY %s 无效", day)
+					}
+				}
+				rec.byDay = append(rec.byDay, icsWeekday{nth: nth, weekday: weekday})
+			}
+		}
+		if err != nil {
+			return rec, fmt.Errorf("%s: %w", kv[0], err)
+		}
+	}
+
+	switch rec.freq {
+	case "DAILY", "WEEKLY", "MONTHLY", "YEARLY":
+	default:
+		return rec, fmt.Errorf("不支持的 FREQ: %s", rec.freq)
+	}
+
+	return rec, nil
+}
+
+// expandIcsEvent 展开重复事件, 未指定 UNTIL 及 COUNT 时展开到 horizon
+func expandIcsEvent(event IcsEvent, horizon time.Time) ([]IcsEvent, error) {
+	rec, err := parseIcsRecurrence(event.rrule, event.Start.Location())
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		result   []IcsEvent
+		duration = event.End.Sub(event.Start)
+		count    int
+	)
+	for period := 0; ; period++ {
+		candidates := rec.periodStarts(event.Start, period)
+		if candidates == nil {
+			break
+		}
+
+		for _, start := range candidates {
+			if start.Before(event.Start) {
+				continue
+			}
+			if !rec.until.IsZero() && start.After(rec.until) {
+				return result, nil
+			}
+			if rec.until.IsZero() && rec.count == 0 && start.After(horizon) {
+				return result, nil
+			}
+			if count++; (rec.count > 0 && count > rec.count) || count > icsMaxOccurrences {
+				return result, nil
+			}
+			if slices.ContainsFunc(event.exDates, start.Equal) {
+				continue
+			}
+
+			occurrence := event
+			occurrence.Start, occurrence.End = start, start.Add(duration)
+			occurrence.rrule, occurrence.exDates = "", nil
+			result = append(result, occurrence)
+		}
+	}
+
+	return result, nil
+}
+
+// periodStarts 第 period 个周期内的全部实例开始时间, 按时间排序, 超出可表示范围时返回 nil
+func (rec icsRecurrence) periodStarts(dtStart time.Time, period int) []time.Time {
+	var (
+		y, m, d = dtStart.Date()
+		hh, mm  = dtStart.Hour(), dtStart.Minute()
+		ss, loc = dtStart.Second(), dtStart.Location()
+		n       = period * rec.interval
+		starts  = []time.Time{}
+	)
+	if n > 100000 {
+		return nil
+	}
+
+	switch rec.freq {
+	case "DAILY":
+		day := time.Date(y, m, d+n, hh, mm, ss, 0, loc)
+		if rec.matchWeekday(day) {
+			starts = append(starts, day)
+		}
+
+	case "WEEKLY":
+		if len(rec.byDay) == 0 {
+			return append(starts, time.Date(y, m, d+7*n, hh, mm, ss, 0, loc))
+		}
+		// 周从星期一开始
+		monday := d - (int(dtStart.Weekday())+6)%7 + 7*n
+		for i := 0; i < 7; i++ {
+			day := time.Date(y, m, monday+i, hh, mm, ss, 0, loc)
+			if rec.matchWeekday(day) {
+				starts = append(starts, day)
+			}
+		}
+
+	case "MONTHLY":
+		first := time.Date(y, m+time.Month(n), 1, hh, mm, ss, 0, loc)
+		if len(rec.byDay) == 0 {
+			if day := time.Date(y, m+time.Month(n), d, hh, mm, ss, 0, loc); day.Month() == first.Month() {
+				starts = append(starts, day)
+			}
+			return starts
+		}
+		for day := first; day.Month() == first.Month(); day = day.AddDate(0, 0, 1) {
+			if rec.matchMonthWeekday(day) {
+				starts = append(starts, day)
+			}
+		}
+
+	case "YEARLY":
+		if day := time.Date(y+n, m, d, hh, mm, ss, 0, loc); day.Day() == d {
+			starts = append(starts, day)
+		}
+	}
+
+	return starts
+}
+
+// matchWeekday BYDAY 为空时匹配全部日期
+func (rec icsRecurrence) matchWeekday(day time.Time) bool {
+	if len(rec.byDay) == 0 {
+		return true
+	}
+	for _, w := range rec.byDay {
+		if w.weekday == day.Weekday() {
+			return true
+		}
+	}
+	return false
+}
+
+// matchMonthWeekday 匹配月内第 nth 个星期, 如 1MO、-1FR
+func (rec icsRecurrence) matchMonthWeekday(day time.Time) bool {
+	daysInMonth := time.Date(day.Year(), day.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	for _, w := range rec.byDay {
+		if w.weekday != day.Weekday() {
+			continue
+		}
+		switch {
+		case w.nth == 0,
+			w.nth > 0 && (day.Day()-1)/7+1 == w.nth,
+			w.nth < 0 && (daysInMonth-day.Day())/7+1 == -w.nth:
+			return true
+		}
+	}
+	return false
+}
+
+// parseIcsLine 解析 NAME;PARAM=VALUE:VALUE 格式的内容行, 引号内的 ":" 及 ";" 属于参数值
+func parseIcsLine(line string) (string, map[string]string, string, bool) {
+	var (
+		parts  []string
+		quoted bool
+		last   int
+		idx    = -1
+	)
+	for i := 0; i < len(line) && idx < 0; i++ {
+		switch c := line[i]; {
+		case c == '"':
+			quoted = !quoted
+		case quoted:
+		case c == ';':
+			parts = append(parts, line[last:i])
+			last = i + 1
+		case c == ':':
+			parts = append(parts, line[last:i])
+			idx = i
+		}
+	}
+	if idx < 0 {
+		return "", nil, "", false
+	}
+
+	params := make(map[string]string)
+	for _, p := range parts[1:] {
+		kv := strings.SplitN(p, "=", 2)
+		if len(kv) == 2 {
+			params[strings.ToUpper(kv[0])] = strings.Trim(kv[1], `"`)
+		}
+	}
+
+	return strings.ToUpper(parts[0]), params, line[idx+1:], true
+}
+
+func parseIcsTime(value string, params map[string]string, loc *time.Location) (time.Time, bool, error) {
+	if params["VALUE"] == "DATE" || len(value) == 8 {
+		t, err := time.ParseInLocation("20060102", value, loc)
+		return t, true, err
+	}
+
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse(icsTimeLayout, value)
+		return t, false, err
+	}
+
+	if tzid, ok := params["TZID"]; ok {
+		if l, err := time.LoadLocation(tzid); err == nil {
+			loc = l
+		}
+	}
+	t, err := time.ParseInLocation("20060102T150405", value, loc)
+	return t, false, err
+}
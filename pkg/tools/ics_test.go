@@ -0,0 +1,173 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseIcsLine(t *testing.T) {
+	tests := []struct {
+		name   string
+		line   string
+		want   string
+		params map[string]string
+		value  string
+	}{
+		{
+			name:  "plain",
+			line:  "SUMMARY:值班: alice",
+			want:  "SUMMARY",
+			value: "值班: alice",
+		},
+		{
+			name:   "quoted tzid",
+			line:   `DTSTART;TZID="Asia/Shanghai":20240101T090000`,
+			want:   "DTSTART",
+			params: map[string]string{"TZID": "Asia/Shanghai"},
+			value:  "20240101T090000",
+		},
+		{
+			name:   "quoted colon and semicolon",
+			line:   `DESCRIPTION;ALTREP="http://example.com/a;b":on-call`,
+			want:   "DESCRIPTION",
+			params: map[string]string{"ALTREP": "http://example.com/a;b"},
+			value:  "on-call",
+		},
+		{
+			name:   "mailto value",
+			line:   "ATTENDEE;CN=Alice;ROLE=REQ-PARTICIPANT:mailto:alice@example.com",
+			want:   "ATTENDEE",
+			params: map[string]string{"CN": "Alice", "ROLE": "REQ-PARTICIPANT"},
+			value:  "mailto:alice@example.com",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name, params, value, ok := parseIcsLine(tt.line)
+			if !ok || name != tt.want || value != tt.value {
+				t.Fatalf("got (%q, %q, %v), want (%q, %q)", name, value, ok, tt.want, tt.value)
+			}
+			for k, v := range tt.params {
+				if params[k] != v {
+					t.Errorf("param %s = %q, want %q", k, params[k], v)
+				}
+			}
+		})
+	}
+
+	if _, _, _, ok := parseIcsLine(`X-NAME;P="a:b"`); ok {
+		t.Error("line without value separator should be rejected")
+	}
+}
+
+func TestDecodeIcsRecurrence(t *testing.T) {
+	loc, _ := time.LoadLocation("Asia/Shanghai")
+	at := func(s string) time.Time {
+		v, _ := time.ParseInLocation("2006-01-02 15:04", s, loc)
+		return v
+	}
+
+	tests := []struct {
+		name  string
+		event string
+		want  []time.Time
+	}{
+		{
+			name:  "daily count",
+			event: "DTSTART:20240101T090000\nDTEND:20240101T210000\nRRULE:FREQ=DAILY;COUNT=3",
+			want:  []time.Time{at("2024-01-01 09:00"), at("2024-01-02 09:00"), at("2024-01-03 09:00")},
+		},
+		{
+			name:  "daily interval until",
+			event: "DTSTART:20240101T090000\nDTEND:20240101T210000\nRRULE:FREQ=DAILY;INTERVAL=2;UNTIL=20240105T090000",
+			want:  []time.Time{at("2024-01-01 09:00"), at("2024-01-03 09:00"), at("2024-01-05 09:00")},
+		},
+		{
+			name:  "weekly byday",
+			event: "DTSTART:20240101T090000\nDTEND:20240101T180000\nRRULE:FREQ=WEEKLY;BYDAY=MO,WE,FR;COUNT=5",
+			want: []time.Time{
+				at("2024-01-01 09:00"), at("2024-01-03 09:00"), at("2024-01-05 09:00"),
+				at("2024-01-08 09:00"), at("2024-01-10 09:00"),
+			},
+		},
+		{
+			name:  "biweekly",
+			event: "DTSTART:20240103T090000\nDTEND:20240110T090000\nRRULE:FREQ=WEEKLY;INTERVAL=2;COUNT=3",
+			want:  []time.Time{at("2024-01-03 09:00"), at("2024-01-17 09:00"), at("2024-01-31 09:00")},
+		},
+		{
+			name:  "monthly last friday",
+			event: "DTSTART:20240126T090000\nDTEND:20240126T180000\nRRULE:FREQ=MONTHLY;BYDAY=-1FR;COUNT=3",
+			want:  []time.Time{at("2024-01-26 09:00"), at("2024-02-23 09:00"), at("2024-03-29 09:00")},
+		},
+		{
+			name:  "monthly skips short months",
+			event: "DTSTART:20240131T090000\nDTEND:20240131T180000\nRRULE:FREQ=MONTHLY;COUNT=3",
+			want:  []time.Time{at("2024-01-31 09:00"), at("2024-03-31 09:00"), at("2024-05-31 09:00")},
+		},
+		{
+			name:  "exdate",
+			event: "DTSTART:20240101T090000\nDTEND:20240101T210000\nRRULE:FREQ=DAILY;COUNT=3\nEXDATE:20240102T090000",
+			want:  []time.Time{at("2024-01-01 09:00"), at("2024-01-03 09:00")},
+		},
+		{
+			name:  "single event",
+			event: "DTSTART:20240101T090000\nDTEND:20240101T210000",
+			want:  []time.Time{at("2024-01-01 09:00")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := "BEGIN:VCALENDAR\nBEGIN:VEVENT\nUID:1\nSUMMARY:值班: alice\n" + tt.event + "\nEND:VEVENT\nEND:VCALENDAR"
+			events, err := DecodeIcs(data, loc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(events) != len(tt.want) {
+				t.Fatalf("got %d events, want %d: %v", len(events), len(tt.want), events)
+			}
+			for i, event := range events {
+				if !event.Start.Equal(tt.want[i]) {
+					t.Errorf("event %d starts at %s, want %s", i, event.Start, tt.want[i])
+				}
+				if event.End.Sub(event.Start) != events[0].End.Sub(events[0].Start) {
+					t.Errorf("event %d duration %s changed", i, event.End.Sub(event.Start))
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeIcsRecurrenceOverride(t *testing.T) {
+	data := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"BEGIN:VEVENT", "UID:1", "SUMMARY:值班: alice",
+		"DTSTART:20240101T010000Z", "DTEND:20240101T130000Z", "RRULE:FREQ=DAILY;COUNT=3",
+		"END:VEVENT",
+		"BEGIN:VEVENT", "UID:1", "SUMMARY:值班: bob", "RECURRENCE-ID:20240102T010000Z",
+		"DTSTART:20240102T020000Z", "DTEND:20240102T140000Z",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}, "\r\n")
+
+	events, err := DecodeIcs(data, time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 3 {
+		t.Fatalf("got %d events, want 3", len(events))
+	}
+	if last := events[2]; last.Summary != "值班: bob" || last.Start.Hour() != 2 {
+		t.Errorf("override not applied: %+v", last)
+	}
+}
+
+func TestDecodeIcsInvalidRRule(t *testing.T) {
+	data := "BEGIN:VEVENT\nDTSTART:20240101T090000\nRRULE:FREQ=HOURLY\nEND:VEVENT"
+	if _, err := DecodeIcs(data, time.UTC); err == nil {
+		t.Error("unsupported FREQ should fail")
+	}
+}
@@ -1,11 +1,15 @@
 package tools
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
+	"sync"
 	"time"
 	"watchAlert/config"
 
 	"github.com/dgrijalva/jwt-go"
+	"github.com/go-redis/redis"
 	"github.com/spf13/viper"
 )
 
@@ -86,3 +90,81 @@ func GetUserID(tokenStr string) string {
 
 	return token.ID
 }
+
+// AckLinkClaims 一键认领链接的声明, 绑定租户、故障中心、事件指纹及接收人
+type AckLinkClaims struct {
+	TenantId      string `json:"tid"`
+	FaultCenterId string `json:"fid"`
+	Fingerprint   string `json:"fp"`
+	Username      string `json:"user"`
+	jwt.StandardClaims
+}
+
+const (
+	// AckLinkAudience 一键认领链接 Token 的受众, 与登录 Token 区分
+	AckLinkAudience = "WatchAlert-AckLink"
+	// ackSignKeyName 一键认领链接签名密钥的 Redis Key
+	ackSignKeyName = "w8t:ackLink:signKey"
+)
+
+var (
+	ackSignKeyMux   sync.Mutex
+	ackSignKeyCache []byte
+)
+
+// AckSignKey 获取一键认领链接的签名密钥, 首次使用时生成随机密钥并保存至 Redis, 所有节点共用
+func AckSignKey(client *redis.Client) ([]byte, error) {
+	ackSignKeyMux.Lock()
+	defer ackSignKeyMux.Unlock()
+
+	if ackSignKeyCache != nil {
+		return ackSignKeyCache, nil
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	if err := client.SetNX(ackSignKeyName, hex.EncodeToString(secret), 0).Err(); err != nil {
+		return nil, err
+	}
+	key, err := client.Get(ackSignKeyName).Result()
+	if err != nil {
+		return nil, err
+	}
+	if ackSignKeyCache, err = hex.DecodeString(key); err != nil || len(ackSignKeyCache) == 0 {
+		ackSignKeyCache = nil
+		return nil, errors.New("invalid ack link sign key")
+	}
+
+	return ackSignKeyCache, nil
+}
+
+// GenerateAckToken 生成一键认领链接的 Token, Id 用于保证链接仅可使用一次
+func GenerateAckToken(key []byte, claims AckLinkClaims, expire time.Duration) (string, error) {
+	now := time.Now()
+	claims.StandardClaims = jwt.StandardClaims{
+		Id:        RandId(),
+		Audience:  AckLinkAudience,
+		ExpiresAt: now.Add(expire).Unix(),
+		IssuedAt:  now.Unix(),
+		Issuer:    AckLinkAudience,
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(key)
+}
+
+// ParseAckToken 解析一键认领链接的 Token, 同时校验签名及有效期
+func ParseAckToken(key []byte, tokenStr string) (AckLinkClaims, error) {
+	claims := AckLinkClaims{}
+	token, err := jwt.ParseWithClaims(tokenStr, &claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, errors.New("invalid signing method")
+		}
+		return key, nil
+	})
+	if err == nil && (!token.Valid || !claims.VerifyAudience(AckLinkAudience, true)) {
+		err = errors.New("invalid Token")
+	}
+	return claims, err
+}
@@ -0,0 +1,51 @@
+package tools
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+func TestParseAckToken(t *testing.T) {
+	key := []byte("0123456789abcdef0123456789abcdef")
+	claims := AckLinkClaims{TenantId: "t1", FaultCenterId: "fc-1", Fingerprint: "fp", Username: "alice"}
+
+	valid, err := GenerateAckToken(key, claims, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, _ := GenerateAckToken([]byte("another-key"), claims, time.Minute)
+	emptyKey, _ := GenerateAckToken([]byte{}, claims, time.Minute)
+	expired, _ := GenerateAckToken(key, claims, -time.Minute)
+	// 同一密钥签发的其他受众 Token
+	login, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, AckLinkClaims{
+		Fingerprint:    "fp",
+		StandardClaims: jwt.StandardClaims{Audience: AppGuardName, ExpiresAt: time.Now().Add(time.Minute).Unix()},
+	}).SignedString(key)
+
+	tests := []struct {
+		name  string
+		token string
+		ok    bool
+	}{
+		{name: "valid", token: valid, ok: true},
+		{name: "signed with another key", token: otherKey},
+		{name: "signed with empty key", token: emptyKey},
+		{name: "expired", token: expired},
+		{name: "wrong audience", token: login},
+		{name: "malformed", token: "not-a-token"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseAckToken(key, tt.token)
+			if (err == nil) != tt.ok {
+				t.Fatalf("ParseAckToken() err = %v, want ok %v", err, tt.ok)
+			}
+			if tt.ok && (got.Fingerprint != claims.Fingerprint || got.Username != claims.Username) {
+				t.Errorf("unexpected claims %+v", got)
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"watchAlert/pkg/metrics"
 
 	"github.com/go-redis/redis"
 	"github.com/zeromicro/go-zero/core/logc"
@@ -45,8 +46,9 @@ func PublishReloadMessage(ctx context.Context, client *redis.Client, channel str
 	if err != nil {
 		return fmt.Errorf("failed to publish reload message: %v", err)
 	}
+	metrics.ReloadMessages.WithLabelValues(channel, msg.Action, "published").Inc()
 
-	logc.Infof(ctx, "[Follower] 向 Leader 发布重载消息: channel=%s, action=%s, id=%s, name=%s",
+	logc.Infof(ctx, "向负责节点发布重载消息: channel=%s, action=%s, id=%s, name=%s",
 		channel, msg.Action, msg.ID, msg.Name)
 
 	return nil
@@ -79,6 +81,7 @@ func SubscribeReloadMessages(ctx context.Context, client *redis.Client, channel
 
 			logc.Infof(ctx, "[Leader] 收到重载消息: action=%s, id=%s, name=%s",
 				msg.Action, msg.ID, msg.Name)
+			metrics.ReloadMessages.WithLabelValues(channel, msg.Action, "received").Inc()
 
 			// 调用处理函数
 			handler(msg)